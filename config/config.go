@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"maps"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/kelseyhightower/envconfig"
@@ -24,6 +25,7 @@ type ClusterConfig struct {
 	KafkaClusterCertChainFile string
 	KafkaClusterCertKeyFile   string
 	KafkaClusterCaFile        string
+	ScramRestorePasswords     map[string]string // Passwords to restore SCRAM credentials with after an invalidation attack, keyed by user
 }
 
 // Specification is the configuration specification for the extension. Configuration values can be applied
@@ -38,12 +40,18 @@ type Specification struct {
 	KafkaClusterCertChainFile                 string   `json:"kafkaClusterCertChainFile" required:"false" split_words:"true"`
 	KafkaClusterCertKeyFile                   string   `json:"kafkaClusterCertKeyFile" required:"false" split_words:"true"`
 	KafkaClusterCaFile                        string   `json:"kafkaClusterCaFile" required:"false" split_words:"true"`
+	ScramRestorePasswords                     string   `json:"scramRestorePasswords" required:"false" split_words:"true"`
 	DiscoveryIntervalConsumerGroup            int      `json:"discoveryIntervalKafkaConsumerGroup" split_words:"true" required:"false" default:"30"`
 	DiscoveryIntervalKafkaBroker              int      `json:"discoveryIntervalKafkaBroker" split_words:"true" required:"false" default:"30"`
 	DiscoveryIntervalKafkaTopic               int      `json:"discoveryIntervalKafkaTopic" split_words:"true" required:"false" default:"30"`
+	DiscoveryIntervalKafkaScramUser           int      `json:"discoveryIntervalKafkaScramUser" split_words:"true" required:"false" default:"60"`
+	DiscoveryIntervalKafkaCluster             int      `json:"discoveryIntervalKafkaCluster" split_words:"true" required:"false" default:"30"`
 	DiscoveryAttributesExcludesBrokers        []string `json:"discoveryAttributesExcludesBrokers" split_words:"true" required:"false"`
 	DiscoveryAttributesExcludesTopics         []string `json:"discoveryAttributesExcludesTopics" split_words:"true" required:"false"`
 	DiscoveryAttributesExcludesConsumerGroups []string `json:"discoveryAttributesExcludesConsumerGroups" split_words:"true" required:"false"`
+	DiscoveryAttributesExcludesScramUsers     []string `json:"discoveryAttributesExcludesScramUsers" split_words:"true" required:"false"`
+	DiscoveryAttributesExcludesClusters       []string `json:"discoveryAttributesExcludesClusters" split_words:"true" required:"false"`
+	ActiveAdviceList                          []string `json:"activeAdviceList" split_words:"true" required:"false" default:"*"`
 
 	// Clusters is a map of cluster name to cluster configuration. Populated by parseClusterConfigs().
 	Clusters map[string]*ClusterConfig `json:"clusters" ignored:"true"`
@@ -90,6 +98,7 @@ func ParseConfiguration() {
 			KafkaClusterCertChainFile: Config.KafkaClusterCertChainFile,
 			KafkaClusterCertKeyFile:   Config.KafkaClusterCertKeyFile,
 			KafkaClusterCaFile:        Config.KafkaClusterCaFile,
+			ScramRestorePasswords:     parseScramRestorePasswords(Config.ScramRestorePasswords),
 		}
 
 		clusterID, err := getClusterName(legacyCluster)
@@ -136,6 +145,7 @@ func parseClusterConfigs() map[string]*ClusterConfig {
 			KafkaClusterCertChainFile: os.Getenv(prefix + "KAFKA_CLUSTER_CERT_CHAIN_FILE"),
 			KafkaClusterCertKeyFile:   os.Getenv(prefix + "KAFKA_CLUSTER_CERT_KEY_FILE"),
 			KafkaClusterCaFile:        os.Getenv(prefix + "KAFKA_CLUSTER_CA_FILE"),
+			ScramRestorePasswords:     parseScramRestorePasswords(os.Getenv(prefix + "SCRAM_RESTORE_PASSWORDS")),
 		}
 
 		// Get cluster name from env var, fall back to index if not set
@@ -168,6 +178,30 @@ func parseClusterConfigs() map[string]*ClusterConfig {
 	return clusters
 }
 
+// parseScramRestorePasswords parses a comma separated list of user:password pairs. Only the first colon separates
+// user and password, so passwords may contain colons.
+func parseScramRestorePasswords(value string) map[string]string {
+	if value == "" {
+		return nil
+	}
+	passwords := make(map[string]string)
+	for pair := range strings.SplitSeq(value, ",") {
+		user, password, found := strings.Cut(pair, ":")
+		if !found || user == "" {
+			log.Warn().Msg("Ignoring SCRAM restore password entry without user, expected format is user:password")
+			continue
+		}
+		passwords[user] = password
+	}
+	return passwords
+}
+
+// GetScramRestorePassword returns the password configured to restore the SCRAM credential of the given user
+func (c *ClusterConfig) GetScramRestorePassword(user string) (string, bool) {
+	password, ok := c.ScramRestorePasswords[user]
+	return password, ok && password != ""
+}
+
 // getClusterName connects to a cluster and retrieves its name from Kafka metadata
 // Note: This will be set by the extkafka package during initialization to avoid import cycles
 var getClusterName func(*ClusterConfig) (string, error)
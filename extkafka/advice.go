@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/steadybit/advice-kit/go/advice_kit_api"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/exthttp"
+)
+
+const adviceIdPrefix = "com.steadybit.extension_kafka.advice"
+
+// kafkaAdvice is a best practice the platform evaluates against the discovered targets.
+type kafkaAdvice struct {
+	Id       string
+	Describe func() advice_kit_api.AdviceDefinition
+}
+
+func allAdvice() []kafkaAdvice {
+	return []kafkaAdvice{
+		{Id: topicReplicationFactorAdviceId, Describe: getTopicReplicationFactorAdvice},
+		{Id: topicMinInSyncReplicasAdviceId, Describe: getTopicMinInSyncReplicasAdvice},
+		{Id: topicUncleanLeaderElectionAdviceId, Describe: getTopicUncleanLeaderElectionAdvice},
+		{Id: topicRackAwarenessAdviceId, Describe: getTopicRackAwarenessAdvice},
+		{Id: clusterSingleBrokerAdviceId, Describe: getClusterSingleBrokerAdvice},
+		{Id: consumerGroupLagCheckAdviceId, Describe: getConsumerGroupLagCheckAdvice},
+	}
+}
+
+// activeAdvice returns the advice enabled by STEADYBIT_EXTENSION_ACTIVE_ADVICE_LIST.
+func activeAdvice() []kafkaAdvice {
+	active := config.Config.ActiveAdviceList
+	return slices.DeleteFunc(allAdvice(), func(advice kafkaAdvice) bool {
+		return !slices.Contains(active, "*") && !slices.Contains(active, advice.Id)
+	})
+}
+
+func advicePath(id string) string {
+	return "/advice/" + strings.TrimPrefix(id, adviceIdPrefix+".")
+}
+
+func RegisterAdviceHandlers() {
+	for _, advice := range activeAdvice() {
+		exthttp.RegisterHttpHandler(advicePath(advice.Id), exthttp.GetterAsHandler(advice.Describe))
+	}
+}
+
+func GetAdviceList() advice_kit_api.AdviceList {
+	refs := make([]advice_kit_api.DescribingEndpointReference, 0, len(allAdvice()))
+	for _, advice := range activeAdvice() {
+		refs = append(refs, advice_kit_api.DescribingEndpointReference{
+			Method: "GET",
+			Path:   advicePath(advice.Id),
+		})
+	}
+	return advice_kit_api.AdviceList{Advice: refs}
+}
+
+// adviceTargetAttr references an attribute of the target the advice is shown for, the platform resolves it in
+// texts and suggested experiments.
+func adviceTargetAttr(attribute string) string {
+	return fmt.Sprintf("${target.attr('%s')}", attribute)
+}
+
+func adviceValidation(id string, name string, shortDescription string, experiment advice_kit_api.Experiment) advice_kit_api.Validation {
+	return advice_kit_api.Validation{
+		Id:               id,
+		Name:             name,
+		ShortDescription: shortDescription,
+		Type:             "EXPERIMENT",
+		Experiment:       new(experiment),
+	}
+}
+
+// adviceExperiment builds a suggested experiment, every lane is a sequence of steps running in parallel to the others.
+func adviceExperiment(name string, hypothesis string, lanes ...[]map[string]any) advice_kit_api.Experiment {
+	experimentLanes := make([]map[string]any, 0, len(lanes))
+	for _, steps := range lanes {
+		experimentLanes = append(experimentLanes, map[string]any{"steps": steps})
+	}
+	return advice_kit_api.Experiment{
+		"name":       name,
+		"hypothesis": hypothesis,
+		"lanes":      experimentLanes,
+	}
+}
+
+// adviceStep is an action step of a suggested experiment. It targets all targets of the target type sharing the
+// given attributes with the target the advice is shown for.
+func adviceStep(actionType string, targetType string, parameters map[string]any, attributes ...string) map[string]any {
+	predicates := make([]map[string]any, 0, len(attributes))
+	for _, attribute := range attributes {
+		predicates = append(predicates, map[string]any{
+			"key":      attribute,
+			"operator": "EQUALS",
+			"values":   []string{adviceTargetAttr(attribute)},
+		})
+	}
+	return map[string]any{
+		"type":          "action",
+		"actionType":    actionType,
+		"ignoreFailure": false,
+		"parameters":    parameters,
+		"radius": map[string]any{
+			"targetType": targetType,
+			"predicate": map[string]any{
+				"operator":   "AND",
+				"predicates": predicates,
+			},
+			"percentage": 100,
+		},
+	}
+}
+
+func adviceWaitStep(duration string) map[string]any {
+	return map[string]any{
+		"type":          "wait",
+		"ignoreFailure": false,
+		"parameters":    map[string]any{"duration": duration},
+	}
+}
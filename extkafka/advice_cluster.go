@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"fmt"
+
+	"github.com/steadybit/advice-kit/go/advice_kit_api"
+	"github.com/steadybit/extension-kit/extbuild"
+)
+
+const clusterSingleBrokerAdviceId = adviceIdPrefix + ".cluster-single-broker"
+
+func getClusterSingleBrokerAdvice() advice_kit_api.AdviceDefinition {
+	clusterName := adviceTargetAttr("kafka.cluster.name")
+	return advice_kit_api.AdviceDefinition{
+		Id:                        clusterSingleBrokerAdviceId,
+		Label:                     "Multiple Brokers",
+		Version:                   extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:                      kafkaIcon,
+		Tags:                      new([]string{"kafka", "cluster", "availability"}),
+		AssessmentQueryApplicable: fmt.Sprintf("target.type=\"%s\" AND kafka.cluster.broker-count IS PRESENT", kafkaClusterTargetId),
+		Status: advice_kit_api.AdviceDefinitionStatus{
+			ActionNeeded: advice_kit_api.AdviceDefinitionStatusActionNeeded{
+				AssessmentQuery: "kafka.cluster.broker-count=\"1\"",
+				Summary:         fmt.Sprintf("Cluster %s runs on a single broker", clusterName),
+				Motivation:      "A single broker is a single point of failure. Every restart, crash or disk failure of the broker makes all topics unavailable, and replication can't protect the data.",
+				Description:     fmt.Sprintf("Cluster %s has exactly one registered broker, so no topic can have more than one replica.", clusterName),
+				Instruction:     "Run at least three brokers, spread across racks or availability zones, and increase the replication factor of the topics to 3.",
+			},
+			ValidationNeeded: advice_kit_api.AdviceDefinitionStatusValidationNeeded{
+				Summary:     fmt.Sprintf("Validate that cluster %s survives the loss of a broker", clusterName),
+				Description: "Analyze for every broker of the cluster which topics would become unavailable if the broker failed.",
+				Validation: new([]advice_kit_api.Validation{
+					adviceValidation(clusterSingleBrokerAdviceId+".experiment-1",
+						"Cluster survives the loss of any broker",
+						"Check the impact of losing each broker on all topics.",
+						adviceExperiment(
+							fmt.Sprintf("Cluster %s survives the loss of any broker", clusterName),
+							"No topic goes offline or falls under min.insync.replicas if any single broker fails.",
+							[]map[string]any{
+								adviceStep(fmt.Sprintf("%s.check-loss-impact", kafkaBrokerTargetId), kafkaBrokerTargetId, map[string]any{
+									"scope": brokerLossScopeBroker,
+								}, adviceClusterPredicate...),
+							},
+						)),
+				}),
+			},
+			Implemented: advice_kit_api.AdviceDefinitionStatusImplemented{
+				Summary:     fmt.Sprintf("Cluster %s survives the loss of a broker", clusterName),
+				Description: "The cluster runs on multiple brokers and an experiment validated that the topics stay available if a broker fails.",
+			},
+		},
+	}
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"fmt"
+
+	"github.com/steadybit/advice-kit/go/advice_kit_api"
+	"github.com/steadybit/extension-kit/extbuild"
+)
+
+const consumerGroupLagCheckAdviceId = adviceIdPrefix + ".consumer-group-lag-check"
+
+// getConsumerGroupLagCheckAdvice asks to observe the lag of every consumer group in an experiment. Action is needed for
+// groups with a single member, no other consumer can take over its partitions.
+func getConsumerGroupLagCheckAdvice() advice_kit_api.AdviceDefinition {
+	groupName := adviceTargetAttr("kafka.consumer-group.name")
+	return advice_kit_api.AdviceDefinition{
+		Id:                        consumerGroupLagCheckAdviceId,
+		Label:                     "Consumer Group Lag",
+		Version:                   extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:                      kafkaIcon,
+		Tags:                      new([]string{"kafka", "consumer group", "lag"}),
+		AssessmentQueryApplicable: fmt.Sprintf("target.type=\"%s\" AND kafka.consumer-group.members IS PRESENT", kafkaConsumerTargetId),
+		Status: advice_kit_api.AdviceDefinitionStatus{
+			ActionNeeded: advice_kit_api.AdviceDefinitionStatusActionNeeded{
+				AssessmentQuery: "kafka.consumer-group.members=\"1\"",
+				Summary:         fmt.Sprintf("Consumer group %s has a single member", groupName),
+				Motivation:      "If the only consumer of a group crashes or is restarted, no other member takes over its partitions and the lag grows until the consumer is back.",
+				Description:     fmt.Sprintf("Consumer group %s has exactly one member, all assigned partitions are consumed by it.", groupName),
+				Instruction:     "Run at least two instances of the consuming application with the same `group.id`, so that the partitions are rebalanced to the remaining members if one fails.",
+			},
+			ValidationNeeded: advice_kit_api.AdviceDefinitionStatusValidationNeeded{
+				Summary:     fmt.Sprintf("Validate that consumer group %s keeps up while the brokers are degraded", groupName),
+				Description: "A consumer group that falls behind delays all downstream processing. Check the lag of the group while the disk I/O capacity of the brokers is limited, to verify it keeps up or recovers in time.",
+				Validation: new([]advice_kit_api.Validation{
+					adviceValidation(consumerGroupLagCheckAdviceId+".experiment-1",
+						"Consumer group keeps up while the brokers are degraded",
+						"Check the consumer lag while limiting the I/O threads of the brokers.",
+						adviceExperiment(
+							fmt.Sprintf("Consumer group %s keeps up while the brokers are degraded", groupName),
+							"The lag of the consumer group stays within the acceptable lag while the disk I/O of the brokers is limited.",
+							[]map[string]any{
+								adviceStep(fmt.Sprintf("%s.check-lag", kafkaConsumerTargetId), kafkaConsumerTargetId, map[string]any{
+									"duration":      "90s",
+									"acceptableLag": 1000,
+									"failEarly":     false,
+								}, "kafka.cluster.name", "kafka.consumer-group.name"),
+							},
+							[]map[string]any{
+								adviceWaitStep("10s"),
+								adviceStep(fmt.Sprintf("%s.limit-io-threads", kafkaBrokerTargetId), kafkaBrokerTargetId, map[string]any{
+									"duration":   "60s",
+									"io_threads": 1,
+								}, adviceClusterPredicate...),
+							},
+						)),
+				}),
+			},
+			Implemented: advice_kit_api.AdviceDefinitionStatusImplemented{
+				Summary:     fmt.Sprintf("The lag of consumer group %s is validated", groupName),
+				Description: "An experiment validated that the consumer group keeps up while the brokers are degraded.",
+			},
+		},
+	}
+}
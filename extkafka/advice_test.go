@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAdviceList(t *testing.T) {
+	defer func() { config.Config.ActiveAdviceList = nil }()
+
+	config.Config.ActiveAdviceList = []string{"*"}
+	assert.Len(t, GetAdviceList().Advice, len(allAdvice()))
+
+	config.Config.ActiveAdviceList = []string{topicReplicationFactorAdviceId}
+	list := GetAdviceList()
+	require.Len(t, list.Advice, 1)
+	assert.Equal(t, "/advice/topic-replication-factor", list.Advice[0].Path)
+
+	config.Config.ActiveAdviceList = nil
+	assert.Empty(t, GetAdviceList().Advice)
+}
+
+func TestAdvice_Describe(t *testing.T) {
+	actions := map[string]action_kit_api.ActionDescription{}
+	for _, description := range []action_kit_api.ActionDescription{
+		NewBrokerLossImpactCheckAction().Describe(),
+		NewProduceCanaryCheckAction().Describe(),
+		NewAlterReplicationThrottleAttack().Describe(),
+		NewTopicThroughputCheckAction().Describe(),
+		NewKafkaBrokerElectNewLeaderAttack().Describe(),
+		NewRackAwarenessCheckAction().Describe(),
+		NewConsumerGroupLagCheckAction().Describe(),
+		NewAlterNumberIOThreadsAttack().Describe(),
+	} {
+		actions[description.Id] = description
+	}
+
+	for _, advice := range allAdvice() {
+		definition := advice.Describe()
+
+		assert.Equal(t, advice.Id, definition.Id)
+		assert.True(t, strings.HasPrefix(definition.Id, adviceIdPrefix+"."), definition.Id)
+		assert.NotEmpty(t, definition.Label)
+		assert.NotEmpty(t, definition.Status.ActionNeeded.AssessmentQuery)
+		require.NotNil(t, definition.Status.ValidationNeeded.Validation, definition.Id)
+		for _, validation := range *definition.Status.ValidationNeeded.Validation {
+			assert.True(t, strings.HasPrefix(validation.Id, definition.Id+"."), validation.Id)
+			require.NotNil(t, validation.Experiment)
+			// Every suggested step must be a valid step of an action of this extension
+			for _, lane := range (*validation.Experiment)["lanes"].([]map[string]any) {
+				for _, step := range lane["steps"].([]map[string]any) {
+					if step["type"] != "action" {
+						continue
+					}
+					action, ok := actions[step["actionType"].(string)]
+					if assert.True(t, ok, "unknown action %s in %s", step["actionType"], validation.Id) {
+						assertAdviceStepMatches(t, action, step, validation.Id)
+					}
+				}
+			}
+		}
+	}
+}
+
+// identifyingAttributes select exactly one target of the target type.
+var identifyingAttributes = map[string][]string{
+	kafkaTopicTargetId:    {"kafka.cluster.name", "kafka.topic.name"},
+	kafkaBrokerTargetId:   {"kafka.cluster.name", "kafka.broker.node-id"},
+	kafkaConsumerTargetId: {"kafka.cluster.name", "kafka.consumer-group.name"},
+}
+
+func assertAdviceStepMatches(t *testing.T, action action_kit_api.ActionDescription, step map[string]any, validationId string) {
+	radius := step["radius"].(map[string]any)
+	require.NotNil(t, action.TargetSelection, action.Id)
+	assert.Equal(t, action.TargetSelection.TargetType, radius["targetType"], "target type of %s in %s", action.Id, validationId)
+
+	if action.TargetSelection.QuantityRestriction != nil && *action.TargetSelection.QuantityRestriction == action_kit_api.QuantityRestrictionExactlyOne {
+		var keys []string
+		for _, predicate := range radius["predicate"].(map[string]any)["predicates"].([]map[string]any) {
+			keys = append(keys, predicate["key"].(string))
+		}
+		for _, attribute := range identifyingAttributes[action.TargetSelection.TargetType] {
+			assert.Contains(t, keys, attribute, "%s accepts exactly one target, but the step in %s can select several", action.Id, validationId)
+		}
+	}
+
+	for name, value := range step["parameters"].(map[string]any) {
+		idx := slices.IndexFunc(action.Parameters, func(parameter action_kit_api.ActionParameter) bool {
+			return parameter.Name == name
+		})
+		if !assert.GreaterOrEqual(t, idx, 0, "unknown parameter %s of %s in %s", name, action.Id, validationId) {
+			continue
+		}
+		switch parameterType := action.Parameters[idx].Type; parameterType {
+		case action_kit_api.ActionParameterTypeString:
+			assert.IsType(t, "", value, "parameter %s of %s in %s", name, action.Id, validationId)
+		case action_kit_api.ActionParameterTypeStringArray:
+			assert.IsType(t, []string{}, value, "parameter %s of %s in %s", name, action.Id, validationId)
+		case action_kit_api.ActionParameterTypeInteger, action_kit_api.ActionParameterTypePercentage:
+			assert.IsType(t, 0, value, "parameter %s of %s in %s", name, action.Id, validationId)
+		case action_kit_api.ActionParameterTypeBoolean:
+			assert.IsType(t, false, value, "parameter %s of %s in %s", name, action.Id, validationId)
+		case action_kit_api.ActionParameterTypeDuration:
+			_, err := time.ParseDuration(value.(string))
+			assert.NoError(t, err, "parameter %s of %s in %s", name, action.Id, validationId)
+		default:
+			assert.Fail(t, "unexpected parameter type", "%s of parameter %s of %s in %s", parameterType, name, action.Id, validationId)
+		}
+	}
+}
+
+func TestAdviceStep(t *testing.T) {
+	step := adviceStep("com.steadybit.extension_kafka.topic.check-throughput", kafkaTopicTargetId, map[string]any{"duration": "60s"}, "kafka.cluster.name")
+
+	assert.Equal(t, map[string]any{
+		"type":          "action",
+		"actionType":    "com.steadybit.extension_kafka.topic.check-throughput",
+		"ignoreFailure": false,
+		"parameters":    map[string]any{"duration": "60s"},
+		"radius": map[string]any{
+			"targetType": kafkaTopicTargetId,
+			"predicate": map[string]any{
+				"operator": "AND",
+				"predicates": []map[string]any{{
+					"key":      "kafka.cluster.name",
+					"operator": "EQUALS",
+					"values":   []string{"${target.attr('kafka.cluster.name')}"},
+				}},
+			},
+			"percentage": 100,
+		},
+	}, step)
+}
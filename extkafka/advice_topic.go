@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"fmt"
+
+	"github.com/steadybit/advice-kit/go/advice_kit_api"
+	"github.com/steadybit/extension-kit/extbuild"
+)
+
+const (
+	topicReplicationFactorAdviceId     = adviceIdPrefix + ".topic-replication-factor"
+	topicMinInSyncReplicasAdviceId     = adviceIdPrefix + ".topic-min-insync-replicas"
+	topicUncleanLeaderElectionAdviceId = adviceIdPrefix + ".topic-unclean-leader-election"
+	topicRackAwarenessAdviceId         = adviceIdPrefix + ".topic-rack-awareness"
+)
+
+// Ephemeral topics are created by experiments and don't need to be resilient.
+var topicAdviceApplicable = fmt.Sprintf("target.type=\"%s\" AND kafka.topic.ephemeral IS NOT PRESENT", kafkaTopicTargetId)
+
+var (
+	adviceTopicName        = adviceTargetAttr("kafka.topic.name")
+	adviceTopicPredicate   = []string{"kafka.cluster.name", "kafka.topic.name"}
+	adviceClusterPredicate = []string{"kafka.cluster.name"}
+)
+
+func getTopicReplicationFactorAdvice() advice_kit_api.AdviceDefinition {
+	return advice_kit_api.AdviceDefinition{
+		Id:                        topicReplicationFactorAdviceId,
+		Label:                     "Topic Replication Factor",
+		Version:                   extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:                      kafkaIcon,
+		Tags:                      new([]string{"kafka", "topic", "replication"}),
+		AssessmentQueryApplicable: topicAdviceApplicable,
+		Status: advice_kit_api.AdviceDefinitionStatus{
+			ActionNeeded: advice_kit_api.AdviceDefinitionStatusActionNeeded{
+				AssessmentQuery: "kafka.topic.replication-factor=\"1\" OR kafka.topic.replication-factor=\"2\"",
+				Summary:         fmt.Sprintf("Topic %s has a replication factor of %s", adviceTopicName, adviceTargetAttr("kafka.topic.replication-factor")),
+				Motivation:      "With fewer than three replicas, a topic can't survive a broker failure while another broker is being restarted for maintenance. With a single replica, every broker failure takes the partitions offline and may lose their data.",
+				Description:     fmt.Sprintf("The partitions of topic %s are replicated to %s broker(s). A replication factor of 3 together with min.insync.replicas=2 keeps the topic writable and durable if a broker fails.", adviceTopicName, adviceTargetAttr("kafka.topic.replication-factor")),
+				Instruction:     "Increase the replication factor to 3 by reassigning the partitions, e.g. with `kafka-reassign-partitions.sh`, and set `default.replication.factor=3` on the brokers for new topics.",
+			},
+			ValidationNeeded: advice_kit_api.AdviceDefinitionStatusValidationNeeded{
+				Summary:     fmt.Sprintf("Validate that topic %s stays available if a broker fails", adviceTopicName),
+				Description: "Analyze for every broker of the cluster whether the topic would go offline or fall under min.insync.replicas if the broker failed.",
+				Validation: new([]advice_kit_api.Validation{
+					adviceValidation(topicReplicationFactorAdviceId+".experiment-1",
+						"Topic survives the loss of any broker",
+						"Check the impact of losing each broker on the topic.",
+						adviceExperiment(
+							fmt.Sprintf("Topic %s survives the loss of any broker", adviceTopicName),
+							"All partitions of the topic stay online and writable with acks=all if any single broker fails.",
+							[]map[string]any{
+								adviceStep(fmt.Sprintf("%s.check-loss-impact", kafkaBrokerTargetId), kafkaBrokerTargetId, map[string]any{
+									"scope":          brokerLossScopeBroker,
+									"criticalTopics": []string{adviceTopicName},
+								}, adviceClusterPredicate...),
+							},
+						)),
+				}),
+			},
+			Implemented: advice_kit_api.AdviceDefinitionStatusImplemented{
+				Summary:     fmt.Sprintf("Topic %s is replicated and validated to survive a broker failure", adviceTopicName),
+				Description: "The topic has at least three replicas and an experiment validated that it stays available if a broker fails.",
+			},
+		},
+	}
+}
+
+func getTopicMinInSyncReplicasAdvice() advice_kit_api.AdviceDefinition {
+	return advice_kit_api.AdviceDefinition{
+		Id:                        topicMinInSyncReplicasAdviceId,
+		Label:                     "Topic min.insync.replicas",
+		Version:                   extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:                      kafkaIcon,
+		Tags:                      new([]string{"kafka", "topic", "durability"}),
+		AssessmentQueryApplicable: topicAdviceApplicable + " AND kafka.topic.min-insync-replicas IS PRESENT",
+		Status: advice_kit_api.AdviceDefinitionStatus{
+			ActionNeeded: advice_kit_api.AdviceDefinitionStatusActionNeeded{
+				AssessmentQuery: "kafka.topic.min-insync-replicas=\"1\" OR kafka.topic.broker-failures-tolerated=\"0\"",
+				Summary:         fmt.Sprintf("Topic %s has min.insync.replicas=%s with a replication factor of %s", adviceTopicName, adviceTargetAttr("kafka.topic.min-insync-replicas"), adviceTargetAttr("kafka.topic.replication-factor")),
+				Motivation:      "With min.insync.replicas=1, a record acknowledged with acks=all may exist on a single broker only and is lost if that broker fails. With min.insync.replicas equal to the replication factor, acks=all producers fail as soon as any replica falls behind, e.g. during a rolling restart.",
+				Description:     fmt.Sprintf("Topic %s requires %s in-sync replica(s) out of %s for acks=all writes. The recommended setting is one less than the replication factor, e.g. 2 for a replication factor of 3.", adviceTopicName, adviceTargetAttr("kafka.topic.min-insync-replicas"), adviceTargetAttr("kafka.topic.replication-factor")),
+				Instruction:     "Set `min.insync.replicas` of the topic to the replication factor minus one, e.g. with `kafka-configs.sh --alter --entity-type topics --add-config min.insync.replicas=2`, and make sure the producers use `acks=all`.",
+			},
+			ValidationNeeded: advice_kit_api.AdviceDefinitionStatusValidationNeeded{
+				Summary:     fmt.Sprintf("Validate that topic %s stays writable if a replica falls behind", adviceTopicName),
+				Description: "Throttle the replication of the topic so that followers drop out of the ISR, while probing every partition with acks=all produce requests.",
+				Validation: new([]advice_kit_api.Validation{
+					adviceValidation(topicMinInSyncReplicasAdviceId+".experiment-1",
+						"Topic stays writable while a replica falls behind",
+						"Throttle the replication while producing with acks=all.",
+						adviceExperiment(
+							fmt.Sprintf("Topic %s stays writable while a replica falls behind", adviceTopicName),
+							"acks=all producers keep succeeding while a follower of every partition is out of sync.",
+							[]map[string]any{
+								adviceStep(fmt.Sprintf("%s.check-produce-canary", kafkaTopicTargetId), kafkaTopicTargetId, map[string]any{
+									"duration":      "90s",
+									"probeInterval": "1s",
+									"maxUnwritable": "10s",
+									"probeTimeout":  "5s",
+								}, adviceTopicPredicate...),
+							},
+							[]map[string]any{
+								adviceWaitStep("10s"),
+								adviceStep(fmt.Sprintf("%s.throttle-replication", kafkaTopicTargetId), kafkaTopicTargetId, map[string]any{
+									"duration":     "60s",
+									"throttleRate": 1024,
+								}, adviceTopicPredicate...),
+							},
+						)),
+				}),
+			},
+			Implemented: advice_kit_api.AdviceDefinitionStatusImplemented{
+				Summary:     fmt.Sprintf("Topic %s tolerates replicas falling behind without losing acknowledged records", adviceTopicName),
+				Description: "min.insync.replicas is between 2 and the replication factor minus one and an experiment validated that producers keep succeeding while a replica falls behind.",
+			},
+		},
+	}
+}
+
+func getTopicUncleanLeaderElectionAdvice() advice_kit_api.AdviceDefinition {
+	return advice_kit_api.AdviceDefinition{
+		Id:                        topicUncleanLeaderElectionAdviceId,
+		Label:                     "Topic Unclean Leader Election",
+		Version:                   extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:                      kafkaIcon,
+		Tags:                      new([]string{"kafka", "topic", "durability"}),
+		AssessmentQueryApplicable: topicAdviceApplicable + " AND kafka.topic.unclean-leader-election IS PRESENT",
+		Status: advice_kit_api.AdviceDefinitionStatus{
+			ActionNeeded: advice_kit_api.AdviceDefinitionStatusActionNeeded{
+				AssessmentQuery: "kafka.topic.unclean-leader-election=\"true\"",
+				Summary:         fmt.Sprintf("Topic %s allows unclean leader elections", adviceTopicName),
+				Motivation:      "An unclean leader election makes an out-of-sync replica the leader if no in-sync replica is left. The partition becomes available again, but all records the new leader didn't replicate yet are lost, even if they were acknowledged with acks=all.",
+				Description:     fmt.Sprintf("Topic %s has unclean.leader.election.enable=true, favoring availability over durability.", adviceTopicName),
+				Instruction:     "Set `unclean.leader.election.enable=false` on the topic, unless losing acknowledged records is acceptable for it. Prefer more replicas over unclean leader elections to stay available.",
+			},
+			ValidationNeeded: advice_kit_api.AdviceDefinitionStatusValidationNeeded{
+				Summary:     fmt.Sprintf("Validate that the leader elections of topic %s don't disrupt producers", adviceTopicName),
+				Description: "Elect a new leader for a partition of the topic while observing the throughput and the partitions of the topic.",
+				Validation: new([]advice_kit_api.Validation{
+					adviceValidation(topicUncleanLeaderElectionAdviceId+".experiment-1",
+						"Topic keeps its throughput during a leader election",
+						"Elect a new leader while checking the topic throughput.",
+						adviceExperiment(
+							fmt.Sprintf("Topic %s keeps its throughput during a leader election", adviceTopicName),
+							"The leader moves to an in-sync replica and producers keep writing to the topic.",
+							[]map[string]any{
+								adviceStep(fmt.Sprintf("%s.check-throughput", kafkaTopicTargetId), kafkaTopicTargetId, map[string]any{
+									"duration":            "60s",
+									"minRecordsPerSecond": 1,
+									"maxStalled":          "30s",
+								}, adviceTopicPredicate...),
+							},
+							[]map[string]any{
+								adviceWaitStep("10s"),
+								adviceStep(fmt.Sprintf("%s.elect-new-leader", kafkaTopicTargetId), kafkaTopicTargetId, map[string]any{
+									"partitions": "0",
+								}, adviceTopicPredicate...),
+							},
+						)),
+				}),
+			},
+			Implemented: advice_kit_api.AdviceDefinitionStatusImplemented{
+				Summary:     fmt.Sprintf("Topic %s only elects in-sync leaders", adviceTopicName),
+				Description: "Unclean leader elections are disabled and an experiment validated that leader elections don't disrupt the topic.",
+			},
+		},
+	}
+}
+
+func getTopicRackAwarenessAdvice() advice_kit_api.AdviceDefinition {
+	return advice_kit_api.AdviceDefinition{
+		Id:                        topicRackAwarenessAdviceId,
+		Label:                     "Topic Rack-Aware Replica Placement",
+		Version:                   extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:                      kafkaIcon,
+		Tags:                      new([]string{"kafka", "topic", "rack", "availability zone"}),
+		AssessmentQueryApplicable: topicAdviceApplicable + " AND kafka.topic.rack-aware IS PRESENT",
+		Status: advice_kit_api.AdviceDefinitionStatus{
+			ActionNeeded: advice_kit_api.AdviceDefinitionStatusActionNeeded{
+				AssessmentQuery: "kafka.topic.rack-aware=\"false\"",
+				Summary:         fmt.Sprintf("The replicas of topic %s aren't spread across racks", adviceTopicName),
+				Motivation:      "Racks, or availability zones, fail as a whole. If all in-sync replicas of a partition are in the same rack, the partition goes offline or becomes read-only when the rack fails.",
+				Description:     fmt.Sprintf("At least one partition of topic %s has replicas on brokers without broker.rack, spans fewer than two racks or would fall under min.insync.replicas if a single rack failed.", adviceTopicName),
+				Instruction:     "Set `broker.rack` on every broker to its rack or availability zone and reassign the partitions of the topic, so that the replicas of every partition are spread across at least two racks.",
+			},
+			ValidationNeeded: advice_kit_api.AdviceDefinitionStatusValidationNeeded{
+				Summary:     fmt.Sprintf("Validate that topic %s survives the loss of a rack", adviceTopicName),
+				Description: "Check the replica placement of the topic and analyze the impact of losing each rack.",
+				Validation: new([]advice_kit_api.Validation{
+					adviceValidation(topicRackAwarenessAdviceId+".experiment-1",
+						"Topic survives the loss of a rack",
+						"Check the rack-aware replica placement and the impact of losing each rack.",
+						adviceExperiment(
+							fmt.Sprintf("Topic %s survives the loss of a rack", adviceTopicName),
+							"The replicas of every partition span multiple racks and the topic stays available if any rack fails.",
+							[]map[string]any{
+								adviceStep(fmt.Sprintf("%s.check-rack-awareness", kafkaTopicTargetId), kafkaTopicTargetId, map[string]any{
+									"minRacks":  2,
+									"allTopics": false,
+								}, adviceTopicPredicate...),
+								adviceStep(fmt.Sprintf("%s.check-loss-impact", kafkaBrokerTargetId), kafkaBrokerTargetId, map[string]any{
+									"scope":          brokerLossScopeRack,
+									"criticalTopics": []string{adviceTopicName},
+								}, adviceClusterPredicate...),
+							},
+						)),
+				}),
+			},
+			Implemented: advice_kit_api.AdviceDefinitionStatusImplemented{
+				Summary:     fmt.Sprintf("Topic %s is rack-aware", adviceTopicName),
+				Description: "The replicas of every partition are spread across racks and an experiment validated that the topic survives the loss of a rack.",
+			},
+		},
+	}
+}
@@ -0,0 +1,247 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+type AlterReplicationThrottleAttack struct{}
+
+type ReplicationThrottleState struct {
+	Topic                   string
+	BrokerIDs               []int32
+	ThrottleRate            int64
+	InitialLeaderRates      map[int32]string // Dynamic broker overrides before the attack, empty if none
+	InitialFollowerRates    map[int32]string // Dynamic broker overrides before the attack, empty if none
+	InitialLeaderReplicas   string           // Topic override before the attack, empty if none
+	InitialFollowerReplicas string           // Topic override before the attack, empty if none
+	BrokerHosts             []string
+	ClusterName             string // Cluster name for multi-cluster support
+}
+
+const (
+	LeaderReplicationThrottledRate       = "leader.replication.throttled.rate"
+	FollowerReplicationThrottledRate     = "follower.replication.throttled.rate"
+	LeaderReplicationThrottledReplicas   = "leader.replication.throttled.replicas"
+	FollowerReplicationThrottledReplicas = "follower.replication.throttled.replicas"
+
+	// Throttles the replication of all replicas of the topic
+	allThrottledReplicas = "*"
+)
+
+// Make sure Action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[ReplicationThrottleState]         = (*AlterReplicationThrottleAttack)(nil)
+	_ action_kit_sdk.ActionWithStop[ReplicationThrottleState] = (*AlterReplicationThrottleAttack)(nil)
+)
+
+func NewAlterReplicationThrottleAttack() action_kit_sdk.Action[ReplicationThrottleState] {
+	return &AlterReplicationThrottleAttack{}
+}
+
+func (k *AlterReplicationThrottleAttack) NewEmptyState() ReplicationThrottleState {
+	return ReplicationThrottleState{}
+}
+
+func (k *AlterReplicationThrottleAttack) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.throttle-replication", kafkaTopicTargetId),
+		Label:       "Throttle Replication",
+		Description: "Throttle the replication of all replicas of the topic to a very low rate on the brokers hosting them, so that followers fall behind and drop out of the ISR while producers keep writing. Use together with a produce action and Check Partitions to test min.insync.replicas and acks=all. The original configuration is restored when the attack ends.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: kafkaTopicTargetId,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "topic name",
+					Description: new("Find topic by cluster and name"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.topic.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		TimeControl: action_kit_api.TimeControlExternal,
+		Kind:        action_kit_api.Attack,
+		Parameters: []action_kit_api.ActionParameter{
+			durationAlter,
+			{
+				Label:        "Throttled rate (bytes/s)",
+				Description:  new("The replication rate in bytes per second the brokers hosting the topic's replicas are throttled to, both as leader and as follower. Followers fall out of the ISR once the produce rate exceeds this value for longer than replica.lag.time.max.ms."),
+				Name:         "throttleRate",
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1024"),
+				MinValue:     new(1),
+				Required:     new(true),
+			},
+		},
+		Stop: new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (k *AlterReplicationThrottleAttack) Prepare(ctx context.Context, state *ReplicationThrottleState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	if len(request.Target.Attributes["kafka.topic.name"]) == 0 {
+		return nil, fmt.Errorf("the target is missing the kafka.topic.name attribute")
+	}
+	state.Topic = request.Target.Attributes["kafka.topic.name"][0]
+
+	// Get cluster name from target
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+
+	state.ThrottleRate = extutil.ToInt64(request.Config["throttleRate"])
+	if state.ThrottleRate <= 0 {
+		return nil, fmt.Errorf("throttled rate must be greater than zero")
+	}
+
+	adminClient, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer adminClient.Close()
+
+	topicDetails, err := adminClient.ListTopics(ctx, state.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve topic %s: %w", state.Topic, err)
+	}
+	topicDetail, ok := topicDetails[state.Topic]
+	if !ok || topicDetail.Err != nil {
+		return nil, fmt.Errorf("failed to retrieve topic %s: %v", state.Topic, topicDetail.Err)
+	}
+	state.BrokerIDs = replicaBrokerIDs(topicDetail.Partitions.Sorted())
+	if len(state.BrokerIDs) == 0 {
+		return nil, fmt.Errorf("topic %s has no replicas", state.Topic)
+	}
+
+	state.InitialLeaderRates = make(map[int32]string, len(state.BrokerIDs))
+	state.InitialFollowerRates = make(map[int32]string, len(state.BrokerIDs))
+	for _, brokerID := range state.BrokerIDs {
+		if state.InitialLeaderRates[brokerID], err = describeDynamicConfigOf(ctx, adminClient, LeaderReplicationThrottledRate, brokerID); err != nil {
+			return nil, err
+		}
+		if state.InitialFollowerRates[brokerID], err = describeDynamicConfigOf(ctx, adminClient, FollowerReplicationThrottledRate, brokerID); err != nil {
+			return nil, err
+		}
+	}
+	if state.InitialLeaderReplicas, err = describeTopicConfigOf(ctx, adminClient, LeaderReplicationThrottledReplicas, state.Topic); err != nil {
+		return nil, err
+	}
+	if state.InitialFollowerReplicas, err = describeTopicConfigOf(ctx, adminClient, FollowerReplicationThrottledReplicas, state.Topic); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (k *AlterReplicationThrottleAttack) Start(ctx context.Context, state *ReplicationThrottleState) (*action_kit_api.StartResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	if err := throttleReplication(ctx, state, clusterConfig); err != nil {
+		// Don't leave the brokers throttled if only a part of the throttle was applied
+		if restoreErr := restoreReplicationThrottle(ctx, state, clusterConfig); restoreErr != nil {
+			log.Error().Err(restoreErr).Msgf("Failed to roll back the replication throttle of topic %s", state.Topic)
+			return nil, errors.Join(err, restoreErr)
+		}
+		return nil, err
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Throttled replication of topic %s to %d bytes/s on broker node-ids: %s", state.Topic, state.ThrottleRate, joinInt32s(state.BrokerIDs)),
+		}},
+	}, nil
+}
+
+func (k *AlterReplicationThrottleAttack) Stop(ctx context.Context, state *ReplicationThrottleState) (*action_kit_api.StopResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	if err := restoreReplicationThrottle(ctx, state, clusterConfig); err != nil {
+		log.Error().Err(err).Msgf("Failed to restore the replication throttle of topic %s", state.Topic)
+		return nil, err
+	}
+
+	return &action_kit_api.StopResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Restored the replication throttle configuration of topic %s and broker node-ids: %s", state.Topic, joinInt32s(state.BrokerIDs)),
+		}},
+	}, nil
+}
+
+func throttleReplication(ctx context.Context, state *ReplicationThrottleState, clusterConfig *config.ClusterConfig) error {
+	rate := strconv.FormatInt(state.ThrottleRate, 10)
+	for _, brokerID := range state.BrokerIDs {
+		if err := alterResourceConfigWithConfig(ctx, state.BrokerHosts, dynamicBrokerConfigResource(brokerID), LeaderReplicationThrottledRate, rate, clusterConfig); err != nil {
+			return err
+		}
+		if err := alterResourceConfigWithConfig(ctx, state.BrokerHosts, dynamicBrokerConfigResource(brokerID), FollowerReplicationThrottledRate, rate, clusterConfig); err != nil {
+			return err
+		}
+	}
+	if err := alterResourceConfigWithConfig(ctx, state.BrokerHosts, topicConfigResource(state.Topic), LeaderReplicationThrottledReplicas, allThrottledReplicas, clusterConfig); err != nil {
+		return err
+	}
+	return alterResourceConfigWithConfig(ctx, state.BrokerHosts, topicConfigResource(state.Topic), FollowerReplicationThrottledReplicas, allThrottledReplicas, clusterConfig)
+}
+
+// restoreReplicationThrottle restores the configuration from before the attack. It restores as much as possible, even
+// if a single restore fails, and configs that weren't altered yet are simply set to their current value again.
+func restoreReplicationThrottle(ctx context.Context, state *ReplicationThrottleState, clusterConfig *config.ClusterConfig) error {
+	var errs []error
+	if err := alterResourceConfigWithConfig(ctx, state.BrokerHosts, topicConfigResource(state.Topic), LeaderReplicationThrottledReplicas, state.InitialLeaderReplicas, clusterConfig); err != nil {
+		errs = append(errs, err)
+	}
+	if err := alterResourceConfigWithConfig(ctx, state.BrokerHosts, topicConfigResource(state.Topic), FollowerReplicationThrottledReplicas, state.InitialFollowerReplicas, clusterConfig); err != nil {
+		errs = append(errs, err)
+	}
+	for _, brokerID := range state.BrokerIDs {
+		if err := alterResourceConfigWithConfig(ctx, state.BrokerHosts, dynamicBrokerConfigResource(brokerID), LeaderReplicationThrottledRate, state.InitialLeaderRates[brokerID], clusterConfig); err != nil {
+			errs = append(errs, fmt.Errorf("broker node-id %d: %w", brokerID, err))
+		}
+		if err := alterResourceConfigWithConfig(ctx, state.BrokerHosts, dynamicBrokerConfigResource(brokerID), FollowerReplicationThrottledRate, state.InitialFollowerRates[brokerID], clusterConfig); err != nil {
+			errs = append(errs, fmt.Errorf("broker node-id %d: %w", brokerID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// replicaBrokerIDs returns the sorted IDs of all brokers hosting a replica of the given partitions.
+func replicaBrokerIDs(partitions []kadm.PartitionDetail) []int32 {
+	var brokerIDs []int32
+	for _, partition := range partitions {
+		for _, replica := range partition.Replicas {
+			if !slices.Contains(brokerIDs, replica) {
+				brokerIDs = append(brokerIDs, replica)
+			}
+		}
+	}
+	slices.Sort(brokerIDs)
+	return brokerIDs
+}
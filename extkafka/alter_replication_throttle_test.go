@@ -0,0 +1,258 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestAlterReplicationThrottle_Describe(t *testing.T) {
+	//Given
+	action := AlterReplicationThrottleAttack{}
+
+	//When
+	response := action.Describe()
+
+	//Then
+	assert.Equal(t, "Throttle Replication", response.Label)
+	assert.Equal(t, kafkaTopicTargetId, response.TargetSelection.TargetType)
+	assert.Equal(t, fmt.Sprintf("%s.throttle-replication", kafkaTopicTargetId), response.Id)
+	assert.Equal(t, new("Kafka"), response.Technology)
+	assert.NotNil(t, response.Stop)
+}
+
+func TestAlterReplicationThrottle_Prepare(t *testing.T) {
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {
+			SeedBrokers: "localhost:9092",
+		},
+	})
+
+	//Given
+	action := AlterReplicationThrottleAttack{}
+	state := action.NewEmptyState()
+	request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"kafka.topic.name":   {"steadybit"},
+				"kafka.cluster.name": {"test-cluster"},
+			},
+		},
+		Config: map[string]any{
+			"duration":     60000,
+			"throttleRate": 0,
+		},
+		ExecutionId: uuid.New(),
+	})
+
+	//When
+	_, err := action.Prepare(t.Context(), &state, request)
+
+	//Then
+	assert.EqualError(t, err, "throttled rate must be greater than zero")
+}
+
+func TestReplicaBrokerIDs(t *testing.T) {
+	partitions := []kadm.PartitionDetail{
+		{Partition: 0, Replicas: []int32{3, 1}},
+		{Partition: 1, Replicas: []int32{1, 2}},
+		{Partition: 2, Replicas: []int32{2, 3}},
+	}
+
+	assert.Equal(t, []int32{1, 2, 3}, replicaBrokerIDs(partitions))
+	assert.Empty(t, replicaBrokerIDs(nil))
+}
+
+// dynamicConfigs serves the dynamic broker and topic configs of a kfake cluster, kfake itself doesn't accept the
+// replication throttle configs. Setting a config listed in failing is rejected.
+type dynamicConfigs struct {
+	mu      sync.Mutex
+	configs map[string]map[string]string // by resource type and name
+	failing map[string]bool              // by config name
+}
+
+// newDynamicConfigsCluster starts a single broker cluster with the topic steadybit whose dynamic configs are served
+// by the returned dynamicConfigs.
+func newDynamicConfigsCluster(t *testing.T) *dynamicConfigs {
+	c, err := kfake.NewCluster(
+		kfake.SeedTopics(-1, "steadybit"),
+		kfake.NumBrokers(1),
+	)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {
+			SeedBrokers: strings.Join(c.ListenAddrs(), ","),
+		},
+	})
+
+	d := &dynamicConfigs{configs: map[string]map[string]string{}, failing: map[string]bool{}}
+	c.ControlKey(int16(kmsg.IncrementalAlterConfigs), func(kreq kmsg.Request) (kmsg.Response, error, bool) {
+		c.KeepControl()
+		return d.alter(kreq.(*kmsg.IncrementalAlterConfigsRequest)), nil, true
+	})
+	c.ControlKey(int16(kmsg.DescribeConfigs), func(kreq kmsg.Request) (kmsg.Response, error, bool) {
+		c.KeepControl()
+		return d.describe(kreq.(*kmsg.DescribeConfigsRequest)), nil, true
+	})
+	return d
+}
+
+func (d *dynamicConfigs) set(resourceType kmsg.ConfigResourceType, resourceName, name, value string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := fmt.Sprintf("%d/%s", resourceType, resourceName)
+	if d.configs[key] == nil {
+		d.configs[key] = map[string]string{}
+	}
+	d.configs[key][name] = value
+}
+
+func (d *dynamicConfigs) get(resourceType kmsg.ConfigResourceType, resourceName, name string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	value, ok := d.configs[fmt.Sprintf("%d/%s", resourceType, resourceName)][name]
+	return value, ok
+}
+
+func (d *dynamicConfigs) alter(req *kmsg.IncrementalAlterConfigsRequest) kmsg.Response {
+	resp := req.ResponseKind().(*kmsg.IncrementalAlterConfigsResponse)
+	for _, resource := range req.Resources {
+		r := kmsg.NewIncrementalAlterConfigsResponseResource()
+		r.ResourceType = resource.ResourceType
+		r.ResourceName = resource.ResourceName
+		for _, c := range resource.Configs {
+			d.mu.Lock()
+			failing := d.failing[c.Name]
+			d.mu.Unlock()
+			switch {
+			case c.Op == kmsg.IncrementalAlterConfigOpSet && failing:
+				r.ErrorCode = kerr.PolicyViolation.Code
+			case c.Op == kmsg.IncrementalAlterConfigOpSet:
+				d.set(resource.ResourceType, resource.ResourceName, c.Name, *c.Value)
+			case c.Op == kmsg.IncrementalAlterConfigOpDelete:
+				d.mu.Lock()
+				delete(d.configs[fmt.Sprintf("%d/%s", resource.ResourceType, resource.ResourceName)], c.Name)
+				d.mu.Unlock()
+			}
+		}
+		resp.Resources = append(resp.Resources, r)
+	}
+	return resp
+}
+
+func (d *dynamicConfigs) describe(req *kmsg.DescribeConfigsRequest) kmsg.Response {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	resp := req.ResponseKind().(*kmsg.DescribeConfigsResponse)
+	for _, resource := range req.Resources {
+		r := kmsg.NewDescribeConfigsResponseResource()
+		r.ResourceType = resource.ResourceType
+		r.ResourceName = resource.ResourceName
+		source := kmsg.ConfigSourceDynamicTopicConfig
+		if resource.ResourceType == kmsg.ConfigResourceTypeBroker {
+			source = kmsg.ConfigSourceDynamicBrokerConfig
+		}
+		for name, value := range d.configs[fmt.Sprintf("%d/%s", resource.ResourceType, resource.ResourceName)] {
+			c := kmsg.NewDescribeConfigsResponseResourceConfig()
+			c.Name = name
+			c.Value = new(value)
+			c.Source = source
+			r.Configs = append(r.Configs, c)
+		}
+		resp.Resources = append(resp.Resources, r)
+	}
+	return resp
+}
+
+func TestAlterReplicationThrottle_StartStop(t *testing.T) {
+	action := AlterReplicationThrottleAttack{}
+	request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"kafka.topic.name":   {"steadybit"},
+				"kafka.cluster.name": {"test-cluster"},
+			},
+		},
+		Config: map[string]any{
+			"duration":     60000,
+			"throttleRate": 1024,
+		},
+		ExecutionId: uuid.New(),
+	})
+
+	t.Run("throttles and restores the replication", func(t *testing.T) {
+		//Given
+		configs := newDynamicConfigsCluster(t)
+		configs.set(kmsg.ConfigResourceTypeBroker, "0", LeaderReplicationThrottledRate, "2048")
+		state := action.NewEmptyState()
+		_, err := action.Prepare(t.Context(), &state, request)
+		require.NoError(t, err)
+		require.Equal(t, []int32{0}, state.BrokerIDs)
+
+		//When
+		_, err = action.Start(t.Context(), &state)
+
+		//Then
+		require.NoError(t, err)
+		for _, name := range []string{LeaderReplicationThrottledReplicas, FollowerReplicationThrottledReplicas} {
+			value, _ := configs.get(kmsg.ConfigResourceTypeTopic, "steadybit", name)
+			assert.Equal(t, allThrottledReplicas, value, name)
+		}
+		for _, name := range []string{LeaderReplicationThrottledRate, FollowerReplicationThrottledRate} {
+			value, _ := configs.get(kmsg.ConfigResourceTypeBroker, "0", name)
+			assert.Equal(t, "1024", value, name)
+		}
+
+		//When
+		_, err = action.Stop(t.Context(), &state)
+
+		//Then
+		require.NoError(t, err)
+		for _, name := range []string{LeaderReplicationThrottledReplicas, FollowerReplicationThrottledReplicas} {
+			_, ok := configs.get(kmsg.ConfigResourceTypeTopic, "steadybit", name)
+			assert.False(t, ok, name)
+		}
+		value, _ := configs.get(kmsg.ConfigResourceTypeBroker, "0", LeaderReplicationThrottledRate)
+		assert.Equal(t, "2048", value)
+		_, ok := configs.get(kmsg.ConfigResourceTypeBroker, "0", FollowerReplicationThrottledRate)
+		assert.False(t, ok)
+	})
+
+	t.Run("rolls back a partially applied throttle", func(t *testing.T) {
+		//Given
+		configs := newDynamicConfigsCluster(t)
+		configs.failing[FollowerReplicationThrottledReplicas] = true
+		state := action.NewEmptyState()
+		_, err := action.Prepare(t.Context(), &state, request)
+		require.NoError(t, err)
+
+		//When
+		_, err = action.Start(t.Context(), &state)
+
+		//Then
+		require.Error(t, err)
+		for _, name := range []string{LeaderReplicationThrottledRate, FollowerReplicationThrottledRate} {
+			_, ok := configs.get(kmsg.ConfigResourceTypeBroker, "0", name)
+			assert.False(t, ok, name)
+		}
+		_, ok := configs.get(kmsg.ConfigResourceTypeTopic, "steadybit", LeaderReplicationThrottledReplicas)
+		assert.False(t, ok)
+	})
+}
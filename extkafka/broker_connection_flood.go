@@ -0,0 +1,420 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+type BrokerConnectionFloodAttack struct{}
+
+type BrokerConnectionFloodState struct {
+	BrokerID              int32
+	BrokerAddress         string
+	ClusterName           string
+	ExecutionID           uuid.UUID
+	Connections           int
+	ConnectionsPerSecond  int
+	IdleRequest           string
+	IdleRequestIntervalMS int64
+	Timeout               time.Time
+}
+
+type ConnectionFloodRunData struct {
+	cancel        context.CancelFunc // cancels all connection holders of this execution
+	ctx           context.Context    // context for all connection holders of this execution
+	wg            sync.WaitGroup     // waits for all connection holders to close their connection
+	open          atomic.Int64       // number of currently open connections
+	failed        atomic.Uint64      // number of failed connection attempts
+	requests      atomic.Uint64      // number of idle requests sent
+	requestErrors atomic.Uint64      // number of idle requests that failed
+	lastError     atomic.Value       // last connection error as string
+}
+
+const (
+	connectionFloodIdleRequestNone        = "none"
+	connectionFloodIdleRequestApiVersions = "api-versions"
+	connectionFloodIdleRequestMetadata    = "metadata"
+
+	// Delay before a connection holder tries again after its connection was refused or lost
+	connectionFloodRetryDelay = time.Second
+	// Interval of the requests detecting lost connections if no idle requests are sent
+	connectionFloodLivenessInterval = 30 * time.Second
+)
+
+var (
+	ConnectionFloodRunDataMap = sync.Map{} //make(map[uuid.UUID]*ConnectionFloodRunData)
+)
+
+// Make sure Action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[BrokerConnectionFloodState]           = (*BrokerConnectionFloodAttack)(nil)
+	_ action_kit_sdk.ActionWithStatus[BrokerConnectionFloodState] = (*BrokerConnectionFloodAttack)(nil)
+	_ action_kit_sdk.ActionWithStop[BrokerConnectionFloodState]   = (*BrokerConnectionFloodAttack)(nil)
+)
+
+func NewBrokerConnectionFloodAttack() action_kit_sdk.Action[BrokerConnectionFloodState] {
+	return &BrokerConnectionFloodAttack{}
+}
+
+func (k *BrokerConnectionFloodAttack) NewEmptyState() BrokerConnectionFloodState {
+	return BrokerConnectionFloodState{}
+}
+
+func (k *BrokerConnectionFloodAttack) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.connection-flood", kafkaBrokerTargetId),
+		Label:       "Flood Connections",
+		Description: "Open and hold a number of client connections to the broker, optionally sending idle metadata or ApiVersions requests. Tests max.connections, max.connections.per.ip and connection storms from a real client point of view. To only lower the broker's connection creation limit, use Limit Connection Creation Rate instead. Every connection is held by a dedicated Kafka client of the extension, so the extension needs memory and file descriptors for all of them.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: kafkaBrokerTargetId,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "broker node id",
+					Description: new("Find broker by cluster name and id"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.broker.node-id=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		TimeControl: action_kit_api.TimeControlExternal,
+		Kind:        action_kit_api.Attack,
+		Parameters: []action_kit_api.ActionParameter{
+			durationAlter,
+			{
+				Label:        "Number of connections",
+				Description:  new("The number of connections opened and held to the broker. Refused or lost connections are retried every second."),
+				Name:         "connections",
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("100"),
+				MinValue:     new(1),
+				MaxValue:     new(5000),
+				Required:     new(true),
+			},
+			{
+				Label:        "Connections per second",
+				Description:  new("How many new connections are opened per second. Set to 0 to open all connections at once (connection storm)."),
+				Name:         "connectionsPerSecond",
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("0"),
+				MinValue:     new(0),
+				MaxValue:     new(1000),
+				Required:     new(true),
+			},
+			{
+				Label:        "Idle request",
+				Description:  new("The request periodically sent on every open connection. 'None' keeps the connections silent apart from an ApiVersions request every 30 seconds that detects lost connections."),
+				Name:         "idleRequest",
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(connectionFloodIdleRequestNone),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "None",
+						Value: connectionFloodIdleRequestNone,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "ApiVersions",
+						Value: connectionFloodIdleRequestApiVersions,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Metadata",
+						Value: connectionFloodIdleRequestMetadata,
+					},
+				}),
+				Required: new(true),
+			},
+			{
+				Label:        "Idle request interval",
+				Description:  new("How often the idle request is sent on every open connection."),
+				Name:         "idleRequestInterval",
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("5s"),
+				Advanced:     new(true),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Open Connections",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_connection_flood_open_connections",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Open connections"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "broker",
+							Title: "Broker",
+						},
+						{
+							From:  "failed",
+							Title: "Failed attempts",
+						},
+					},
+				}),
+			},
+		}),
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("1s"),
+		}),
+		Stop: new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (k *BrokerConnectionFloodAttack) Prepare(_ context.Context, state *BrokerConnectionFloodState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	state.BrokerID = extutil.ToInt32(request.Target.Attributes["kafka.broker.node-id"][0])
+	if len(request.Target.Attributes["kafka.broker.host"]) == 0 || len(request.Target.Attributes["kafka.broker.port"]) == 0 {
+		return nil, fmt.Errorf("the target is missing the kafka.broker.host or kafka.broker.port attribute")
+	}
+	state.BrokerAddress = net.JoinHostPort(request.Target.Attributes["kafka.broker.host"][0], request.Target.Attributes["kafka.broker.port"][0])
+
+	// Get cluster name from target
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	if _, err := config.GetClusterConfig(clusterName); err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+	state.ClusterName = clusterName
+
+	state.Connections = extutil.ToInt(request.Config["connections"])
+	if state.Connections <= 0 {
+		return nil, fmt.Errorf("number of connections must be greater than zero")
+	}
+	state.ConnectionsPerSecond = extutil.ToInt(request.Config["connectionsPerSecond"])
+
+	state.IdleRequest = extutil.ToString(request.Config["idleRequest"])
+	switch state.IdleRequest {
+	case connectionFloodIdleRequestNone, "":
+		state.IdleRequest = connectionFloodIdleRequestNone
+	case connectionFloodIdleRequestApiVersions, connectionFloodIdleRequestMetadata:
+	default:
+		return nil, fmt.Errorf("unknown idle request '%s'", state.IdleRequest)
+	}
+	state.IdleRequestIntervalMS = extutil.ToInt64(request.Config["idleRequestInterval"])
+	if state.IdleRequestIntervalMS <= 0 {
+		state.IdleRequestIntervalMS = 5000
+	}
+
+	duration := extutil.ToInt64(request.Config["duration"])
+	state.Timeout = time.Now().Add(time.Millisecond * time.Duration(duration))
+	state.ExecutionID = request.ExecutionId
+	return nil, nil
+}
+
+func (k *BrokerConnectionFloodAttack) Start(_ context.Context, state *BrokerConnectionFloodState) (*action_kit_api.StartResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runData := &ConnectionFloodRunData{
+		cancel: cancel,
+		ctx:    ctx,
+	}
+	ConnectionFloodRunDataMap.Store(state.ExecutionID, runData)
+
+	for i := 0; i < state.Connections; i++ {
+		runData.wg.Add(1)
+		go holdFloodConnection(runData, state, clusterConfig, connectionFloodOpenDelay(i, state.ConnectionsPerSecond))
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Opening %d connection(s) to broker node-id %v (%s)", state.Connections, state.BrokerID, state.BrokerAddress),
+		}},
+	}, nil
+}
+
+func (k *BrokerConnectionFloodAttack) Status(_ context.Context, state *BrokerConnectionFloodState) (*action_kit_api.StatusResult, error) {
+	runData, err := loadConnectionFloodRunData(state.ExecutionID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load connection flood run data")
+		return nil, err
+	}
+	return &action_kit_api.StatusResult{
+		Completed: false,
+		Metrics: new([]action_kit_api.Metric{
+			*toConnectionFloodMetric(runData, state, time.Now()),
+		}),
+	}, nil
+}
+
+func (k *BrokerConnectionFloodAttack) Stop(_ context.Context, state *BrokerConnectionFloodState) (*action_kit_api.StopResult, error) {
+	runData, err := loadConnectionFloodRunData(state.ExecutionID)
+	if err != nil {
+		log.Debug().Err(err).Msg("Connection flood run data not found, stop was already called")
+		return nil, nil
+	}
+	metric := toConnectionFloodMetric(runData, state, time.Now())
+	runData.cancel()
+	runData.wg.Wait()
+	ConnectionFloodRunDataMap.Delete(state.ExecutionID)
+
+	messages := []action_kit_api.Message{{
+		Level: extutil.Ptr(action_kit_api.Info),
+		Message: fmt.Sprintf("Closed all connections to broker node-id %v (%s). Failed connection attempts: %d, idle requests: %d (%d failed)",
+			state.BrokerID, state.BrokerAddress, runData.failed.Load(), runData.requests.Load(), runData.requestErrors.Load()),
+	}}
+	if lastError, ok := runData.lastError.Load().(string); ok {
+		messages = append(messages, action_kit_api.Message{
+			Level:   extutil.Ptr(action_kit_api.Warn),
+			Message: fmt.Sprintf("Last connection error: %s", lastError),
+		})
+	}
+	return &action_kit_api.StopResult{
+		Messages: &messages,
+		Metrics:  new([]action_kit_api.Metric{*metric}),
+	}, nil
+}
+
+func loadConnectionFloodRunData(executionID uuid.UUID) (*ConnectionFloodRunData, error) {
+	runData, ok := ConnectionFloodRunDataMap.Load(executionID)
+	if !ok {
+		return nil, fmt.Errorf("failed to load connection flood run data")
+	}
+	return runData.(*ConnectionFloodRunData), nil
+}
+
+// connectionFloodOpenDelay spreads the opening of the connections according to the requested rate.
+func connectionFloodOpenDelay(index int, connectionsPerSecond int) time.Duration {
+	if connectionsPerSecond <= 0 {
+		return 0
+	}
+	return time.Duration(index) * time.Second / time.Duration(connectionsPerSecond)
+}
+
+// holdFloodConnection opens a dedicated client connection to the broker and keeps it open until the execution is
+// cancelled. Refused or lost connections are retried.
+func holdFloodConnection(runData *ConnectionFloodRunData, state *BrokerConnectionFloodState, clusterConfig *config.ClusterConfig, openDelay time.Duration) {
+	defer runData.wg.Done()
+
+	if !sleepOrDone(runData.ctx, openDelay) {
+		return
+	}
+
+	for runData.ctx.Err() == nil {
+		err := openAndHoldFloodConnection(runData, state, clusterConfig)
+		if err != nil && runData.ctx.Err() == nil {
+			runData.failed.Add(1)
+			runData.lastError.Store(err.Error())
+			log.Debug().Err(err).Msgf("Connection to broker %s failed", state.BrokerAddress)
+		}
+		if !sleepOrDone(runData.ctx, connectionFloodRetryDelay) {
+			return
+		}
+	}
+}
+
+func openAndHoldFloodConnection(runData *ConnectionFloodRunData, state *BrokerConnectionFloodState, clusterConfig *config.ClusterConfig) error {
+	// A connection is kept open by the client as long as it isn't idle for longer than the idle timeout
+	idleTimeout := time.Until(state.Timeout) + time.Minute
+	client, err := createNewClientWithConfig([]string{state.BrokerAddress}, clusterConfig, kgo.ConnIdleTimeout(idleTimeout))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	seeds := client.SeedBrokers()
+	if len(seeds) == 0 {
+		return fmt.Errorf("no seed broker for %s", state.BrokerAddress)
+	}
+	broker := seeds[0]
+
+	// The first request opens the connection (including the SASL handshake if configured)
+	if _, err := broker.Request(runData.ctx, kmsg.NewPtrApiVersionsRequest()); err != nil {
+		return err
+	}
+	runData.open.Add(1)
+	defer runData.open.Add(-1)
+
+	// Without idle requests, a rare ApiVersions request detects connections closed by the broker. The client reopens
+	// a closed connection for the request, so only connections that can't be reopened are reported as lost.
+	interval := time.Duration(state.IdleRequestIntervalMS) * time.Millisecond
+	if state.IdleRequest == connectionFloodIdleRequestNone {
+		interval = connectionFloodLivenessInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-runData.ctx.Done():
+			return nil
+		case <-ticker.C:
+			_, err := broker.Request(runData.ctx, newConnectionFloodIdleRequest(state.IdleRequest))
+			if runData.ctx.Err() != nil {
+				return nil
+			}
+			if state.IdleRequest == connectionFloodIdleRequestNone {
+				if err != nil {
+					return fmt.Errorf("connection lost: %w", err)
+				}
+				continue
+			}
+			runData.requests.Add(1)
+			if err != nil {
+				runData.requestErrors.Add(1)
+				return err
+			}
+		}
+	}
+}
+
+func newConnectionFloodIdleRequest(idleRequest string) kmsg.Request {
+	if idleRequest == connectionFloodIdleRequestMetadata {
+		// An empty topic list (in contrast to nil) only requests the broker list
+		req := kmsg.NewPtrMetadataRequest()
+		req.Topics = []kmsg.MetadataRequestTopic{}
+		return req
+	}
+	return kmsg.NewPtrApiVersionsRequest()
+}
+
+func sleepOrDone(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func toConnectionFloodMetric(runData *ConnectionFloodRunData, state *BrokerConnectionFloodState, now time.Time) *action_kit_api.Metric {
+	return new(action_kit_api.Metric{
+		Name: new("kafka_connection_flood_open_connections"),
+		Metric: map[string]string{
+			"broker": fmt.Sprintf("%v (%s)", state.BrokerID, state.BrokerAddress),
+			"failed": strconv.FormatUint(runData.failed.Load(), 10),
+			"id":     state.ClusterName + "-" + strconv.Itoa(int(state.BrokerID)),
+		},
+		Timestamp: now,
+		Value:     float64(runData.open.Load()),
+	})
+}
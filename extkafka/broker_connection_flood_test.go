@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestBrokerConnectionFlood_Describe(t *testing.T) {
+	//Given
+	action := BrokerConnectionFloodAttack{}
+
+	//When
+	response := action.Describe()
+
+	//Then
+	assert.Equal(t, "Flood Connections", response.Label)
+	assert.Equal(t, kafkaBrokerTargetId, response.TargetSelection.TargetType)
+	assert.Equal(t, fmt.Sprintf("%s.connection-flood", kafkaBrokerTargetId), response.Id)
+	assert.Equal(t, new("Kafka"), response.Technology)
+}
+
+func TestBrokerConnectionFlood_Prepare(t *testing.T) {
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {
+			SeedBrokers: "localhost:9092",
+		},
+	})
+
+	//Given
+	action := BrokerConnectionFloodAttack{}
+	state := action.NewEmptyState()
+	request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"kafka.broker.node-id": {"2"},
+				"kafka.broker.host":    {"broker-2"},
+				"kafka.broker.port":    {"9093"},
+				"kafka.cluster.name":   {"test-cluster"},
+			},
+		},
+		Config: map[string]any{
+			"duration":             10000,
+			"connections":          50,
+			"connectionsPerSecond": 10,
+			"idleRequest":          connectionFloodIdleRequestMetadata,
+			"idleRequestInterval":  2000,
+		},
+		ExecutionId: uuid.New(),
+	})
+
+	//When
+	_, err := action.Prepare(t.Context(), &state, request)
+
+	//Then
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), state.BrokerID)
+	assert.Equal(t, "broker-2:9093", state.BrokerAddress)
+	assert.Equal(t, 50, state.Connections)
+	assert.Equal(t, 10, state.ConnectionsPerSecond)
+	assert.Equal(t, connectionFloodIdleRequestMetadata, state.IdleRequest)
+	assert.Equal(t, int64(2000), state.IdleRequestIntervalMS)
+}
+
+func TestBrokerConnectionFlood_connectionFloodOpenDelay(t *testing.T) {
+	assert.Equal(t, time.Duration(0), connectionFloodOpenDelay(10, 0))
+	assert.Equal(t, time.Duration(0), connectionFloodOpenDelay(0, 4))
+	assert.Equal(t, 500*time.Millisecond, connectionFloodOpenDelay(2, 4))
+}
+
+func TestBrokerConnectionFlood_newConnectionFloodIdleRequest(t *testing.T) {
+	metadata, ok := newConnectionFloodIdleRequest(connectionFloodIdleRequestMetadata).(*kmsg.MetadataRequest)
+	require.True(t, ok)
+	assert.NotNil(t, metadata.Topics)
+	assert.Empty(t, metadata.Topics)
+
+	_, ok = newConnectionFloodIdleRequest(connectionFloodIdleRequestApiVersions).(*kmsg.ApiVersionsRequest)
+	assert.True(t, ok)
+}
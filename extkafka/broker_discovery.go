@@ -15,9 +15,13 @@ import (
 	"github.com/twmb/franz-go/pkg/kadm"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// logDirDiscoveryConcurrency limits the brokers of a cluster whose log dirs are described at the same time.
+const logDirDiscoveryConcurrency = 5
+
 type kafkaBrokerDiscovery struct {
 }
 
@@ -190,6 +194,41 @@ func (r *kafkaBrokerDiscovery) DescribeAttributes() []discovery_kit_api.Attribut
 				Other: "Kafka broker racks",
 			},
 		},
+		{
+			Attribute: "kafka.broker.log-dirs",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka broker log dir",
+				Other: "Kafka broker log dirs",
+			},
+		},
+		{
+			Attribute: "kafka.broker.log-dirs.offline",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka broker offline log dir",
+				Other: "Kafka broker offline log dirs",
+			},
+		},
+		{
+			Attribute: "kafka.broker.log-dirs.total-bytes",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka broker log dir total bytes",
+				Other: "Kafka broker log dir total bytes",
+			},
+		},
+		{
+			Attribute: "kafka.broker.log-dirs.usable-bytes",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka broker log dir usable bytes",
+				Other: "Kafka broker log dir usable bytes",
+			},
+		},
+		{
+			Attribute: "kafka.broker.log-dirs.replica-bytes",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka broker log dir replica bytes",
+				Other: "Kafka broker log dir replica bytes",
+			},
+		},
 		{
 			Attribute: "kafka.pod.name",
 			Label: discovery_kit_api.PluralLabel{
@@ -255,10 +294,11 @@ func getAllBrokersMultiCluster(ctx context.Context) ([]discovery_kit_api.Target,
 func discoverBrokersForCluster(ctx context.Context, clusterName string, clusterConfig *config.ClusterConfig) ([]discovery_kit_api.Target, error) {
 	result := make([]discovery_kit_api.Target, 0, 20)
 
-	client, err := createNewAdminClientWithConfig(strings.Split(clusterConfig.SeedBrokers, ","), clusterConfig)
+	kafkaClient, err := createNewClientWithConfig(strings.Split(clusterConfig.SeedBrokers, ","), clusterConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize kafka client for cluster %s: %s", clusterName, err.Error())
 	}
+	client := kadm.NewClient(kafkaClient)
 	defer client.Close()
 
 	brokerDetails, err := client.ListBrokers(ctx)
@@ -278,6 +318,24 @@ func discoverBrokersForCluster(ctx context.Context, clusterName string, clusterC
 		result = append(result, toBrokerTarget(broker, metadata.Controller, clusterName, metadata.Cluster))
 	}
 
+	// Log dirs are optional, describing them requires the DESCRIBE permission on the cluster. Listing the partitions
+	// is costly on large brokers, so only a few brokers are described at a time.
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, logDirDiscoveryConcurrency)
+	for i, broker := range brokerDetails {
+		wg.Go(func() {
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			logDirs, err := describeLogDirUsageOfBroker(ctx, kafkaClient, broker.NodeID, true)
+			if err != nil {
+				log.Debug().Err(err).Msgf("Cluster %s: Failed to describe log dirs of broker %d", clusterName, broker.NodeID)
+				return
+			}
+			addLogDirAttributes(result[i].Attributes, logDirs)
+		})
+	}
+	wg.Wait()
+
 	return result, nil
 }
 
@@ -318,3 +376,32 @@ func toBrokerTarget(broker kadm.BrokerDetail, controller int32, clusterName stri
 		Attributes: attributes,
 	}
 }
+
+// addLogDirAttributes adds the log dirs of the broker. The capacity is published per log dir as dir=bytes, log dirs
+// on a shared volume report the same capacity and must not be summed up.
+func addLogDirAttributes(attributes map[string][]string, logDirs []logDirUsage) {
+	var dirs, offlineDirs, totalBytes, usableBytes, replicaBytes []string
+	for _, logDir := range logDirs {
+		if logDir.Err != nil {
+			offlineDirs = append(offlineDirs, logDir.Dir)
+			continue
+		}
+		dirs = append(dirs, logDir.Dir)
+		replicaBytes = append(replicaBytes, fmt.Sprintf("%s=%d", logDir.Dir, logDir.ReplicaBytes))
+		if logDir.TotalBytes >= 0 && logDir.UsableBytes >= 0 {
+			totalBytes = append(totalBytes, fmt.Sprintf("%s=%d", logDir.Dir, logDir.TotalBytes))
+			usableBytes = append(usableBytes, fmt.Sprintf("%s=%d", logDir.Dir, logDir.UsableBytes))
+		}
+	}
+	if len(dirs) > 0 {
+		attributes["kafka.broker.log-dirs"] = dirs
+		attributes["kafka.broker.log-dirs.replica-bytes"] = replicaBytes
+	}
+	if len(offlineDirs) > 0 {
+		attributes["kafka.broker.log-dirs.offline"] = offlineDirs
+	}
+	if len(totalBytes) > 0 {
+		attributes["kafka.broker.log-dirs.total-bytes"] = totalBytes
+		attributes["kafka.broker.log-dirs.usable-bytes"] = usableBytes
+	}
+}
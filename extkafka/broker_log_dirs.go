@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// logDirUsage is the capacity of the volume a single log dir of a broker is on and the size of the replicas in it.
+type logDirUsage struct {
+	Dir string
+	// TotalBytes and UsableBytes are -1 if the broker doesn't report them (DescribeLogDirs v4+, Kafka 3.3+).
+	TotalBytes  int64
+	UsableBytes int64
+	// ReplicaBytes is the size of all replicas hosted in the log dir, only known if the partitions were described.
+	ReplicaBytes int64
+	Err          error // non-nil if the log dir is offline, e.g. after a disk failure
+}
+
+// usedPercent returns the used share of the volume the log dir is on, false if the broker doesn't report it.
+func (u logDirUsage) usedPercent() (float64, bool) {
+	if u.Err != nil || u.TotalBytes <= 0 || u.UsableBytes < 0 {
+		return 0, false
+	}
+	return float64(u.TotalBytes-u.UsableBytes) * 100 / float64(u.TotalBytes), true
+}
+
+// describeLogDirUsageOfBroker describes all log directories of a single broker. In contrast to
+// describeLogDirsOfBroker, offline log dirs are returned with their error.
+func describeLogDirUsageOfBroker(ctx context.Context, client *kgo.Client, brokerID int32, withPartitions bool) ([]logDirUsage, error) {
+	dirs, err := describeAllLogDirsOfBroker(ctx, client, brokerID, withPartitions)
+	if err != nil {
+		return nil, err
+	}
+	return toLogDirUsages(dirs), nil
+}
+
+func toLogDirUsages(dirs []kmsg.DescribeLogDirsResponseDir) []logDirUsage {
+	usages := make([]logDirUsage, 0, len(dirs))
+	for _, dir := range dirs {
+		usage := logDirUsage{
+			Dir:         dir.Dir,
+			TotalBytes:  dir.TotalBytes,
+			UsableBytes: dir.UsableBytes,
+			Err:         kerr.ErrorForCode(dir.ErrorCode),
+		}
+		for _, topic := range dir.Topics {
+			for _, partition := range topic.Partitions {
+				usage.ReplicaBytes += partition.Size
+			}
+		}
+		usages = append(usages, usage)
+	}
+	slices.SortFunc(usages, func(a, b logDirUsage) int { return strings.Compare(a.Dir, b.Dir) })
+	return usages
+}
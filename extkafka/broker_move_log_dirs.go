@@ -0,0 +1,391 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+type MoveLogDirsAttack struct{}
+
+type MoveLogDirsState struct {
+	BrokerID    int32
+	Topic       string
+	Moves       []LogDirMove
+	MoveBack    bool
+	BrokerHosts []string
+	ClusterName string // Cluster name for multi-cluster support
+}
+
+type LogDirMove struct {
+	Partition int32
+	FromDir   string
+	ToDir     string
+}
+
+// Make sure Action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[MoveLogDirsState]           = (*MoveLogDirsAttack)(nil)
+	_ action_kit_sdk.ActionWithStatus[MoveLogDirsState] = (*MoveLogDirsAttack)(nil)
+	_ action_kit_sdk.ActionWithStop[MoveLogDirsState]   = (*MoveLogDirsAttack)(nil)
+)
+
+func NewMoveLogDirsAttack() action_kit_sdk.Action[MoveLogDirsState] {
+	return &MoveLogDirsAttack{}
+}
+
+func (k *MoveLogDirsAttack) NewEmptyState() MoveLogDirsState {
+	return MoveLogDirsState{}
+}
+
+func (k *MoveLogDirsAttack) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.move-log-dirs", kafkaBrokerTargetId),
+		Label:       "Move Replicas Between Log Dirs",
+		Description: "Move the replicas of selected partitions between the log directories (JBOD disks) of the broker, rehearsing disk rebalancing within a broker. The progress of the moves is reported while the attack runs. Optionally, the replicas are moved back when the attack ends.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: kafkaBrokerTargetId,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "broker node id",
+					Description: new("Find broker by cluster name and id"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.broker.node-id=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		TimeControl: action_kit_api.TimeControlExternal,
+		Kind:        action_kit_api.Attack,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Label:        "Duration",
+				Description:  new("How long the attack runs. Moves that are still in progress when the duration expires continue in the background."),
+				Name:         "duration",
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("120s"),
+				Required:     new(true),
+			},
+			{
+				Label:       "Topic",
+				Description: new("The topic whose replicas on the broker are moved."),
+				Name:        "topic",
+				Type:        action_kit_api.ActionParameterTypeString,
+				Required:    new(true),
+			},
+			{
+				Label:       "Partitions",
+				Description: new("Optional. The partitions to move. If empty, all partitions of the topic with a replica on the broker are moved."),
+				Name:        "partitions",
+				Type:        action_kit_api.ActionParameterTypeStringArray,
+			},
+			{
+				Label:       "Target log dir",
+				Description: new("Optional. The log directory the replicas are moved to. If empty, every replica is moved to the next log directory of the broker."),
+				Name:        "targetLogDir",
+				Type:        action_kit_api.ActionParameterTypeString,
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ParameterOptionsFromTargetAttribute{
+						Attribute: "kafka.broker.log-dirs",
+					},
+				}),
+			},
+			{
+				Label:        "Move back",
+				Description:  new("If enabled, the replicas are moved back to their original log directories when the attack ends."),
+				Name:         "moveBack",
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Pending Log Dir Moves",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_log_dir_moves_pending",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Pending moves"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "topic",
+							Title: "Topic",
+						},
+						{
+							From:  "broker",
+							Title: "Broker",
+						},
+					},
+				}),
+			},
+		}),
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("2s"),
+		}),
+		Stop: new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (k *MoveLogDirsAttack) Prepare(ctx context.Context, state *MoveLogDirsState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	state.BrokerID = extutil.ToInt32(request.Target.Attributes["kafka.broker.node-id"][0])
+
+	// Get cluster name from target
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+
+	state.Topic = extutil.ToString(request.Config["topic"])
+	if state.Topic == "" {
+		return nil, fmt.Errorf("a topic is required")
+	}
+	state.MoveBack = true
+	if request.Config["moveBack"] != nil {
+		state.MoveBack = extutil.ToBool(request.Config["moveBack"])
+	}
+	var partitions []int32
+	for _, p := range extutil.ToStringArray(request.Config["partitions"]) {
+		partition, err := strconv.ParseInt(strings.TrimSpace(p), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid partition '%s': %w", p, err)
+		}
+		partitions = append(partitions, int32(partition))
+	}
+	targetLogDir := extutil.ToString(request.Config["targetLogDir"])
+
+	kafkaClient, err := createNewClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	adminClient := kadm.NewClient(kafkaClient)
+	defer adminClient.Close()
+
+	logDirs, err := describeLogDirsOfBroker(ctx, kafkaClient, state.BrokerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe log dirs of broker node-id %d: %w", state.BrokerID, err)
+	}
+	dirs := make([]string, 0, len(logDirs))
+	for _, logDir := range logDirs {
+		dirs = append(dirs, logDir.Dir)
+	}
+	slices.Sort(dirs)
+	if len(dirs) < 2 {
+		return nil, fmt.Errorf("broker node-id %d has %d log dir(s), at least 2 are required to move replicas", state.BrokerID, len(dirs))
+	}
+	if targetLogDir != "" && !slices.Contains(dirs, targetLogDir) {
+		return nil, fmt.Errorf("log dir %s doesn't exist on broker node-id %d, available log dirs: %s", targetLogDir, state.BrokerID, strings.Join(dirs, ", "))
+	}
+
+	described, err := adminClient.DescribeBrokerLogDirs(ctx, state.BrokerID, kadm.TopicsSet{state.Topic: nil})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe replicas of topic %s on broker node-id %d: %w", state.Topic, state.BrokerID, err)
+	}
+	currentDirs := currentLogDirsOfTopic(described, state.Topic)
+	if len(currentDirs) == 0 {
+		return nil, fmt.Errorf("broker node-id %d hosts no replica of topic %s", state.BrokerID, state.Topic)
+	}
+
+	state.Moves, err = planLogDirMoves(currentDirs, partitions, dirs, targetLogDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.Moves) == 0 {
+		return nil, fmt.Errorf("all selected replicas of topic %s are already in log dir %s", state.Topic, targetLogDir)
+	}
+	return nil, nil
+}
+
+func (k *MoveLogDirsAttack) Start(ctx context.Context, state *MoveLogDirsState) (*action_kit_api.StartResult, error) {
+	if err := alterReplicaLogDirs(ctx, state, false); err != nil {
+		return nil, err
+	}
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Moving %d replica(s) of topic %s on broker node-id %d: %s", len(state.Moves), state.Topic, state.BrokerID, describeLogDirMoves(state.Moves, false)),
+		}},
+	}, nil
+}
+
+func (k *MoveLogDirsAttack) Status(ctx context.Context, state *MoveLogDirsState) (*action_kit_api.StatusResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+	adminClient, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer adminClient.Close()
+
+	described, err := adminClient.DescribeBrokerLogDirs(ctx, state.BrokerID, kadm.TopicsSet{state.Topic: nil})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe replicas of topic %s on broker node-id %d: %w", state.Topic, state.BrokerID, err)
+	}
+
+	pending, progress := logDirMoveProgress(described, state)
+	now := time.Now()
+	return &action_kit_api.StatusResult{
+		Completed: false,
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("%d/%d replica(s) of topic %s moved on broker node-id %d. %s", len(state.Moves)-pending, len(state.Moves), state.Topic, state.BrokerID, progress),
+		}},
+		Metrics: new([]action_kit_api.Metric{
+			{
+				Name: new("kafka_log_dir_moves_pending"),
+				Metric: map[string]string{
+					"topic":  state.Topic,
+					"broker": strconv.Itoa(int(state.BrokerID)),
+					"id":     fmt.Sprintf("%s-%d-%s", state.ClusterName, state.BrokerID, state.Topic),
+				},
+				Timestamp: now,
+				Value:     float64(pending),
+			},
+		}),
+	}, nil
+}
+
+func (k *MoveLogDirsAttack) Stop(ctx context.Context, state *MoveLogDirsState) (*action_kit_api.StopResult, error) {
+	if !state.MoveBack {
+		return nil, nil
+	}
+	if err := alterReplicaLogDirs(ctx, state, true); err != nil {
+		return nil, err
+	}
+	return &action_kit_api.StopResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Moving %d replica(s) of topic %s on broker node-id %d back: %s", len(state.Moves), state.Topic, state.BrokerID, describeLogDirMoves(state.Moves, true)),
+		}},
+	}, nil
+}
+
+func alterReplicaLogDirs(ctx context.Context, state *MoveLogDirsState, back bool) error {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster config: %w", err)
+	}
+	adminClient, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer adminClient.Close()
+
+	var req kadm.AlterReplicaLogDirsReq
+	for _, move := range state.Moves {
+		dir := move.ToDir
+		if back {
+			dir = move.FromDir
+		}
+		req.Add(dir, kadm.TopicsSet{state.Topic: {move.Partition: struct{}{}}})
+	}
+
+	responses, err := adminClient.AlterBrokerReplicaLogDirs(ctx, state.BrokerID, req)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	responses.Each(func(response kadm.AlterReplicaLogDirsResponse) {
+		if response.Err != nil {
+			errs = append(errs, fmt.Errorf("partition %d to %s: %w", response.Partition, response.Dir, response.Err))
+		}
+	})
+	if len(errs) > 0 {
+		log.Error().Errs("errors", errs).Msgf("Failed to move replicas of topic %s on broker node-id %d", state.Topic, state.BrokerID)
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// currentLogDirsOfTopic returns the log dir of every replica of the topic, ignoring future replicas of moves in progress.
+func currentLogDirsOfTopic(described kadm.DescribedLogDirs, topic string) map[int32]string {
+	currentDirs := make(map[int32]string)
+	described.EachPartition(func(p kadm.DescribedLogDirPartition) {
+		if p.Topic == topic && !p.IsFuture {
+			currentDirs[p.Partition] = p.Dir
+		}
+	})
+	return currentDirs
+}
+
+// planLogDirMoves determines the log dir moves for the selected partitions (all if none are selected). Without a target
+// log dir, every replica is moved to the log dir following its current one.
+func planLogDirMoves(currentDirs map[int32]string, partitions []int32, dirs []string, targetLogDir string) ([]LogDirMove, error) {
+	if len(partitions) == 0 {
+		for partition := range currentDirs {
+			partitions = append(partitions, partition)
+		}
+	}
+	slices.Sort(partitions)
+
+	moves := make([]LogDirMove, 0, len(partitions))
+	for _, partition := range partitions {
+		fromDir, ok := currentDirs[partition]
+		if !ok {
+			return nil, fmt.Errorf("partition %d has no replica on the broker", partition)
+		}
+		toDir := targetLogDir
+		if toDir == "" {
+			toDir = dirs[(slices.Index(dirs, fromDir)+1)%len(dirs)]
+		}
+		if toDir == fromDir {
+			continue
+		}
+		moves = append(moves, LogDirMove{Partition: partition, FromDir: fromDir, ToDir: toDir})
+	}
+	return moves, nil
+}
+
+// logDirMoveProgress returns the number of pending moves and a human-readable progress of them.
+func logDirMoveProgress(described kadm.DescribedLogDirs, state *MoveLogDirsState) (int, string) {
+	pending := 0
+	var progress []string
+	for _, move := range state.Moves {
+		replica, ok := described.Lookup(move.ToDir, state.Topic, move.Partition)
+		switch {
+		case !ok:
+			pending++
+			progress = append(progress, fmt.Sprintf("partition %d: not started", move.Partition))
+		case replica.IsFuture:
+			pending++
+			progress = append(progress, fmt.Sprintf("partition %d: %d bytes copied, %d offsets behind", move.Partition, replica.Size, replica.OffsetLag))
+		}
+	}
+	return pending, strings.Join(progress, ", ")
+}
+
+func describeLogDirMoves(moves []LogDirMove, back bool) string {
+	descriptions := make([]string, 0, len(moves))
+	for _, move := range moves {
+		if back {
+			descriptions = append(descriptions, fmt.Sprintf("%d: %s -> %s", move.Partition, move.ToDir, move.FromDir))
+		} else {
+			descriptions = append(descriptions, fmt.Sprintf("%d: %s -> %s", move.Partition, move.FromDir, move.ToDir))
+		}
+	}
+	return strings.Join(descriptions, ", ")
+}
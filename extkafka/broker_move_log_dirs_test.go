@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestMoveLogDirs_Describe(t *testing.T) {
+	//Given
+	action := MoveLogDirsAttack{}
+
+	//When
+	response := action.Describe()
+
+	//Then
+	assert.Equal(t, "Move Replicas Between Log Dirs", response.Label)
+	assert.Equal(t, kafkaBrokerTargetId, response.TargetSelection.TargetType)
+	assert.Equal(t, fmt.Sprintf("%s.move-log-dirs", kafkaBrokerTargetId), response.Id)
+	assert.Equal(t, new("Kafka"), response.Technology)
+}
+
+func TestMoveLogDirs_planLogDirMoves(t *testing.T) {
+	dirs := []string{"/data/1", "/data/2", "/data/3"}
+	currentDirs := map[int32]string{0: "/data/1", 1: "/data/3", 2: "/data/2"}
+
+	t.Run("next log dir for all partitions", func(t *testing.T) {
+		moves, err := planLogDirMoves(currentDirs, nil, dirs, "")
+		require.NoError(t, err)
+		assert.Equal(t, []LogDirMove{
+			{Partition: 0, FromDir: "/data/1", ToDir: "/data/2"},
+			{Partition: 1, FromDir: "/data/3", ToDir: "/data/1"},
+			{Partition: 2, FromDir: "/data/2", ToDir: "/data/3"},
+		}, moves)
+	})
+
+	t.Run("target log dir skips replicas already there", func(t *testing.T) {
+		moves, err := planLogDirMoves(currentDirs, []int32{2, 0}, dirs, "/data/2")
+		require.NoError(t, err)
+		assert.Equal(t, []LogDirMove{{Partition: 0, FromDir: "/data/1", ToDir: "/data/2"}}, moves)
+	})
+
+	t.Run("partition without replica on the broker", func(t *testing.T) {
+		_, err := planLogDirMoves(currentDirs, []int32{7}, dirs, "")
+		assert.ErrorContains(t, err, "partition 7")
+	})
+}
+
+func TestMoveLogDirs_logDirMoveProgress(t *testing.T) {
+	//Given
+	state := &MoveLogDirsState{
+		Topic: "orders",
+		Moves: []LogDirMove{
+			{Partition: 0, FromDir: "/data/1", ToDir: "/data/2"},
+			{Partition: 1, FromDir: "/data/1", ToDir: "/data/2"},
+			{Partition: 2, FromDir: "/data/1", ToDir: "/data/2"},
+		},
+	}
+	described := kadm.DescribedLogDirs{
+		"/data/1": {Dir: "/data/1", Topics: kadm.DescribedLogDirTopics{"orders": {
+			1: {Dir: "/data/1", Topic: "orders", Partition: 1},
+			2: {Dir: "/data/1", Topic: "orders", Partition: 2},
+		}}},
+		"/data/2": {Dir: "/data/2", Topics: kadm.DescribedLogDirTopics{"orders": {
+			0: {Dir: "/data/2", Topic: "orders", Partition: 0},
+			1: {Dir: "/data/2", Topic: "orders", Partition: 1, IsFuture: true, Size: 2048, OffsetLag: 12},
+		}}},
+	}
+
+	//When
+	pending, progress := logDirMoveProgress(described, state)
+
+	//Then
+	assert.Equal(t, 2, pending)
+	assert.Equal(t, "partition 1: 2048 bytes copied, 12 offsets behind, partition 2: not started", progress)
+}
+
+func TestMoveLogDirs_currentLogDirsOfTopic(t *testing.T) {
+	described := kadm.DescribedLogDirs{
+		"/data/1": {Dir: "/data/1", Topics: kadm.DescribedLogDirTopics{
+			"orders":   {0: {Dir: "/data/1", Topic: "orders", Partition: 0}},
+			"payments": {0: {Dir: "/data/1", Topic: "payments", Partition: 0}},
+		}},
+		"/data/2": {Dir: "/data/2", Topics: kadm.DescribedLogDirTopics{"orders": {
+			0: {Dir: "/data/2", Topic: "orders", Partition: 0, IsFuture: true},
+			1: {Dir: "/data/2", Topic: "orders", Partition: 1},
+		}}},
+	}
+
+	assert.Equal(t, map[int32]string{0: "/data/1", 1: "/data/2"}, currentLogDirsOfTopic(described, "orders"))
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// brokerProbe is the result of a single ApiVersions request pinned to a broker.
+type brokerProbe struct {
+	NodeID  int32
+	Latency time.Duration // including the connection setup if the client wasn't connected to the broker yet
+	Err     error
+}
+
+// probeBrokers sends an ApiVersions request to every broker concurrently. The brokers must be known to the client,
+// e.g. from a preceding metadata request. A first, untimed request sets up the connection, so the latency doesn't
+// include the TCP, TLS and SASL handshakes of the fresh client. The timeout only applies to the timed request, the
+// connection setup may take as long as the client's dial timeout on top.
+func probeBrokers(ctx context.Context, client *kgo.Client, nodeIDs []int32, timeout time.Duration) []brokerProbe {
+	setupTimeout := client.OptValue(kgo.DialTimeout).(time.Duration) + timeout
+	probes := make([]brokerProbe, len(nodeIDs))
+	var wg sync.WaitGroup
+	for i, nodeID := range nodeIDs {
+		wg.Go(func() {
+			broker := client.Broker(int(nodeID))
+			if latency, err := requestApiVersions(ctx, broker, setupTimeout); err != nil {
+				probes[i] = brokerProbe{NodeID: nodeID, Latency: latency, Err: err}
+				return
+			}
+			latency, err := requestApiVersions(ctx, broker, timeout)
+			probes[i] = brokerProbe{NodeID: nodeID, Latency: latency, Err: err}
+		})
+	}
+	wg.Wait()
+	slices.SortFunc(probes, func(a, b brokerProbe) int { return int(a.NodeID - b.NodeID) })
+	return probes
+}
+
+func requestApiVersions(ctx context.Context, broker *kgo.Broker, timeout time.Duration) (time.Duration, error) {
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	resp, err := broker.Request(probeCtx, kmsg.NewPtrApiVersionsRequest())
+	if err == nil {
+		err = kerr.ErrorForCode(resp.(*kmsg.ApiVersionsResponse).ErrorCode)
+	}
+	return time.Since(start), err
+}
+
+// evaluateBrokerProbes describes every broker that didn't respond or responded slower than maxLatency. If broker
+// downtime is expected, unresponsive brokers are the expected effect, e.g. a killed broker stays registered until its
+// session times out, and only slow responses are reported.
+func evaluateBrokerProbes(probes []brokerProbe, maxLatency time.Duration, downtimeExpected bool) []string {
+	var deviations []string
+	for _, probe := range probes {
+		switch {
+		case probe.Err != nil && downtimeExpected:
+			continue
+		case errors.Is(probe.Err, context.DeadlineExceeded):
+			deviations = append(deviations, fmt.Sprintf("Broker %d is registered but didn't respond within %s.", probe.NodeID, probe.Latency.Round(time.Millisecond)))
+		case probe.Err != nil:
+			deviations = append(deviations, fmt.Sprintf("Broker %d is registered but unresponsive: %s.", probe.NodeID, probe.Err.Error()))
+		case probe.Latency > maxLatency:
+			deviations = append(deviations, fmt.Sprintf("Broker %d responded in %s, at most %s allowed.", probe.NodeID, probe.Latency.Round(time.Millisecond), maxLatency))
+		}
+	}
+	return deviations
+}
+
+func toBrokerProbeMetrics(probes []brokerProbe, maxLatency time.Duration, now time.Time) []action_kit_api.Metric {
+	metrics := make([]action_kit_api.Metric, 0, len(probes))
+	for _, probe := range probes {
+		state := "success"
+		errorMessage := ""
+		if probe.Err != nil {
+			state = "danger"
+			errorMessage = probe.Err.Error()
+		} else if probe.Latency > maxLatency {
+			state = "warn"
+		}
+		metrics = append(metrics, action_kit_api.Metric{
+			Name: new("kafka_broker_probe_latency"),
+			Metric: map[string]string{
+				"id":    fmt.Sprintf("broker-%d", probe.NodeID),
+				"state": state,
+				"error": errorMessage,
+			},
+			Timestamp: now,
+			Value:     float64(probe.Latency.Milliseconds()),
+		})
+	}
+	return metrics
+}
@@ -0,0 +1,337 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+type BrokerLogDirsCheckAction struct{}
+
+type BrokerLogDirsCheckState struct {
+	BrokerID           int32
+	End                time.Time
+	MaxDiskUsage       float64 // in percent, zero to not check the disk usage
+	MaxOfflineReplicas int
+	FailEarly          bool
+	DeviationSeen      bool
+	DeviationTitle     string
+	BrokerHosts        []string
+	ClusterName        string
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[BrokerLogDirsCheckState]           = (*BrokerLogDirsCheckAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[BrokerLogDirsCheckState] = (*BrokerLogDirsCheckAction)(nil)
+)
+
+func NewBrokerLogDirsCheckAction() action_kit_sdk.Action[BrokerLogDirsCheckState] {
+	return &BrokerLogDirsCheckAction{}
+}
+
+func (m *BrokerLogDirsCheckAction) NewEmptyState() BrokerLogDirsCheckState {
+	return BrokerLogDirsCheckState{}
+}
+
+func (m *BrokerLogDirsCheckAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.check-log-dirs", kafkaBrokerTargetId),
+		Label:       "Check Broker Log Dirs",
+		Description: "Monitor the log dirs of the broker during an experiment. Fail if a log dir goes offline, the disk usage of a log dir exceeds a threshold or too many replicas hosted on the broker are offline because of log dir failures. The disk usage requires brokers reporting the volume capacity (Kafka 3.3+).",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: kafkaBrokerTargetId,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "broker node id",
+					Description: new("Find broker by cluster name and id"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.broker.node-id=\"\"",
+				},
+				{
+					Label:       "by cluster name",
+					Description: new("Find brokers by cluster name"),
+					Query:       "kafka.cluster.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("How long the check runs. The log dirs are described continuously for this duration."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("60s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "maxDiskUsage",
+				Label:        "Max Disk Usage (%)",
+				Description:  new("The maximum used share of the volume of every log dir. Set to 0 to not check the disk usage."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("85"),
+				MinValue:     new(0),
+				MaxValue:     new(100),
+				Required:     new(true),
+			},
+			{
+				Name:         "maxOfflineReplicas",
+				Label:        "Max Offline Replicas",
+				Description:  new("How many replicas hosted on the broker may be offline, e.g. because their log dir failed."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("0"),
+				MinValue:     new(0),
+				Required:     new(true),
+			},
+			{
+				Name:         "failEarly",
+				Label:        "Fail early",
+				Description:  new("If enabled, the check fails as soon as a threshold is violated. If disabled, the check keeps monitoring for the whole duration and only fails at the end of the step."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Advanced:     new(true),
+				Required:     new(false),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Kafka Log Dir Disk Usage",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_broker_log_dir_usage",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Disk usage (%)"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "error",
+							Title: "Error",
+						},
+					},
+				}),
+			},
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Kafka Offline Replicas",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_broker_offline_replicas",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Offline replicas"),
+				}),
+			},
+		}),
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("5s"),
+		}),
+	}
+}
+
+func (m *BrokerLogDirsCheckAction) Prepare(_ context.Context, state *BrokerLogDirsCheckState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	duration := request.Config["duration"].(float64)
+
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	state.BrokerID = extutil.ToInt32(extutil.MustHaveValue(request.Target.Attributes, "kafka.broker.node-id")[0])
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+	state.End = time.Now().Add(time.Millisecond * time.Duration(duration))
+	state.MaxDiskUsage = float64(extutil.ToInt64(request.Config["maxDiskUsage"]))
+	state.MaxOfflineReplicas = extutil.ToInt(request.Config["maxOfflineReplicas"])
+	state.FailEarly = true
+	if request.Config["failEarly"] != nil {
+		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
+	}
+
+	return nil, nil
+}
+
+func (m *BrokerLogDirsCheckAction) Start(ctx context.Context, state *BrokerLogDirsCheckState) (*action_kit_api.StartResult, error) {
+	statusResult, err := BrokerLogDirsCheckStatus(ctx, state)
+	if statusResult == nil {
+		return nil, err
+	}
+	return &action_kit_api.StartResult{
+		Error:    statusResult.Error,
+		Messages: statusResult.Messages,
+		Metrics:  statusResult.Metrics,
+	}, err
+}
+
+func (m *BrokerLogDirsCheckAction) Status(ctx context.Context, state *BrokerLogDirsCheckState) (*action_kit_api.StatusResult, error) {
+	return BrokerLogDirsCheckStatus(ctx, state)
+}
+
+func BrokerLogDirsCheckStatus(ctx context.Context, state *BrokerLogDirsCheckState) (*action_kit_api.StatusResult, error) {
+	now := time.Now()
+
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	kafkaClient, err := createNewClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer kafkaClient.Close()
+	client := kadm.NewClient(kafkaClient)
+
+	// The metadata request also makes the broker known to the client, so its log dirs can be described directly.
+	metadata, err := client.Metadata(ctx)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve metadata from Kafka. Full response: %v", err), err))
+	}
+	offlineReplicas := offlineReplicasOn(metadata.Topics, state.BrokerID)
+
+	// A broker that is down can't describe its log dirs, that is covered by Check Brokers. The offline replicas are
+	// still reported by the controller.
+	var messages []action_kit_api.Message
+	var logDirs []logDirUsage
+	if slices.Contains(metadata.Brokers.NodeIDs(), state.BrokerID) {
+		logDirs, err = describeLogDirUsageOfBroker(ctx, kafkaClient, state.BrokerID, false)
+		if err != nil {
+			messages = append(messages, action_kit_api.Message{
+				Level:   extutil.Ptr(action_kit_api.Warn),
+				Message: fmt.Sprintf("Failed to describe the log dirs of broker %d: %s", state.BrokerID, err.Error()),
+			})
+		}
+	} else {
+		messages = append(messages, action_kit_api.Message{
+			Level:   extutil.Ptr(action_kit_api.Warn),
+			Message: fmt.Sprintf("Broker %d isn't registered, its log dirs can't be described.", state.BrokerID),
+		})
+	}
+
+	completed := now.After(state.End)
+	var checkError *action_kit_api.ActionKitError
+	for _, deviation := range evaluateLogDirs(state, logDirs, offlineReplicas) {
+		if state.FailEarly {
+			checkError = &action_kit_api.ActionKitError{
+				Title:  deviation,
+				Status: extutil.Ptr(action_kit_api.Failed),
+			}
+			break
+		}
+		state.DeviationSeen = true
+		if state.DeviationTitle == "" {
+			state.DeviationTitle = deviation
+		}
+	}
+	if !state.FailEarly && completed && state.DeviationSeen {
+		checkError = &action_kit_api.ActionKitError{
+			Title:  state.DeviationTitle,
+			Status: extutil.Ptr(action_kit_api.Failed),
+		}
+	}
+
+	return &action_kit_api.StatusResult{
+		Completed: completed,
+		Error:     checkError,
+		Messages:  new(messages),
+		Metrics:   new(toLogDirMetrics(state, logDirs, offlineReplicas, now)),
+	}, nil
+}
+
+// offlineReplicasOn returns the partitions, as topic-partition, whose replica on the broker is offline.
+func offlineReplicasOn(topics kadm.TopicDetails, brokerID int32) []string {
+	var offline []string
+	for _, topic := range topics.Sorted() {
+		if topic.Err != nil {
+			continue
+		}
+		for _, partition := range topic.Partitions.Sorted() {
+			if slices.Contains(partition.OfflineReplicas, brokerID) {
+				offline = append(offline, fmt.Sprintf("%s-%d", topic.Topic, partition.Partition))
+			}
+		}
+	}
+	return offline
+}
+
+func evaluateLogDirs(state *BrokerLogDirsCheckState, logDirs []logDirUsage, offlineReplicas []string) []string {
+	var deviations []string
+	for _, logDir := range logDirs {
+		if logDir.Err != nil {
+			deviations = append(deviations, fmt.Sprintf("Log dir %s of broker %d is offline: %s.", logDir.Dir, state.BrokerID, logDir.Err.Error()))
+			continue
+		}
+		if used, ok := logDir.usedPercent(); ok && state.MaxDiskUsage > 0 && used > state.MaxDiskUsage {
+			deviations = append(deviations, fmt.Sprintf("Log dir %s of broker %d is %.1f%% full, at most %.0f%% allowed.", logDir.Dir, state.BrokerID, used, state.MaxDiskUsage))
+		}
+	}
+	if len(offlineReplicas) > state.MaxOfflineReplicas {
+		deviations = append(deviations, fmt.Sprintf("Broker %d has %d offline replica(s), at most %d allowed: %s.",
+			state.BrokerID, len(offlineReplicas), state.MaxOfflineReplicas, strings.Join(offlineReplicas, ", ")))
+	}
+	return deviations
+}
+
+func toLogDirMetrics(state *BrokerLogDirsCheckState, logDirs []logDirUsage, offlineReplicas []string, now time.Time) []action_kit_api.Metric {
+	metrics := make([]action_kit_api.Metric, 0, len(logDirs)+1)
+	for _, logDir := range logDirs {
+		used, ok := logDir.usedPercent()
+		metricState := "success"
+		errorMessage := ""
+		switch {
+		case logDir.Err != nil:
+			metricState = "danger"
+			errorMessage = logDir.Err.Error()
+		case !ok:
+			// Brokers that don't report the capacity have no disk usage to chart
+			continue
+		case state.MaxDiskUsage > 0 && used > state.MaxDiskUsage:
+			metricState = "warn"
+		}
+		metrics = append(metrics, action_kit_api.Metric{
+			Name: new("kafka_broker_log_dir_usage"),
+			Metric: map[string]string{
+				"id":    fmt.Sprintf("broker-%d:%s", state.BrokerID, logDir.Dir),
+				"state": metricState,
+				"error": errorMessage,
+			},
+			Timestamp: now,
+			Value:     used,
+		})
+	}
+	metricState := "success"
+	if len(offlineReplicas) > state.MaxOfflineReplicas {
+		metricState = "danger"
+	}
+	metrics = append(metrics, action_kit_api.Metric{
+		Name: new("kafka_broker_offline_replicas"),
+		Metric: map[string]string{
+			"id":    fmt.Sprintf("broker-%d", state.BrokerID),
+			"state": metricState,
+		},
+		Timestamp: now,
+		Value:     float64(len(offlineReplicas)),
+	})
+	return metrics
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestCheckBrokerLogDirs_Describe(t *testing.T) {
+	desc := (&BrokerLogDirsCheckAction{}).Describe()
+
+	assert.Equal(t, "Check Broker Log Dirs", desc.Label)
+	assert.Equal(t, kafkaBrokerTargetId+".check-log-dirs", desc.Id)
+	assert.Equal(t, kafkaBrokerTargetId, desc.TargetSelection.TargetType)
+	assert.Equal(t, "Kafka", *desc.Technology)
+}
+
+func describedLogDir(dir string, totalBytes, usableBytes int64, errorCode int16, sizes ...int64) kmsg.DescribeLogDirsResponseDir {
+	logDir := kmsg.NewDescribeLogDirsResponseDir()
+	logDir.Dir = dir
+	logDir.TotalBytes = totalBytes
+	logDir.UsableBytes = usableBytes
+	logDir.ErrorCode = errorCode
+	topic := kmsg.NewDescribeLogDirsResponseDirTopic()
+	topic.Topic = "orders"
+	for partition, size := range sizes {
+		p := kmsg.NewDescribeLogDirsResponseDirTopicPartition()
+		p.Partition = int32(partition)
+		p.Size = size
+		topic.Partitions = append(topic.Partitions, p)
+	}
+	logDir.Topics = []kmsg.DescribeLogDirsResponseDirTopic{topic}
+	return logDir
+}
+
+func TestAddLogDirAttributes(t *testing.T) {
+	//Given
+	logDirs := toLogDirUsages([]kmsg.DescribeLogDirsResponseDir{
+		describedLogDir("/data/b", 1000, 400, 0, 100, 50),
+		describedLogDir("/data/a", 2000, 1500, 0, 25),
+		describedLogDir("/data/c", -1, -1, kerr.KafkaStorageError.Code),
+	})
+	attributes := map[string][]string{}
+
+	//When
+	addLogDirAttributes(attributes, logDirs)
+
+	//Then
+	assert.Equal(t, []string{"/data/a", "/data/b"}, attributes["kafka.broker.log-dirs"])
+	assert.Equal(t, []string{"/data/c"}, attributes["kafka.broker.log-dirs.offline"])
+	assert.Equal(t, []string{"/data/a=2000", "/data/b=1000"}, attributes["kafka.broker.log-dirs.total-bytes"])
+	assert.Equal(t, []string{"/data/a=1500", "/data/b=400"}, attributes["kafka.broker.log-dirs.usable-bytes"])
+	assert.Equal(t, []string{"/data/a=25", "/data/b=150"}, attributes["kafka.broker.log-dirs.replica-bytes"])
+}
+
+func TestAddLogDirAttributes_WithoutCapacity(t *testing.T) {
+	//Given
+	logDirs := toLogDirUsages([]kmsg.DescribeLogDirsResponseDir{describedLogDir("/data", -1, -1, 0, 10)})
+	attributes := map[string][]string{}
+
+	//When
+	addLogDirAttributes(attributes, logDirs)
+
+	//Then
+	assert.Equal(t, []string{"/data"}, attributes["kafka.broker.log-dirs"])
+	assert.Equal(t, []string{"/data=10"}, attributes["kafka.broker.log-dirs.replica-bytes"])
+	assert.NotContains(t, attributes, "kafka.broker.log-dirs.total-bytes")
+	assert.NotContains(t, attributes, "kafka.broker.log-dirs.usable-bytes")
+	assert.NotContains(t, attributes, "kafka.broker.log-dirs.offline")
+}
+
+func TestOfflineReplicasOn(t *testing.T) {
+	//Given
+	topics := kadm.TopicDetails{
+		"orders": {Topic: "orders", Partitions: kadm.PartitionDetails{
+			0: {Topic: "orders", Partition: 0, Replicas: []int32{1, 2}, OfflineReplicas: []int32{2}},
+			1: {Topic: "orders", Partition: 1, Replicas: []int32{1, 2}, OfflineReplicas: []int32{1}},
+		}},
+		"payments": {Topic: "payments", Partitions: kadm.PartitionDetails{
+			0: {Topic: "payments", Partition: 0, Replicas: []int32{2, 3}, OfflineReplicas: []int32{2, 3}},
+		}},
+	}
+
+	//When
+	offline := offlineReplicasOn(topics, 2)
+
+	//Then
+	assert.Equal(t, []string{"orders-0", "payments-0"}, offline)
+}
+
+func TestEvaluateLogDirs(t *testing.T) {
+	//Given
+	state := &BrokerLogDirsCheckState{BrokerID: 1, MaxDiskUsage: 80, MaxOfflineReplicas: 1}
+	logDirs := toLogDirUsages([]kmsg.DescribeLogDirsResponseDir{
+		describedLogDir("/data/a", 1000, 100, 0),
+		describedLogDir("/data/b", 1000, 900, 0),
+		describedLogDir("/data/c", -1, -1, kerr.KafkaStorageError.Code),
+		describedLogDir("/data/d", -1, -1, 0),
+	})
+
+	//When
+	deviations := evaluateLogDirs(state, logDirs, []string{"orders-0", "orders-1"})
+
+	//Then
+	assert.Equal(t, []string{
+		"Log dir /data/a of broker 1 is 90.0% full, at most 80% allowed.",
+		"Log dir /data/c of broker 1 is offline: " + kerr.KafkaStorageError.Error() + ".",
+		"Broker 1 has 2 offline replica(s), at most 1 allowed: orders-0, orders-1.",
+	}, deviations)
+
+	metrics := toLogDirMetrics(state, logDirs, []string{"orders-0", "orders-1"}, time.Now())
+	require.Len(t, metrics, 4)
+	assert.Equal(t, "broker-1:/data/a", metrics[0].Metric["id"])
+	assert.Equal(t, "warn", metrics[0].Metric["state"])
+	assert.Equal(t, 90.0, metrics[0].Value)
+	assert.Equal(t, "success", metrics[1].Metric["state"])
+	assert.Equal(t, "danger", metrics[2].Metric["state"])
+	assert.Equal(t, "kafka_broker_offline_replicas", *metrics[3].Name)
+	assert.Equal(t, 2.0, metrics[3].Value)
+}
+
+func TestEvaluateLogDirs_DiskUsageDisabled(t *testing.T) {
+	//Given
+	state := &BrokerLogDirsCheckState{BrokerID: 1}
+	logDirs := toLogDirUsages([]kmsg.DescribeLogDirsResponseDir{describedLogDir("/data", 1000, 0, 0)})
+
+	//When
+	deviations := evaluateLogDirs(state, logDirs, nil)
+
+	//Then
+	assert.Empty(t, deviations)
+}
@@ -0,0 +1,274 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+const (
+	brokerLossScopeBroker = "broker"
+	brokerLossScopeRack   = "rack"
+)
+
+type BrokerLossImpactCheckAction struct{}
+
+type BrokerLossImpactCheckState struct {
+	BrokerID       int32
+	Rack           string // only set for the rack scope
+	CriticalTopics []string
+	BrokerHosts    []string
+	ClusterName    string
+}
+
+// brokerLossImpact is the report of what happens to the partitions if the failed brokers were gone.
+type brokerLossImpact struct {
+	FailedBrokers []int32                 `json:"failedBrokers"`
+	Rack          string                  `json:"rack,omitempty"`
+	Topics        []topicBrokerLossImpact `json:"topics"`
+}
+
+// topicBrokerLossImpact holds the affected partitions of a single topic.
+type topicBrokerLossImpact struct {
+	Topic             string  `json:"topic"`
+	Critical          bool    `json:"critical"`
+	MinInSyncReplicas int     `json:"minInSyncReplicas,omitempty"`
+	Offline           []int32 `json:"offline,omitempty"`     // no in-sync replica is left to take over
+	LeaderLoss        []int32 `json:"leaderLoss,omitempty"`  // the leader moves to another in-sync replica
+	UnderMinIsr       []int32 `json:"underMinIsr,omitempty"` // fewer in-sync replicas than min.insync.replicas are left
+}
+
+func (t topicBrokerLossImpact) unavailable() bool {
+	return len(t.Offline) > 0 || len(t.UnderMinIsr) > 0
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[BrokerLossImpactCheckState] = (*BrokerLossImpactCheckAction)(nil)
+)
+
+func NewBrokerLossImpactCheckAction() action_kit_sdk.Action[BrokerLossImpactCheckState] {
+	return &BrokerLossImpactCheckAction{}
+}
+
+func (m *BrokerLossImpactCheckAction) NewEmptyState() BrokerLossImpactCheckState {
+	return BrokerLossImpactCheckState{}
+}
+
+func (m *BrokerLossImpactCheckAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.check-loss-impact", kafkaBrokerTargetId),
+		Label:       "Check Broker Loss Impact",
+		Description: "Analyze which partitions would go offline, lose their leader or fall under min.insync.replicas if the broker, or all brokers of its rack, failed. Nothing is changed in the cluster. The report is attached as artifact and the check fails if a critical topic would become unavailable, so the topology can be validated before running a destructive broker attack. If several brokers are targeted, the loss of each one is analyzed separately.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: kafkaBrokerTargetId,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "broker node id",
+					Description: new("Find broker by cluster name and id"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.broker.node-id=\"\"",
+				},
+				{
+					Label:       "broker rack",
+					Description: new("Find brokers by cluster name and rack"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.broker.rack=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInstantaneous,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "scope",
+				Label:        "Failure Scope",
+				Description:  new("Whether only the broker fails, or all brokers in the same rack (kafka.broker.rack) as the broker."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(brokerLossScopeBroker),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Broker",
+						Value: brokerLossScopeBroker,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Rack",
+						Value: brokerLossScopeRack,
+					},
+				}),
+				Required: new(true),
+			},
+			{
+				Name:        "criticalTopics",
+				Label:       "Critical Topics",
+				Description: new("The check fails if one of these topics would have a partition offline or under min.insync.replicas. Leave empty to treat all topics as critical."),
+				Type:        action_kit_api.ActionParameterTypeStringArray,
+				Required:    new(false),
+			},
+		},
+	}
+}
+
+func (m *BrokerLossImpactCheckAction) Prepare(_ context.Context, state *BrokerLossImpactCheckState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	state.BrokerID = extutil.ToInt32(extutil.MustHaveValue(request.Target.Attributes, "kafka.broker.node-id")[0])
+	if request.Config["scope"] == brokerLossScopeRack {
+		rack := request.Target.Attributes["kafka.broker.rack"]
+		if len(rack) == 0 {
+			return nil, fmt.Errorf("broker %d has no rack configured, the rack scope can't be analyzed", state.BrokerID)
+		}
+		state.Rack = rack[0]
+	}
+	if request.Config["criticalTopics"] != nil {
+		state.CriticalTopics = extutil.ToStringArray(request.Config["criticalTopics"])
+	}
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+
+	return nil, nil
+}
+
+func (m *BrokerLossImpactCheckAction) Start(ctx context.Context, state *BrokerLossImpactCheckState) (*action_kit_api.StartResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	metadata, err := client.Metadata(ctx)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve metadata from Kafka. Full response: %v", err), err))
+	}
+	minInSyncReplicas, err := describeMinInSyncReplicas(ctx, client, metadata.Topics.Names()...)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to describe the min.insync.replicas of the topics. Full response: %v", err), err))
+	}
+
+	impact := analyzeBrokerLoss(metadata.Topics, minInSyncReplicas, failedBrokersOf(state, metadata.Brokers), state.CriticalTopics)
+	impact.Rack = state.Rack
+	artifact, err := toBrokerLossImpactArtifact(impact)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: describeBrokerLossImpact(impact),
+		}},
+		Artifacts: new([]action_kit_api.Artifact{artifact}),
+	}
+	var unavailable []string
+	for _, topic := range impact.Topics {
+		if topic.Critical && topic.unavailable() {
+			unavailable = append(unavailable, topic.Topic)
+		}
+	}
+	if len(unavailable) > 0 {
+		result.Error = &action_kit_api.ActionKitError{
+			Title:  fmt.Sprintf("Critical topic(s) %s would become unavailable if broker(s) %s failed.", strings.Join(unavailable, ", "), joinInt32s(impact.FailedBrokers)),
+			Status: extutil.Ptr(action_kit_api.Failed),
+		}
+	}
+	return result, nil
+}
+
+// failedBrokersOf returns the broker of the state, or all brokers in its rack.
+func failedBrokersOf(state *BrokerLossImpactCheckState, brokers kadm.BrokerDetails) []int32 {
+	if state.Rack == "" {
+		return []int32{state.BrokerID}
+	}
+	failed := []int32{state.BrokerID}
+	for _, broker := range brokers {
+		if broker.Rack != nil && *broker.Rack == state.Rack && broker.NodeID != state.BrokerID {
+			failed = append(failed, broker.NodeID)
+		}
+	}
+	slices.Sort(failed)
+	return failed
+}
+
+// analyzeBrokerLoss computes the affected partitions if the failed brokers were gone. Only unaffected topics are left
+// out of the report. Topics missing in minInSyncReplicas are not checked for min ISR.
+func analyzeBrokerLoss(topics kadm.TopicDetails, minInSyncReplicas map[string]int, failed []int32, criticalTopics []string) brokerLossImpact {
+	impact := brokerLossImpact{FailedBrokers: failed, Topics: []topicBrokerLossImpact{}}
+	for _, topic := range topics.Sorted() {
+		if topic.Err != nil {
+			continue
+		}
+		topicImpact := topicBrokerLossImpact{
+			Topic:             topic.Topic,
+			Critical:          len(criticalTopics) == 0 || slices.Contains(criticalTopics, topic.Topic),
+			MinInSyncReplicas: minInSyncReplicas[topic.Topic],
+		}
+		for _, partition := range topic.Partitions.Sorted() {
+			remainingIsr := slices.DeleteFunc(slices.Clone(partition.ISR), func(replica int32) bool { return slices.Contains(failed, replica) })
+			if len(remainingIsr) == 0 {
+				topicImpact.Offline = append(topicImpact.Offline, partition.Partition)
+				continue
+			}
+			if slices.Contains(failed, partition.Leader) {
+				topicImpact.LeaderLoss = append(topicImpact.LeaderLoss, partition.Partition)
+			}
+			if minIsr, ok := minInSyncReplicas[topic.Topic]; ok && len(remainingIsr) < minIsr {
+				topicImpact.UnderMinIsr = append(topicImpact.UnderMinIsr, partition.Partition)
+			}
+		}
+		if len(topicImpact.Offline) > 0 || len(topicImpact.LeaderLoss) > 0 || len(topicImpact.UnderMinIsr) > 0 {
+			impact.Topics = append(impact.Topics, topicImpact)
+		}
+	}
+	return impact
+}
+
+func describeBrokerLossImpact(impact brokerLossImpact) string {
+	var offline, leaderLoss, underMinIsr int
+	for _, topic := range impact.Topics {
+		offline += len(topic.Offline)
+		leaderLoss += len(topic.LeaderLoss)
+		underMinIsr += len(topic.UnderMinIsr)
+	}
+	failed := fmt.Sprintf("broker(s) %s", joinInt32s(impact.FailedBrokers))
+	if impact.Rack != "" {
+		failed = fmt.Sprintf("rack %s with %s", impact.Rack, failed)
+	}
+	return fmt.Sprintf("If %s failed, %d partition(s) would go offline, %d would lose their leader and %d would fall under min.insync.replicas, affecting %d topic(s).",
+		failed, offline, leaderLoss, underMinIsr, len(impact.Topics))
+}
+
+func toBrokerLossImpactArtifact(impact brokerLossImpact) (action_kit_api.Artifact, error) {
+	data, err := json.MarshalIndent(impact, "", "  ")
+	if err != nil {
+		return action_kit_api.Artifact{}, fmt.Errorf("failed to write broker loss impact: %w", err)
+	}
+	return action_kit_api.Artifact{
+		Label: "broker-loss-impact.json",
+		Data:  base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
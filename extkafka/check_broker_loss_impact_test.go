@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestCheckBrokerLossImpact_Describe(t *testing.T) {
+	desc := (&BrokerLossImpactCheckAction{}).Describe()
+
+	assert.Equal(t, "Check Broker Loss Impact", desc.Label)
+	assert.Equal(t, kafkaBrokerTargetId+".check-loss-impact", desc.Id)
+	assert.Equal(t, kafkaBrokerTargetId, desc.TargetSelection.TargetType)
+	assert.Equal(t, "Kafka", *desc.Technology)
+}
+
+func TestCheckBrokerLossImpact_PrepareRackScopeRequiresRack(t *testing.T) {
+	//Given
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {SeedBrokers: "localhost:9092"},
+	})
+	action := BrokerLossImpactCheckAction{}
+	state := action.NewEmptyState()
+	request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"kafka.cluster.name":   {"test-cluster"},
+				"kafka.broker.node-id": {"2"},
+			},
+		},
+		Config:      map[string]any{"scope": brokerLossScopeRack},
+		ExecutionId: uuid.New(),
+	})
+
+	//When
+	_, err := action.Prepare(t.Context(), &state, request)
+
+	//Then
+	assert.EqualError(t, err, "broker 2 has no rack configured, the rack scope can't be analyzed")
+}
+
+func TestFailedBrokersOf(t *testing.T) {
+	brokers := kadm.BrokerDetails{
+		{NodeID: 1, Rack: new("eu-1a")},
+		{NodeID: 2, Rack: new("eu-1b")},
+		{NodeID: 3, Rack: new("eu-1a")},
+		{NodeID: 4},
+	}
+
+	assert.Equal(t, []int32{2}, failedBrokersOf(&BrokerLossImpactCheckState{BrokerID: 2}, brokers))
+	assert.Equal(t, []int32{1, 3}, failedBrokersOf(&BrokerLossImpactCheckState{BrokerID: 3, Rack: "eu-1a"}, brokers))
+}
+
+func TestAnalyzeBrokerLoss(t *testing.T) {
+	//Given
+	topics := kadm.TopicDetails{
+		"orders": {
+			Topic: "orders",
+			Partitions: kadm.PartitionDetails{
+				0: {Partition: 0, Leader: 1, Replicas: []int32{1, 2, 3}, ISR: []int32{1, 2, 3}},
+				1: {Partition: 1, Leader: 2, Replicas: []int32{2, 3, 1}, ISR: []int32{2, 3}},
+				2: {Partition: 2, Leader: 3, Replicas: []int32{3, 1, 2}, ISR: []int32{3, 1, 2}},
+			},
+		},
+		"audit": {
+			Topic: "audit",
+			Partitions: kadm.PartitionDetails{
+				0: {Partition: 0, Leader: 1, Replicas: []int32{1}, ISR: []int32{1}},
+			},
+		},
+		"payments": {
+			Topic: "payments",
+			Partitions: kadm.PartitionDetails{
+				0: {Partition: 0, Leader: 3, Replicas: []int32{3, 4}, ISR: []int32{3, 4}},
+			},
+		},
+	}
+
+	//When
+	impact := analyzeBrokerLoss(topics, map[string]int{"orders": 2}, []int32{1, 2}, []string{"orders"})
+
+	//Then
+	assert.Equal(t, []topicBrokerLossImpact{
+		{Topic: "audit", Critical: false, Offline: []int32{0}},
+		{Topic: "orders", Critical: true, MinInSyncReplicas: 2, LeaderLoss: []int32{0, 1}, UnderMinIsr: []int32{0, 1, 2}},
+	}, impact.Topics)
+	assert.Equal(t, "If broker(s) 1,2 failed, 1 partition(s) would go offline, 2 would lose their leader and 3 would fall under min.insync.replicas, affecting 2 topic(s).", describeBrokerLossImpact(impact))
+
+	artifact, err := toBrokerLossImpactArtifact(impact)
+	require.NoError(t, err)
+	data, err := base64.StdEncoding.DecodeString(artifact.Data)
+	require.NoError(t, err)
+	assert.Equal(t, "broker-loss-impact.json", artifact.Label)
+	assert.Contains(t, string(data), `"underMinIsr": [`)
+}
@@ -16,6 +16,7 @@ import (
 	extension_kit "github.com/steadybit/extension-kit"
 	"github.com/steadybit/extension-kit/extbuild"
 	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
 
 	"slices"
 	"sort"
@@ -36,6 +37,7 @@ type CheckBrokersState struct {
 	// that a deviating change was observed during the step so the failure can be reported once the step ends.
 	DeviationSeen     bool
 	DeviationTitle    string
+	MaxProbeLatency   time.Duration // 0 if the brokers aren't probed
 	BrokerHosts       []string
 	ClusterName       string // Cluster name for multi-cluster support
 }
@@ -63,7 +65,7 @@ func (m *CheckBrokersAction) Describe() action_kit_api.ActionDescription {
 	return action_kit_api.ActionDescription{
 		Id:          fmt.Sprintf("%s.check", kafkaBrokerTargetId),
 		Label:       "Check Brokers",
-		Description: "Monitor broker-level changes such as controller elections and broker downtime during an experiment. For topic partition changes, use Check Partitions instead. For consumer group state, use Check Consumer State.",
+		Description: "Monitor broker-level changes such as controller elections and broker downtime during an experiment, and optionally probe every registered broker to detect brokers that are hung or slow. For topic partition changes, use Check Partitions instead. For consumer group state, use Check Consumer State.",
 		Version:     extbuild.GetSemverVersionStringOrUnknown(),
 		Icon:        new(kafkaIcon),
 		TargetSelection: new(action_kit_api.TargetSelection{
@@ -125,6 +127,14 @@ func (m *CheckBrokersAction) Describe() action_kit_api.ActionDescription {
 				}),
 				Required: new(true),
 			},
+			{
+				Name:         "maxProbeLatency",
+				Label:        "Max Broker Response Time",
+				Description:  new("If set, every registered broker is probed with an ApiVersions request on each poll. The check fails if a broker doesn't respond or responds slower than this. The connection is set up before the request is timed, so the response time excludes the TCP, TLS and SASL handshakes. If 'Broker downtime' is expected, unresponsive brokers don't fail the check. Set to 0 to not probe the brokers."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("0s"),
+				Required:     new(false),
+			},
 			{
 				Name:         "failEarly",
 				Label:        "Fail early",
@@ -158,6 +168,24 @@ func (m *CheckBrokersAction) Describe() action_kit_api.ActionDescription {
 					Hide: new(true),
 				}),
 			},
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Kafka Broker Response Time",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_broker_probe_latency",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Response time (ms)"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "error",
+							Title: "Error",
+						},
+					},
+				}),
+			},
 		}),
 		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
 			CallInterval: new("2s"),
@@ -205,6 +233,10 @@ func (m *CheckBrokersAction) Prepare(ctx context.Context, state *CheckBrokersSta
 	if request.Config["failEarly"] != nil {
 		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
 	}
+	// Experiments created before the probe existed don't set the parameter and keep not probing the brokers.
+	if request.Config["maxProbeLatency"] != nil {
+		state.MaxProbeLatency = time.Duration(extutil.ToInt64(request.Config["maxProbeLatency"])) * time.Millisecond
+	}
 
 	state.End = end
 	state.ExpectedChanges = expectedState
@@ -241,17 +273,24 @@ func BrokerCheckStatus(ctx context.Context, state *CheckBrokersState) (*action_k
 		return nil, fmt.Errorf("failed to get cluster config: %w", err)
 	}
 
-	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	kafkaClient, err := createNewClientWithConfig(state.BrokerHosts, clusterConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
 	}
-	defer client.Close()
+	defer kafkaClient.Close()
+	client := kadm.NewClient(kafkaClient)
 
+	// The metadata request also makes the brokers known to the client, so they can be probed directly.
 	metadata, err := client.BrokerMetadata(ctx)
 	if err != nil {
 		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve brokers from Kafka. Full response: %v", err), err))
 	}
 
+	var probes []brokerProbe
+	if state.MaxProbeLatency > 0 {
+		probes = probeBrokers(ctx, kafkaClient, metadata.Brokers.NodeIDs(), 2*state.MaxProbeLatency)
+	}
+
 	// Check for changes
 	changes := make(map[string][]int32)
 
@@ -328,6 +367,11 @@ func BrokerCheckStatus(ctx context.Context, state *CheckBrokersState) (*action_k
 		}
 	}
 
+	// A registered broker that is hung or slow is a deviation, unless its downtime is expected.
+	for _, deviation := range evaluateBrokerProbes(probes, state.MaxProbeLatency, slices.Contains(state.ExpectedChanges, BrokerDowntime)) {
+		recordDeviation(deviation)
+	}
+
 	if !state.FailEarly && completed && state.DeviationSeen {
 		checkError = new(action_kit_api.ActionKitError{
 			Title:  state.DeviationTitle,
@@ -338,6 +382,7 @@ func BrokerCheckStatus(ctx context.Context, state *CheckBrokersState) (*action_k
 	metrics := []action_kit_api.Metric{
 		*toBrokerChangeMetric(state.ClusterName, state.ExpectedChanges, keys, changes, now),
 	}
+	metrics = append(metrics, toBrokerProbeMetrics(probes, state.MaxProbeLatency, now)...)
 
 	return &action_kit_api.StatusResult{
 		Completed: completed,
@@ -28,7 +28,7 @@ func TestCheckBrokers_Describe(t *testing.T) {
 	response := action.Describe()
 
 	//Then
-	assert.Equal(t, "Monitor broker-level changes such as controller elections and broker downtime during an experiment. For topic partition changes, use Check Partitions instead. For consumer group state, use Check Consumer State.", response.Description)
+	assert.Equal(t, "Monitor broker-level changes such as controller elections and broker downtime during an experiment, and optionally probe every registered broker to detect brokers that are hung or slow. For topic partition changes, use Check Partitions instead. For consumer group state, use Check Consumer State.", response.Description)
 	assert.Equal(t, "Check Brokers", response.Label)
 	assert.Equal(t, fmt.Sprintf("%s.check", kafkaBrokerTargetId), response.Id)
 	assert.Equal(t, new("Kafka"), response.Technology)
@@ -72,6 +72,7 @@ func TestCheckBrokers_Prepare(t *testing.T) {
 					"expectedChanges": []string{"test"},
 					"changeCheckMode": "allTheTime",
 					"duration":        10000,
+					"maxProbeLatency": 500,
 				},
 				ExecutionId: uuid.New(),
 			}),
@@ -80,6 +81,7 @@ func TestCheckBrokers_Prepare(t *testing.T) {
 				ExpectedChanges:   []string{"test"},
 				StateCheckMode:    "allTheTime",
 				StateCheckSuccess: false,
+				MaxProbeLatency:   500 * time.Millisecond,
 			},
 		},
 	}
@@ -101,6 +103,7 @@ func TestCheckBrokers_Prepare(t *testing.T) {
 				assert.Equal(t, tt.wantedState.ExpectedChanges, state.ExpectedChanges)
 				assert.Equal(t, tt.wantedState.StateCheckMode, state.StateCheckMode)
 				assert.Equal(t, tt.wantedState.StateCheckSuccess, state.StateCheckSuccess)
+				assert.Equal(t, tt.wantedState.MaxProbeLatency, state.MaxProbeLatency)
 				assert.True(t, state.FailEarly) // defaults to true when not provided (non-breaking)
 				assert.NotNil(t, state.End)
 			}
@@ -227,3 +230,32 @@ func TestCheckBrokers_Status(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateBrokerProbes(t *testing.T) {
+	//Given
+	probes := []brokerProbe{
+		{NodeID: 1, Latency: 20 * time.Millisecond},
+		{NodeID: 2, Latency: 1500 * time.Millisecond},
+		{NodeID: 3, Latency: 2 * time.Second, Err: context.DeadlineExceeded},
+		{NodeID: 4, Latency: 5 * time.Millisecond, Err: fmt.Errorf("connection refused")},
+	}
+
+	//When
+	deviations := evaluateBrokerProbes(probes, time.Second, false)
+	deviationsWithDowntime := evaluateBrokerProbes(probes, time.Second, true)
+	metrics := toBrokerProbeMetrics(probes, time.Second, time.Now())
+
+	//Then
+	assert.Equal(t, []string{
+		"Broker 2 responded in 1.5s, at most 1s allowed.",
+		"Broker 3 is registered but didn't respond within 2s.",
+		"Broker 4 is registered but unresponsive: connection refused.",
+	}, deviations)
+	assert.Equal(t, []string{"Broker 2 responded in 1.5s, at most 1s allowed."}, deviationsWithDowntime)
+	require.Len(t, metrics, 4)
+	assert.Equal(t, map[string]string{"id": "broker-1", "state": "success", "error": ""}, metrics[0].Metric)
+	assert.Equal(t, 20.0, metrics[0].Value)
+	assert.Equal(t, "warn", metrics[1].Metric["state"])
+	assert.Equal(t, "danger", metrics[2].Metric["state"])
+	assert.Equal(t, "connection refused", metrics[3].Metric["error"])
+}
@@ -5,6 +5,7 @@ package extkafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"slices"
 	"strings"
@@ -18,12 +19,14 @@ import (
 	"github.com/steadybit/extension-kit/extbuild"
 	"github.com/steadybit/extension-kit/extutil"
 	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
 )
 
 type ConsumerGroupCheckAction struct{}
 
 type ConsumerGroupCheckState struct {
 	ConsumerGroupName string
+	Protocol          string // one of the consumerGroupProtocol constants, classic for targets discovered before it was known
 	TopicName         string
 	End               time.Time
 	ExpectedState     []string
@@ -56,7 +59,7 @@ func (m *ConsumerGroupCheckAction) Describe() action_kit_api.ActionDescription {
 	return action_kit_api.ActionDescription{
 		Id:          fmt.Sprintf("%s.check", kafkaConsumerTargetId),
 		Label:       "Check Consumer State",
-		Description: "Monitor consumer group state transitions (Stable, Dead, Rebalancing) during an experiment. For broker-level monitoring, use Check Brokers. For topic partition changes, use Check Partitions.",
+		Description: "Monitor consumer group state transitions (Stable, Dead, Rebalancing) during an experiment. Supports classic and next-gen (KIP-848) consumer groups as well as share groups (KIP-932). For broker-level monitoring, use Check Brokers. For topic partition changes, use Check Partitions.",
 		Version:     extbuild.GetSemverVersionStringOrUnknown(),
 		Icon:        new(kafkaIcon),
 		TargetSelection: new(action_kit_api.TargetSelection{
@@ -86,7 +89,7 @@ func (m *ConsumerGroupCheckAction) Describe() action_kit_api.ActionDescription {
 			{
 				Name:        "expectedStateList",
 				Label:       "Expected State List",
-				Description: new("Which consumer group states to expect. The check succeeds if the group is in any of the selected states. This parameter is specific to consumer group checks — for topic partition checks, use 'expectedChanges' instead."),
+				Description: new("Which consumer group states to expect. The check succeeds if the group is in any of the selected states. PreparingRebalance and CompletingRebalance only apply to classic groups, Assigning and Reconciling only to next-gen consumer groups. This parameter is specific to consumer group checks — for topic partition checks, use 'expectedChanges' instead."),
 				Type:        action_kit_api.ActionParameterTypeStringArray,
 				Options: new([]action_kit_api.ParameterOption{
 					action_kit_api.ExplicitParameterOption{
@@ -101,6 +104,14 @@ func (m *ConsumerGroupCheckAction) Describe() action_kit_api.ActionDescription {
 						Label: "CompletingRebalance",
 						Value: "CompletingRebalance",
 					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Assigning (next-gen)",
+						Value: "Assigning",
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Reconciling (next-gen)",
+						Value: "Reconciling",
+					},
 					action_kit_api.ExplicitParameterOption{
 						Label: "Stable",
 						Value: "Stable",
@@ -206,6 +217,10 @@ func (m *ConsumerGroupCheckAction) Prepare(_ context.Context, state *ConsumerGro
 	}
 
 	state.ConsumerGroupName = request.Target.Attributes["kafka.consumer-group.name"][0]
+	state.Protocol = consumerGroupProtocolClassic
+	if protocol := request.Target.Attributes["kafka.consumer-group.protocol"]; len(protocol) > 0 {
+		state.Protocol = protocol[0]
+	}
 	state.ClusterName = clusterName
 	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
 	state.End = end
@@ -246,20 +261,11 @@ func ConsumerGroupCheckStatus(ctx context.Context, state *ConsumerGroupCheckStat
 	}
 	defer client.Close()
 
-	groups, err := client.DescribeGroups(ctx, state.ConsumerGroupName)
+	group, err := describeConsumerGroupState(ctx, client, state.ConsumerGroupName, state.Protocol)
 	if err != nil {
 		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve consumer groups from Kafka for name %s. Full response: %v", state.ConsumerGroupName, err), err))
 	}
 
-	var group kadm.DescribedGroup
-	if len(groups.Sorted()) == 0 {
-		log.Err(err).Msgf("No consumer group with that name %s.", state.ConsumerGroupName)
-	} else if len(groups.Sorted()) > 1 {
-		log.Err(err).Msgf("More than 1 consumer group with that name %s.", state.ConsumerGroupName)
-	} else {
-		group = groups.Sorted()[0]
-	}
-
 	completed := now.After(state.End)
 	var checkError *action_kit_api.ActionKitError
 
@@ -320,7 +326,60 @@ func ConsumerGroupCheckStatus(ctx context.Context, state *ConsumerGroupCheckStat
 	}, nil
 }
 
-func toConsumerGroupMetric(group kadm.DescribedGroup, now time.Time) *action_kit_api.Metric {
+// consumerGroupState is the state of a group independent of its protocol.
+type consumerGroupState struct {
+	Group string
+	State string
+}
+
+// describeConsumerGroupState describes the group using the describe API of its protocol. A next-gen consumer or
+// share group that doesn't exist (anymore) is reported as Dead, like the classic API does.
+func describeConsumerGroupState(ctx context.Context, client *kadm.Client, groupName string, protocol string) (consumerGroupState, error) {
+	switch protocol {
+	case consumerGroupProtocolConsumer:
+		groups, err := client.DescribeConsumerGroups(ctx, groupName)
+		if err != nil {
+			return consumerGroupState{}, err
+		}
+		group, ok := groups[groupName]
+		if !ok || errors.Is(group.Err, kerr.GroupIDNotFound) {
+			return consumerGroupState{Group: groupName, State: "Dead"}, nil
+		}
+		if group.Err != nil {
+			return consumerGroupState{}, group.Err
+		}
+		return consumerGroupState{Group: group.Group, State: group.State}, nil
+	case consumerGroupProtocolShare:
+		groups, err := client.DescribeShareGroups(ctx, groupName)
+		if err != nil {
+			return consumerGroupState{}, err
+		}
+		group, ok := groups[groupName]
+		if !ok || errors.Is(group.Err, kerr.GroupIDNotFound) {
+			return consumerGroupState{Group: groupName, State: "Dead"}, nil
+		}
+		if group.Err != nil {
+			return consumerGroupState{}, group.Err
+		}
+		return consumerGroupState{Group: group.GroupID, State: group.GroupState}, nil
+	default:
+		groups, err := client.DescribeGroups(ctx, groupName)
+		if err != nil {
+			return consumerGroupState{}, err
+		}
+		var group kadm.DescribedGroup
+		if len(groups.Sorted()) == 0 {
+			log.Error().Msgf("No consumer group with that name %s.", groupName)
+		} else if len(groups.Sorted()) > 1 {
+			log.Error().Msgf("More than 1 consumer group with that name %s.", groupName)
+		} else {
+			group = groups.Sorted()[0]
+		}
+		return consumerGroupState{Group: group.Group, State: group.State}, nil
+	}
+}
+
+func toConsumerGroupMetric(group consumerGroupState, now time.Time) *action_kit_api.Metric {
 	var tooltip string
 	var state string
 
@@ -329,7 +388,7 @@ func toConsumerGroupMetric(group kadm.DescribedGroup, now time.Time) *action_kit
 		state = "success"
 	} else if group.State == "Empty" {
 		state = "warn"
-	} else if group.State == "PreparingRebalance" {
+	} else if group.State == "PreparingRebalance" || group.State == "Assigning" || group.State == "Reconciling" {
 		state = "warn"
 	} else if group.State == "Dead" {
 		state = "danger"
@@ -0,0 +1,467 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+type ConsumerGroupRebalanceCheckAction struct{}
+
+type ConsumerGroupRebalanceCheckState struct {
+	ConsumerGroupName string
+	Protocol          string // one of the consumerGroupProtocol constants, classic for targets discovered before it was known
+	End               time.Time
+	MaxRebalances     int
+	MaxRecoveryTime   time.Duration
+	FailEarly         bool
+	DeviationSeen     bool
+	DeviationTitle    string
+	// Observed is the membership seen by the previous status call, nil before the first one.
+	Observed          *groupMembership
+	Rebalances        int
+	Joins             int
+	Leaves            int
+	AssignmentChanges int
+	// DisruptedSince is the time the group was first seen outside of Stable, nil while it is Stable.
+	DisruptedSince  *time.Time
+	LongestRecovery time.Duration
+	BrokerHosts     []string
+	ClusterName     string
+}
+
+// groupMembership is the membership of a group at a point in time, independent of its protocol.
+type groupMembership struct {
+	State       string
+	Generation  int32               // the group epoch, -1 if the protocol doesn't expose it (classic groups)
+	Assignments map[string][]string // the assigned partitions, formatted as "topic-partition", by member id
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[ConsumerGroupRebalanceCheckState]           = (*ConsumerGroupRebalanceCheckAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[ConsumerGroupRebalanceCheckState] = (*ConsumerGroupRebalanceCheckAction)(nil)
+)
+
+func NewConsumerGroupRebalanceCheckAction() action_kit_sdk.Action[ConsumerGroupRebalanceCheckState] {
+	return &ConsumerGroupRebalanceCheckAction{}
+}
+
+func (m *ConsumerGroupRebalanceCheckAction) NewEmptyState() ConsumerGroupRebalanceCheckState {
+	return ConsumerGroupRebalanceCheckState{}
+}
+
+func (m *ConsumerGroupRebalanceCheckAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.check-rebalance", kafkaConsumerTargetId),
+		Label:       "Check Consumer Group Rebalances",
+		Description: "Track rebalances, member joins and leaves and assignment changes of a consumer group and fail if the group rebalances too often or takes too long to return to Stable after a disruption. The time to recover is reported as metric. Classic groups don't expose their generation, so their rebalances are detected by state and assignment changes between two polls.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          kafkaConsumerTargetId,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionExactlyOne),
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "consumer group name",
+					Description: new("Find consumer group by cluster and name"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.consumer-group.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("How long the check runs. The group membership is polled continuously for this duration."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("60s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "maxRebalances",
+				Label:        "Max Rebalances",
+				Description:  new("The maximum number of rebalances allowed during the check."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("3"),
+				Required:     new(true),
+			},
+			{
+				Name:         "maxRecoveryTime",
+				Label:        "Max Time to Recover",
+				Description:  new("How long the group may stay out of Stable after a disruption, e.g. while rebalancing or after all members left."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "failEarly",
+				Label:        "Fail early",
+				Description:  new("If enabled, the check fails as soon as a threshold is exceeded. If disabled, the check keeps polling for the whole duration and only fails at the end of the step."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Advanced:     new(true),
+				Required:     new(false),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Consumer Group Time to Recover",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_consumer_group_time_to_recover",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Seconds out of Stable"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "state",
+							Title: "State",
+						},
+					},
+				}),
+			},
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Consumer Group Rebalances",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_consumer_group_rebalances",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Rebalances"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "members",
+							Title: "Members",
+						},
+					},
+				}),
+			},
+		}),
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("1s"),
+		}),
+	}
+}
+
+func (m *ConsumerGroupRebalanceCheckAction) Prepare(_ context.Context, state *ConsumerGroupRebalanceCheckState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	if len(request.Target.Attributes["kafka.consumer-group.name"]) == 0 {
+		return nil, fmt.Errorf("the target is missing the kafka.consumer-group.name attribute")
+	}
+	duration := request.Config["duration"].(float64)
+
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	state.ConsumerGroupName = request.Target.Attributes["kafka.consumer-group.name"][0]
+	state.Protocol = consumerGroupProtocolClassic
+	if protocol := request.Target.Attributes["kafka.consumer-group.protocol"]; len(protocol) > 0 {
+		state.Protocol = protocol[0]
+	}
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+	state.End = time.Now().Add(time.Millisecond * time.Duration(duration))
+	state.MaxRebalances = extutil.ToInt(request.Config["maxRebalances"])
+	state.MaxRecoveryTime = time.Duration(extutil.ToInt64(request.Config["maxRecoveryTime"])) * time.Millisecond
+	state.FailEarly = true
+	if request.Config["failEarly"] != nil {
+		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
+	}
+
+	return nil, nil
+}
+
+func (m *ConsumerGroupRebalanceCheckAction) Start(ctx context.Context, state *ConsumerGroupRebalanceCheckState) (*action_kit_api.StartResult, error) {
+	statusResult, err := ConsumerGroupRebalanceCheckStatus(ctx, state)
+	if statusResult == nil {
+		return nil, err
+	}
+	return &action_kit_api.StartResult{
+		Error:    statusResult.Error,
+		Messages: statusResult.Messages,
+		Metrics:  statusResult.Metrics,
+	}, err
+}
+
+func (m *ConsumerGroupRebalanceCheckAction) Status(ctx context.Context, state *ConsumerGroupRebalanceCheckState) (*action_kit_api.StatusResult, error) {
+	return ConsumerGroupRebalanceCheckStatus(ctx, state)
+}
+
+func ConsumerGroupRebalanceCheckStatus(ctx context.Context, state *ConsumerGroupRebalanceCheckState) (*action_kit_api.StatusResult, error) {
+	now := time.Now()
+
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	membership, err := describeGroupMembership(ctx, client, state.ConsumerGroupName, state.Protocol)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve consumer groups from Kafka for name %s. Full response: %v", state.ConsumerGroupName, err), err))
+	}
+	recovered := observeMembership(state, membership, now)
+
+	completed := now.After(state.End)
+	var checkError *action_kit_api.ActionKitError
+	for _, deviation := range evaluateRebalances(state, now) {
+		if state.FailEarly {
+			checkError = &action_kit_api.ActionKitError{
+				Title:  deviation,
+				Status: extutil.Ptr(action_kit_api.Failed),
+			}
+			break
+		}
+		state.DeviationSeen = true
+		if state.DeviationTitle == "" {
+			state.DeviationTitle = deviation
+		}
+	}
+	if !state.FailEarly && completed && state.DeviationSeen {
+		checkError = &action_kit_api.ActionKitError{
+			Title:  state.DeviationTitle,
+			Status: extutil.Ptr(action_kit_api.Failed),
+		}
+	}
+
+	var messages *[]action_kit_api.Message
+	if completed || checkError != nil {
+		messages = &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: describeRebalances(state, now),
+		}}
+	}
+
+	return &action_kit_api.StatusResult{
+		Completed: completed,
+		Error:     checkError,
+		Messages:  messages,
+		Metrics:   new(toRebalanceMetrics(state, recovered, now)),
+	}, nil
+}
+
+// describeGroupMembership describes the group using the describe API of its protocol. A group that doesn't exist
+// (anymore) is reported as Dead without members.
+func describeGroupMembership(ctx context.Context, client *kadm.Client, groupName string, protocol string) (groupMembership, error) {
+	dead := groupMembership{State: "Dead", Generation: -1, Assignments: map[string][]string{}}
+	switch protocol {
+	case consumerGroupProtocolConsumer:
+		groups, err := client.DescribeConsumerGroups(ctx, groupName)
+		if err != nil {
+			return groupMembership{}, err
+		}
+		group, ok := groups[groupName]
+		if !ok || errors.Is(group.Err, kerr.GroupIDNotFound) {
+			return dead, nil
+		}
+		if group.Err != nil {
+			return groupMembership{}, group.Err
+		}
+		membership := groupMembership{State: group.State, Generation: group.Epoch, Assignments: map[string][]string{}}
+		for _, member := range group.Members {
+			membership.Assignments[member.MemberID] = toPartitionNames(member.Assignment)
+		}
+		return membership, nil
+	case consumerGroupProtocolShare:
+		groups, err := client.DescribeShareGroups(ctx, groupName)
+		if err != nil {
+			return groupMembership{}, err
+		}
+		group, ok := groups[groupName]
+		if !ok || errors.Is(group.Err, kerr.GroupIDNotFound) {
+			return dead, nil
+		}
+		if group.Err != nil {
+			return groupMembership{}, group.Err
+		}
+		membership := groupMembership{State: group.GroupState, Generation: group.GroupEpoch, Assignments: map[string][]string{}}
+		for _, member := range group.Members {
+			membership.Assignments[member.MemberID] = toPartitionNames(member.Assignment)
+		}
+		return membership, nil
+	default:
+		groups, err := client.DescribeGroups(ctx, groupName)
+		if err != nil {
+			return groupMembership{}, err
+		}
+		group, ok := groups[groupName]
+		if !ok {
+			return dead, nil
+		}
+		if group.Err != nil {
+			return groupMembership{}, group.Err
+		}
+		membership := groupMembership{State: group.State, Generation: -1, Assignments: map[string][]string{}}
+		for _, member := range group.Members {
+			assigned := kadm.TopicsSet{}
+			if consumer, ok := member.Assigned.AsConsumer(); ok {
+				for _, topic := range consumer.Topics {
+					assigned.Add(topic.Topic, topic.Partitions...)
+				}
+			}
+			membership.Assignments[member.MemberID] = toPartitionNames(assigned)
+		}
+		return membership, nil
+	}
+}
+
+func toPartitionNames(topics kadm.TopicsSet) []string {
+	var names []string
+	for _, topic := range topics.Sorted() {
+		for _, partition := range topic.Partitions {
+			names = append(names, fmt.Sprintf("%s-%d", topic.Topic, partition))
+		}
+	}
+	return names
+}
+
+func isRebalancingState(state string) bool {
+	return state == "PreparingRebalance" || state == "CompletingRebalance" || state == "Assigning" || state == "Reconciling"
+}
+
+// isRebalance reports whether the group rebalanced between the two observations. If the generation isn't known, a
+// rebalance is counted when the group enters a rebalancing state, or when the assignment changed without a
+// rebalancing state in between, as a rebalance may complete within a single poll interval.
+func isRebalance(previous groupMembership, current groupMembership, assignmentChanged bool) bool {
+	if previous.Generation >= 0 && current.Generation >= 0 {
+		return current.Generation != previous.Generation
+	}
+	if isRebalancingState(previous.State) {
+		return false
+	}
+	return isRebalancingState(current.State) || assignmentChanged
+}
+
+// observeMembership compares the membership to the previous observation and updates the counters of the state. It
+// returns the time it took the group to recover if it returned to Stable with this observation, zero otherwise.
+func observeMembership(state *ConsumerGroupRebalanceCheckState, current groupMembership, now time.Time) time.Duration {
+	if previous := state.Observed; previous != nil {
+		for member := range current.Assignments {
+			if _, ok := previous.Assignments[member]; !ok {
+				state.Joins++
+			}
+		}
+		for member := range previous.Assignments {
+			if _, ok := current.Assignments[member]; !ok {
+				state.Leaves++
+			}
+		}
+		assignmentChanged := !maps.EqualFunc(previous.Assignments, current.Assignments, slices.Equal[[]string])
+		if assignmentChanged {
+			state.AssignmentChanges++
+		}
+		if isRebalance(*previous, current, assignmentChanged) {
+			state.Rebalances++
+		}
+	}
+	state.Observed = &current
+
+	if current.State != "Stable" {
+		if state.DisruptedSince == nil {
+			state.DisruptedSince = &now
+		}
+		return 0
+	}
+	if state.DisruptedSince == nil {
+		return 0
+	}
+	recovered := now.Sub(*state.DisruptedSince)
+	state.DisruptedSince = nil
+	state.LongestRecovery = max(state.LongestRecovery, recovered)
+	return recovered
+}
+
+func evaluateRebalances(state *ConsumerGroupRebalanceCheckState, now time.Time) []string {
+	var deviations []string
+	if state.Rebalances > state.MaxRebalances {
+		deviations = append(deviations, fmt.Sprintf("Consumer group %s rebalanced %d times, at most %d allowed.",
+			state.ConsumerGroupName, state.Rebalances, state.MaxRebalances))
+	}
+	if state.DisruptedSince != nil && now.Sub(*state.DisruptedSince) > state.MaxRecoveryTime {
+		deviations = append(deviations, fmt.Sprintf("Consumer group %s hasn't returned to Stable for %s, at most %s allowed.",
+			state.ConsumerGroupName, now.Sub(*state.DisruptedSince).Round(time.Second), state.MaxRecoveryTime))
+	} else if state.LongestRecovery > state.MaxRecoveryTime {
+		deviations = append(deviations, fmt.Sprintf("Consumer group %s took %s to return to Stable, at most %s allowed.",
+			state.ConsumerGroupName, state.LongestRecovery.Round(time.Second), state.MaxRecoveryTime))
+	}
+	return deviations
+}
+
+func describeRebalances(state *ConsumerGroupRebalanceCheckState, now time.Time) string {
+	summary := fmt.Sprintf("Consumer group %s rebalanced %d times, %d members joined, %d left and the assignment changed %d times.",
+		state.ConsumerGroupName, state.Rebalances, state.Joins, state.Leaves, state.AssignmentChanges)
+	if state.DisruptedSince != nil {
+		return fmt.Sprintf("%s It hasn't returned to Stable for %s.", summary, now.Sub(*state.DisruptedSince).Round(time.Millisecond))
+	}
+	if state.LongestRecovery > 0 {
+		return fmt.Sprintf("%s Longest time to recover: %s.", summary, state.LongestRecovery.Round(time.Millisecond))
+	}
+	return summary
+}
+
+// toRebalanceMetrics reports the time the group is out of Stable, or the time it took to recover on the observation
+// it returned to Stable, and the number of rebalances so far.
+func toRebalanceMetrics(state *ConsumerGroupRebalanceCheckState, recovered time.Duration, now time.Time) []action_kit_api.Metric {
+	timeToRecover := recovered
+	if state.DisruptedSince != nil {
+		timeToRecover = now.Sub(*state.DisruptedSince)
+	}
+	var groupState string
+	var members int
+	if state.Observed != nil {
+		groupState = state.Observed.State
+		members = len(state.Observed.Assignments)
+	}
+	return []action_kit_api.Metric{
+		{
+			Name: new("kafka_consumer_group_time_to_recover"),
+			Metric: map[string]string{
+				"id":    state.ConsumerGroupName,
+				"state": groupState,
+			},
+			Timestamp: now,
+			Value:     timeToRecover.Seconds(),
+		},
+		{
+			Name: new("kafka_consumer_group_rebalances"),
+			Metric: map[string]string{
+				"id":      state.ConsumerGroupName,
+				"members": fmt.Sprintf("%d", members),
+			},
+			Timestamp: now,
+			Value:     float64(state.Rebalances),
+		},
+	}
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckConsumerGroupRebalance_Describe(t *testing.T) {
+	desc := (&ConsumerGroupRebalanceCheckAction{}).Describe()
+
+	assert.Equal(t, "Check Consumer Group Rebalances", desc.Label)
+	assert.Equal(t, kafkaConsumerTargetId+".check-rebalance", desc.Id)
+	assert.Equal(t, kafkaConsumerTargetId, desc.TargetSelection.TargetType)
+	assert.Equal(t, "Kafka", *desc.Technology)
+}
+
+func TestCheckConsumerGroupRebalance_Prepare(t *testing.T) {
+	//Given
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {SeedBrokers: "localhost:9092"},
+	})
+	action := ConsumerGroupRebalanceCheckAction{}
+	state := action.NewEmptyState()
+	request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"kafka.consumer-group.name":     {"steadybit"},
+				"kafka.consumer-group.protocol": {consumerGroupProtocolConsumer},
+				"kafka.cluster.name":            {"test-cluster"},
+			},
+		},
+		Config:      map[string]any{"duration": 60000, "maxRebalances": 2, "maxRecoveryTime": 30000, "failEarly": false},
+		ExecutionId: uuid.New(),
+	})
+
+	//When
+	_, err := action.Prepare(t.Context(), &state, request)
+
+	//Then
+	require.NoError(t, err)
+	assert.Equal(t, "steadybit", state.ConsumerGroupName)
+	assert.Equal(t, consumerGroupProtocolConsumer, state.Protocol)
+	assert.Equal(t, 2, state.MaxRebalances)
+	assert.Equal(t, 30*time.Second, state.MaxRecoveryTime)
+	assert.False(t, state.FailEarly)
+	assert.Equal(t, []string{"localhost:9092"}, state.BrokerHosts)
+}
+
+func TestObserveMembership_ClassicGroup(t *testing.T) {
+	//Given
+	start := time.Now()
+	state := &ConsumerGroupRebalanceCheckState{ConsumerGroupName: "steadybit", MaxRebalances: 1, MaxRecoveryTime: 5 * time.Second}
+	stable := groupMembership{State: "Stable", Generation: -1, Assignments: map[string][]string{
+		"a": {"orders-0", "orders-1"},
+		"b": {"orders-2"},
+	}}
+	rebalancing := groupMembership{State: "PreparingRebalance", Generation: -1, Assignments: map[string][]string{
+		"a": {"orders-0", "orders-1"},
+		"b": {"orders-2"},
+	}}
+	recovered := groupMembership{State: "Stable", Generation: -1, Assignments: map[string][]string{
+		"a": {"orders-0"},
+		"c": {"orders-1", "orders-2"},
+	}}
+	reassigned := groupMembership{State: "Stable", Generation: -1, Assignments: map[string][]string{
+		"a": {"orders-0", "orders-1"},
+		"c": {"orders-2"},
+	}}
+
+	//When
+	assert.Zero(t, observeMembership(state, stable, start))
+	assert.Zero(t, observeMembership(state, rebalancing, start.Add(time.Second)))
+	timeToRecover := observeMembership(state, recovered, start.Add(4*time.Second))
+	observeMembership(state, reassigned, start.Add(5*time.Second))
+
+	//Then
+	assert.Equal(t, 3*time.Second, timeToRecover)
+	assert.Equal(t, 2, state.Rebalances)
+	assert.Equal(t, 1, state.Joins)
+	assert.Equal(t, 1, state.Leaves)
+	assert.Equal(t, 2, state.AssignmentChanges)
+	assert.Nil(t, state.DisruptedSince)
+	assert.Equal(t, []string{"Consumer group steadybit rebalanced 2 times, at most 1 allowed."}, evaluateRebalances(state, start.Add(5*time.Second)))
+	assert.Equal(t, "Consumer group steadybit rebalanced 2 times, 1 members joined, 1 left and the assignment changed 2 times. Longest time to recover: 3s.", describeRebalances(state, start.Add(5*time.Second)))
+}
+
+func TestObserveMembership_NextGenGroupCountsEpochChanges(t *testing.T) {
+	//Given
+	start := time.Now()
+	state := &ConsumerGroupRebalanceCheckState{ConsumerGroupName: "steadybit", MaxRebalances: 5, MaxRecoveryTime: 5 * time.Second}
+	assignments := map[string][]string{"a": {"orders-0"}}
+
+	//When
+	observeMembership(state, groupMembership{State: "Stable", Generation: 3, Assignments: assignments}, start)
+	observeMembership(state, groupMembership{State: "Stable", Generation: 4, Assignments: assignments}, start.Add(time.Second))
+	observeMembership(state, groupMembership{State: "Reconciling", Generation: 4, Assignments: assignments}, start.Add(2*time.Second))
+
+	//Then
+	assert.Equal(t, 1, state.Rebalances)
+	assert.Empty(t, evaluateRebalances(state, start.Add(6*time.Second)))
+	assert.Equal(t, []string{"Consumer group steadybit hasn't returned to Stable for 6s, at most 5s allowed."}, evaluateRebalances(state, start.Add(8*time.Second)))
+
+	metrics := toRebalanceMetrics(state, 0, start.Add(8*time.Second))
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "kafka_consumer_group_time_to_recover", *metrics[0].Name)
+	assert.Equal(t, 6.0, metrics[0].Value)
+	assert.Equal(t, "Reconciling", metrics[0].Metric["state"])
+	assert.Equal(t, 1.0, metrics[1].Value)
+	assert.Equal(t, "1", metrics[1].Metric["members"])
+}
+
+func TestEvaluateRebalances_SlowRecovery(t *testing.T) {
+	state := &ConsumerGroupRebalanceCheckState{ConsumerGroupName: "steadybit", MaxRebalances: 5, MaxRecoveryTime: 5 * time.Second, LongestRecovery: 12 * time.Second}
+
+	assert.Equal(t, []string{"Consumer group steadybit took 12s to return to Stable, at most 5s allowed."}, evaluateRebalances(state, time.Now()))
+}
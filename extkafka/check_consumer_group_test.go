@@ -28,7 +28,7 @@ func TestCheckConsumerGroup_Describe(t *testing.T) {
 	response := action.Describe()
 
 	//Then
-	assert.Equal(t, "Monitor consumer group state transitions (Stable, Dead, Rebalancing) during an experiment. For broker-level monitoring, use Check Brokers. For topic partition changes, use Check Partitions.", response.Description)
+	assert.Equal(t, "Monitor consumer group state transitions (Stable, Dead, Rebalancing) during an experiment. Supports classic and next-gen (KIP-848) consumer groups as well as share groups (KIP-932). For broker-level monitoring, use Check Brokers. For topic partition changes, use Check Partitions.", response.Description)
 	assert.Equal(t, "Check Consumer State", response.Label)
 	assert.Equal(t, kafkaConsumerTargetId, response.TargetSelection.TargetType)
 	assert.Equal(t, fmt.Sprintf("%s.check", kafkaConsumerTargetId), response.Id)
@@ -68,12 +68,38 @@ func TestCheckConsumerGroup_Prepare(t *testing.T) {
 
 			wantedState: &ConsumerGroupCheckState{
 				ConsumerGroupName: "steadybit",
+				Protocol:          consumerGroupProtocolClassic,
 				ExpectedState:     []string{"test"},
 				StateCheckMode:    "test",
 				StateCheckSuccess: true,
 				TopicName:         "steadybit",
 			},
 		},
+		{
+			name: "Should use the protocol of the group",
+			requestBody: extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+				Target: &action_kit_api.Target{
+					Attributes: map[string][]string{
+						"kafka.consumer-group.name":     {"steadybit"},
+						"kafka.consumer-group.protocol": {consumerGroupProtocolConsumer},
+						"kafka.cluster.name":            {"test-cluster"},
+					},
+				},
+				Config: map[string]any{
+					"expectedStateList": []string{"Reconciling"},
+					"stateCheckMode":    "test",
+					"duration":          10000,
+				},
+				ExecutionId: uuid.New(),
+			}),
+
+			wantedState: &ConsumerGroupCheckState{
+				ConsumerGroupName: "steadybit",
+				Protocol:          consumerGroupProtocolConsumer,
+				ExpectedState:     []string{"Reconciling"},
+				StateCheckMode:    "test",
+			},
+		},
 		{
 			name: "Should return error for consumer group name",
 			requestBody: extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
@@ -109,6 +135,7 @@ func TestCheckConsumerGroup_Prepare(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.wantedState.StateCheckMode, state.StateCheckMode)
 				assert.Equal(t, tt.wantedState.ConsumerGroupName, state.ConsumerGroupName)
+				assert.Equal(t, tt.wantedState.Protocol, state.Protocol)
 				assert.Equal(t, tt.wantedState.ExpectedState, state.ExpectedState)
 				assert.False(t, state.StateCheckSuccess)
 				assert.True(t, state.FailEarly) // defaults to true when not provided (non-breaking)
@@ -238,3 +265,23 @@ func TestCheckConsumerGroup_Status(t *testing.T) {
 		})
 	}
 }
+
+func TestToConsumerGroupMetric(t *testing.T) {
+	tests := []struct {
+		state    string
+		expected string
+	}{
+		{state: "Stable", expected: "success"},
+		{state: "Reconciling", expected: "warn"},
+		{state: "Assigning", expected: "warn"},
+		{state: "Dead", expected: "danger"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			metric := toConsumerGroupMetric(consumerGroupState{Group: "steadybit", State: tt.state}, time.Now())
+
+			assert.Equal(t, tt.expected, metric.Metric["state"])
+			assert.Equal(t, "steadybit", metric.Metric["kafka.consumer-group.name"])
+		})
+	}
+}
@@ -0,0 +1,477 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+const (
+	lagTrendDraining = "draining"
+	lagTrendStable   = "stable"
+	lagTrendGrowing  = "growing"
+)
+
+type ConsumerLagTrendCheckAction struct{}
+
+type ConsumerLagTrendCheckState struct {
+	ConsumerGroupName string
+	Topics            []string // all topics the group has committed offsets for, if empty
+	End               time.Time
+	GrowthWindow      time.Duration
+	MaxDrainTime      time.Duration // 0 if disabled
+	Tolerance         float64       // lag change in records/s still considered stable
+	FailEarly         bool
+	DeviationSeen     bool
+	DeviationTitle    string
+	// SampledAt and Committed are the committed offsets, keyed by "topic-partition", of the previous status call.
+	SampledAt time.Time
+	Committed map[string]int64
+	// Samples are the total lags within the growth window the lag rate is fitted to, oldest first.
+	Samples []lagSample
+	Trend   string
+	// GrowingSince is the first sample of the current growth, nil while the lag isn't growing.
+	GrowingSince *time.Time
+	BrokerHosts  []string
+	ClusterName  string
+}
+
+type lagSample struct {
+	SampledAt time.Time
+	Lag       int64
+}
+
+// lagTrend is the change of the lag fitted over the growth window.
+type lagTrend struct {
+	Lag             int64
+	ConsumptionRate float64       // committed records per second since the previous sample
+	LagRate         float64       // lag change per second fitted over the growth window, negative while draining
+	Trend           string        // one of the lagTrend constants
+	TimeToDrain     time.Duration // predicted time until the lag is 0, -1 if it isn't draining
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[ConsumerLagTrendCheckState]           = (*ConsumerLagTrendCheckAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[ConsumerLagTrendCheckState] = (*ConsumerLagTrendCheckAction)(nil)
+)
+
+func NewConsumerLagTrendCheckAction() action_kit_sdk.Action[ConsumerLagTrendCheckState] {
+	return &ConsumerLagTrendCheckAction{}
+}
+
+func (m *ConsumerLagTrendCheckAction) NewEmptyState() ConsumerLagTrendCheckState {
+	return ConsumerLagTrendCheckState{}
+}
+
+func (m *ConsumerLagTrendCheckAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.check-lag-trend", kafkaConsumerTargetId),
+		Label:       "Check Consumer Lag Trend",
+		Description: "Compute the consumption rate of the consumer group and how fast its lag changes over the growth window, classify the lag as draining, stable or growing and predict the time to drain it. Fail if the lag keeps growing for longer than a window or the predicted drain time exceeds an SLO. Use Check Topic Lag for absolute lag thresholds.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          kafkaConsumerTargetId,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionExactlyOne),
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "consumer group name",
+					Description: new("Find consumer group by cluster and name"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.consumer-group.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("How long the check runs. The lag is polled continuously for this duration."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("120s"),
+				Required:     new(true),
+			},
+			{
+				Name:        "topics",
+				Label:       "Topics",
+				Description: new("The topics to compute the trend for. Leave empty to use all topics the consumer group committed offsets for."),
+				Type:        action_kit_api.ActionParameterTypeStringArray,
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ParameterOptionsFromTargetAttribute{
+						Attribute: "kafka.consumer-group.topics",
+					},
+				}),
+				Required: new(false),
+			},
+			{
+				Name:         "growthWindow",
+				Label:        "Max Growth Duration",
+				Description:  new("How long the lag may keep growing. The lag rate is fitted over the lag samples within this window, so a single draining poll doesn't interrupt the growth."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "maxDrainTime",
+				Label:        "Max Time to Drain",
+				Description:  new("The maximum predicted time to drain the lag while it is draining. Set to 0 to not check the drain time."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("5m"),
+				Required:     new(true),
+			},
+			{
+				Name:         "tolerance",
+				Label:        "Stable Tolerance",
+				Description:  new("How many records per second the lag may change while still being considered stable."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1"),
+				Advanced:     new(true),
+				Required:     new(true),
+			},
+			{
+				Name:         "failEarly",
+				Label:        "Fail early",
+				Description:  new("If enabled, the check fails as soon as a threshold is violated. If disabled, the check keeps polling for the whole duration and only fails at the end of the step."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Advanced:     new(true),
+				Required:     new(false),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Consumer Group Lag Trend",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_consumer_group_lag_rate",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Grouping: new(action_kit_api.LineChartWidgetGroupingConfig{
+					ShowSummary: new(true),
+					Groups: []action_kit_api.LineChartWidgetGroup{
+						{
+							Title: "Draining or Stable",
+							Color: "success",
+							Matcher: action_kit_api.LineChartWidgetGroupMatcherFallback{
+								Type: action_kit_api.ComSteadybitWidgetLineChartGroupMatcherFallback,
+							},
+						},
+						{
+							Title: "Growing",
+							Color: "warn",
+							Matcher: action_kit_api.LineChartWidgetGroupMatcherKeyEqualsValue{
+								Type:  action_kit_api.ComSteadybitWidgetLineChartGroupMatcherKeyEqualsValue,
+								Key:   "trend",
+								Value: lagTrendGrowing,
+							},
+						},
+					},
+				}),
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Lag change/s"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "trend",
+							Title: "Trend",
+						},
+						{
+							From:  "time_to_drain",
+							Title: "Time to Drain",
+						},
+					},
+				}),
+			},
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Consumer Group Consumption Rate",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_consumer_group_consumption_rate",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Records/s"),
+				}),
+			},
+		}),
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("5s"),
+		}),
+	}
+}
+
+func (m *ConsumerLagTrendCheckAction) Prepare(_ context.Context, state *ConsumerLagTrendCheckState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	if len(request.Target.Attributes["kafka.consumer-group.name"]) == 0 {
+		return nil, fmt.Errorf("the target is missing the kafka.consumer-group.name attribute")
+	}
+	duration := request.Config["duration"].(float64)
+
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	state.ConsumerGroupName = request.Target.Attributes["kafka.consumer-group.name"][0]
+	if request.Config["topics"] != nil {
+		state.Topics = extutil.ToStringArray(request.Config["topics"])
+	}
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+	state.End = time.Now().Add(time.Millisecond * time.Duration(duration))
+	state.GrowthWindow = time.Duration(extutil.ToInt64(request.Config["growthWindow"])) * time.Millisecond
+	state.MaxDrainTime = time.Duration(extutil.ToInt64(request.Config["maxDrainTime"])) * time.Millisecond
+	state.Tolerance = float64(extutil.ToInt64(request.Config["tolerance"]))
+	state.FailEarly = true
+	if request.Config["failEarly"] != nil {
+		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
+	}
+
+	return nil, nil
+}
+
+func (m *ConsumerLagTrendCheckAction) Start(ctx context.Context, state *ConsumerLagTrendCheckState) (*action_kit_api.StartResult, error) {
+	statusResult, err := ConsumerLagTrendCheckStatus(ctx, state)
+	if statusResult == nil {
+		return nil, err
+	}
+	return &action_kit_api.StartResult{
+		Error:    statusResult.Error,
+		Messages: statusResult.Messages,
+		Metrics:  statusResult.Metrics,
+	}, err
+}
+
+func (m *ConsumerLagTrendCheckAction) Status(ctx context.Context, state *ConsumerLagTrendCheckState) (*action_kit_api.StatusResult, error) {
+	return ConsumerLagTrendCheckStatus(ctx, state)
+}
+
+func ConsumerLagTrendCheckStatus(ctx context.Context, state *ConsumerLagTrendCheckState) (*action_kit_api.StatusResult, error) {
+	now := time.Now()
+
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	groupLag, err := describeGroupLag(ctx, client, state.ConsumerGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	previousTrend := state.Trend
+	trend := sampleLagTrend(state, groupLag, now)
+
+	completed := now.After(state.End)
+	var checkError *action_kit_api.ActionKitError
+	for _, deviation := range evaluateLagTrend(state, trend, now) {
+		if state.FailEarly {
+			checkError = &action_kit_api.ActionKitError{
+				Title:  deviation,
+				Status: extutil.Ptr(action_kit_api.Failed),
+			}
+			break
+		}
+		state.DeviationSeen = true
+		if state.DeviationTitle == "" {
+			state.DeviationTitle = deviation
+		}
+	}
+	if !state.FailEarly && completed && state.DeviationSeen {
+		checkError = &action_kit_api.ActionKitError{
+			Title:  state.DeviationTitle,
+			Status: extutil.Ptr(action_kit_api.Failed),
+		}
+	}
+
+	var messages []action_kit_api.Message
+	var metrics []action_kit_api.Metric
+	if trend != nil {
+		if trend.Trend != previousTrend {
+			messages = append(messages, action_kit_api.Message{
+				Level:   extutil.Ptr(action_kit_api.Info),
+				Message: describeLagTrend(state.ConsumerGroupName, *trend),
+			})
+		}
+		metrics = toLagTrendMetrics(state.ConsumerGroupName, *trend, now)
+	}
+
+	return &action_kit_api.StatusResult{
+		Completed: completed,
+		Error:     checkError,
+		Messages:  new(messages),
+		Metrics:   new(metrics),
+	}, nil
+}
+
+// sampleLagTrend remembers the lag and committed offsets and returns the trend fitted over the samples within the growth
+// window, nil for the first sample. Only partitions with a commit in both samples count towards the consumption rate,
+// so partitions committed for the first time or reset backwards don't distort it.
+func sampleLagTrend(state *ConsumerLagTrendCheckState, groupLag kadm.GroupLag, now time.Time) *lagTrend {
+	var lag int64
+	var consumed int64
+	committed := map[string]int64{}
+	for _, memberLag := range groupLag.Sorted() {
+		if len(state.Topics) > 0 && !slices.Contains(state.Topics, memberLag.Topic) {
+			continue
+		}
+		if memberLag.Lag > 0 {
+			lag += memberLag.Lag
+		}
+		if memberLag.Commit.At < 0 {
+			continue
+		}
+		key := fmt.Sprintf("%s-%d", memberLag.Topic, memberLag.Partition)
+		committed[key] = memberLag.Commit.At
+		if previous, ok := state.Committed[key]; ok {
+			consumed += max(memberLag.Commit.At-previous, 0)
+		}
+	}
+
+	// Keep the samples within the growth window, but at least two to fit the lag rate to
+	state.Samples = append(state.Samples, lagSample{SampledAt: now, Lag: lag})
+	first := 0
+	for first < len(state.Samples)-2 && now.Sub(state.Samples[first].SampledAt) > state.GrowthWindow {
+		first++
+	}
+	state.Samples = state.Samples[first:]
+
+	var trend *lagTrend
+	elapsed := now.Sub(state.SampledAt).Seconds()
+	if !state.SampledAt.IsZero() && elapsed > 0 {
+		trend = &lagTrend{
+			Lag:             lag,
+			ConsumptionRate: float64(consumed) / elapsed,
+			LagRate:         fitLagRate(state.Samples),
+			TimeToDrain:     -1,
+		}
+		switch {
+		case trend.LagRate > state.Tolerance:
+			trend.Trend = lagTrendGrowing
+		case trend.LagRate < -state.Tolerance:
+			trend.Trend = lagTrendDraining
+			trend.TimeToDrain = time.Duration(float64(lag) / -trend.LagRate * float64(time.Second))
+		default:
+			trend.Trend = lagTrendStable
+		}
+		if lag == 0 {
+			trend.TimeToDrain = 0
+		}
+
+		state.Trend = trend.Trend
+		if trend.Trend != lagTrendGrowing {
+			state.GrowingSince = nil
+		} else if state.GrowingSince == nil {
+			state.GrowingSince = new(state.SampledAt)
+		}
+	}
+
+	state.SampledAt = now
+	state.Committed = committed
+	return trend
+}
+
+// fitLagRate returns the slope of the least squares regression line through the samples in records per second.
+func fitLagRate(samples []lagSample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	var meanTime, meanLag float64
+	for _, sample := range samples {
+		meanTime += sample.SampledAt.Sub(samples[0].SampledAt).Seconds()
+		meanLag += float64(sample.Lag)
+	}
+	meanTime /= float64(len(samples))
+	meanLag /= float64(len(samples))
+
+	var covariance, variance float64
+	for _, sample := range samples {
+		dt := sample.SampledAt.Sub(samples[0].SampledAt).Seconds() - meanTime
+		covariance += dt * (float64(sample.Lag) - meanLag)
+		variance += dt * dt
+	}
+	if variance == 0 {
+		return 0
+	}
+	return covariance / variance
+}
+
+// evaluateLagTrend fails if the lag keeps growing for longer than the window, or the predicted drain time exceeds the
+// SLO. A stable lag is expected to be the steady state of the group and isn't predicted to drain.
+func evaluateLagTrend(state *ConsumerLagTrendCheckState, trend *lagTrend, now time.Time) []string {
+	var deviations []string
+	if trend == nil {
+		return deviations
+	}
+	if state.GrowingSince != nil && now.Sub(*state.GrowingSince) > state.GrowthWindow {
+		deviations = append(deviations, fmt.Sprintf("Lag of consumer group %s has been growing for %s, currently by %.1f records/s to %d records, at most %s allowed.",
+			state.ConsumerGroupName, now.Sub(*state.GrowingSince).Round(time.Second), trend.LagRate, trend.Lag, state.GrowthWindow))
+	}
+	if state.MaxDrainTime > 0 && trend.Trend == lagTrendDraining && trend.TimeToDrain > state.MaxDrainTime {
+		deviations = append(deviations, fmt.Sprintf("Lag of %d records of consumer group %s is predicted to drain in %s, at most %s allowed.",
+			trend.Lag, state.ConsumerGroupName, trend.TimeToDrain.Round(time.Second), state.MaxDrainTime))
+	}
+	return deviations
+}
+
+func describeLagTrend(group string, trend lagTrend) string {
+	switch trend.Trend {
+	case lagTrendDraining:
+		return fmt.Sprintf("Lag of consumer group %s is draining by %.1f records/s, %d records are predicted to drain in %s.", group, -trend.LagRate, trend.Lag, trend.TimeToDrain.Round(time.Second))
+	case lagTrendGrowing:
+		return fmt.Sprintf("Lag of consumer group %s is growing by %.1f records/s to %d records while consuming %.1f records/s.", group, trend.LagRate, trend.Lag, trend.ConsumptionRate)
+	default:
+		return fmt.Sprintf("Lag of consumer group %s is stable at %d records while consuming %.1f records/s.", group, trend.Lag, trend.ConsumptionRate)
+	}
+}
+
+func toLagTrendMetrics(group string, trend lagTrend, now time.Time) []action_kit_api.Metric {
+	timeToDrain := "-"
+	if trend.TimeToDrain >= 0 {
+		timeToDrain = trend.TimeToDrain.Round(time.Second).String()
+	}
+	return []action_kit_api.Metric{
+		{
+			Name: new("kafka_consumer_group_lag_rate"),
+			Metric: map[string]string{
+				"id":            group,
+				"trend":         trend.Trend,
+				"time_to_drain": timeToDrain,
+			},
+			Timestamp: now,
+			Value:     math.Round(trend.LagRate*100) / 100,
+		},
+		{
+			Name: new("kafka_consumer_group_consumption_rate"),
+			Metric: map[string]string{
+				"id": group,
+			},
+			Timestamp: now,
+			Value:     math.Round(trend.ConsumptionRate*100) / 100,
+		},
+	}
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestCheckConsumerLagTrend_Describe(t *testing.T) {
+	desc := (&ConsumerLagTrendCheckAction{}).Describe()
+
+	assert.Equal(t, "Check Consumer Lag Trend", desc.Label)
+	assert.Equal(t, kafkaConsumerTargetId+".check-lag-trend", desc.Id)
+	assert.Equal(t, kafkaConsumerTargetId, desc.TargetSelection.TargetType)
+	assert.Equal(t, "Kafka", *desc.Technology)
+}
+
+func memberLagOf(topic string, partition int32, committed int64, lag int64) kadm.GroupMemberLag {
+	return kadm.GroupMemberLag{Topic: topic, Partition: partition, Commit: kadm.Offset{At: committed}, Lag: lag}
+}
+
+func TestSampleLagTrend(t *testing.T) {
+	//Given
+	start := time.Now()
+	state := &ConsumerLagTrendCheckState{ConsumerGroupName: "steadybit", GrowthWindow: 10 * time.Second, MaxDrainTime: time.Minute, Tolerance: 1}
+
+	//When
+	first := sampleLagTrend(state, kadm.GroupLag{"orders": {
+		0: memberLagOf("orders", 0, 100, 500),
+		1: memberLagOf("orders", 1, 200, 500),
+	}}, start)
+	growing := sampleLagTrend(state, kadm.GroupLag{"orders": {
+		0: memberLagOf("orders", 0, 150, 600),
+		1: memberLagOf("orders", 1, 250, 600),
+	}}, start.Add(5*time.Second))
+	dip := sampleLagTrend(state, kadm.GroupLag{"orders": {
+		0: memberLagOf("orders", 0, 250, 550),
+		1: memberLagOf("orders", 1, 350, 550),
+	}}, start.Add(10*time.Second))
+	growingSince := state.GrowingSince
+	draining := sampleLagTrend(state, kadm.GroupLag{"orders": {
+		0: memberLagOf("orders", 0, 350, 450),
+		1: memberLagOf("orders", 1, 450, 450),
+	}}, start.Add(20*time.Second))
+
+	//Then
+	assert.Nil(t, first)
+	require.NotNil(t, growing)
+	assert.Equal(t, lagTrendGrowing, growing.Trend)
+	assert.Equal(t, 20.0, growing.ConsumptionRate)
+	assert.Equal(t, 40.0, growing.LagRate)
+	assert.Equal(t, time.Duration(-1), growing.TimeToDrain)
+
+	// A single drop of the lag doesn't interrupt the growth fitted over the window
+	require.NotNil(t, dip)
+	assert.Equal(t, lagTrendGrowing, dip.Trend)
+	assert.Equal(t, 40.0, dip.ConsumptionRate)
+	assert.Equal(t, 10.0, dip.LagRate)
+	assert.Equal(t, new(start), growingSince)
+
+	require.NotNil(t, draining)
+	assert.Equal(t, lagTrendDraining, draining.Trend)
+	assert.Equal(t, 20.0, draining.ConsumptionRate)
+	assert.Equal(t, -20.0, draining.LagRate)
+	assert.Equal(t, 45*time.Second, draining.TimeToDrain)
+	assert.Len(t, state.Samples, 2)
+	assert.Nil(t, state.GrowingSince)
+	assert.Empty(t, evaluateLagTrend(state, draining, start.Add(20*time.Second)))
+	assert.Equal(t, "Lag of consumer group steadybit is draining by 20.0 records/s, 900 records are predicted to drain in 45s.", describeLagTrend("steadybit", *draining))
+}
+
+func TestFitLagRate(t *testing.T) {
+	start := time.Now()
+
+	assert.Equal(t, 0.0, fitLagRate([]lagSample{{SampledAt: start, Lag: 100}}))
+	assert.Equal(t, 0.0, fitLagRate([]lagSample{{SampledAt: start, Lag: 100}, {SampledAt: start, Lag: 200}}))
+	assert.InDelta(t, 6.0, fitLagRate([]lagSample{
+		{SampledAt: start, Lag: 100},
+		{SampledAt: start.Add(1 * time.Second), Lag: 130},
+		{SampledAt: start.Add(2 * time.Second), Lag: 100},
+		{SampledAt: start.Add(3 * time.Second), Lag: 130},
+	}), 0.001)
+}
+
+func TestEvaluateLagTrend(t *testing.T) {
+	//Given
+	start := time.Now()
+	state := &ConsumerLagTrendCheckState{ConsumerGroupName: "steadybit", GrowthWindow: 10 * time.Second, MaxDrainTime: 30 * time.Second, Tolerance: 1, GrowingSince: new(start)}
+	growing := &lagTrend{Lag: 2000, LagRate: 12.5, Trend: lagTrendGrowing, TimeToDrain: -1}
+	draining := &lagTrend{Lag: 2000, LagRate: -10, Trend: lagTrendDraining, TimeToDrain: 200 * time.Second}
+
+	//When
+	growingWithinWindow := evaluateLagTrend(state, growing, start.Add(5*time.Second))
+	growingTooLong := evaluateLagTrend(state, growing, start.Add(15*time.Second))
+	state.GrowingSince = nil
+	drainingTooSlow := evaluateLagTrend(state, draining, start)
+
+	//Then
+	assert.Empty(t, growingWithinWindow)
+	assert.Equal(t, []string{"Lag of consumer group steadybit has been growing for 15s, currently by 12.5 records/s to 2000 records, at most 10s allowed."}, growingTooLong)
+	assert.Equal(t, []string{"Lag of 2000 records of consumer group steadybit is predicted to drain in 3m20s, at most 30s allowed."}, drainingTooSlow)
+}
+
+func TestToLagTrendMetrics(t *testing.T) {
+	metrics := toLagTrendMetrics("steadybit", lagTrend{Lag: 10, ConsumptionRate: 3.333333, LagRate: 0.5, Trend: lagTrendStable, TimeToDrain: -1}, time.Now())
+
+	require.Len(t, metrics, 2)
+	assert.Equal(t, map[string]string{"id": "steadybit", "trend": lagTrendStable, "time_to_drain": "-"}, metrics[0].Metric)
+	assert.Equal(t, 0.5, metrics[0].Value)
+	assert.Equal(t, 3.33, metrics[1].Value)
+}
@@ -0,0 +1,323 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+const (
+	offsetAnomalyRewind = "rewind"
+	offsetAnomalySkip   = "skip"
+)
+
+type OffsetContinuityCheckAction struct{}
+
+type OffsetContinuityCheckState struct {
+	ConsumerGroupName string
+	Topics            []string // empty to check all topics the group committed offsets for
+	End               time.Time
+	FailEarly         bool
+	DeviationSeen     bool
+	DeviationTitle    string
+	// Committed is the last committed offset seen per partition, keyed by "topic-partition".
+	Committed   map[string]int64
+	Anomalies   []offsetAnomaly
+	BrokerHosts []string
+	ClusterName string
+}
+
+// offsetAnomaly is a committed offset that moved backwards (rewind) or beyond the log end offset (skip).
+type offsetAnomaly struct {
+	Kind      string
+	Topic     string
+	Partition int32
+	From      int64 // the previously committed offset, -1 if there was none
+	To        int64 // the newly committed offset
+	LogEnd    int64 // the log end offset at the time the anomaly was seen
+	Delta     int64 // the records reprocessed (rewind, negative) or skipped (skip, positive)
+	Time      time.Time
+}
+
+func (a offsetAnomaly) String() string {
+	if a.Kind == offsetAnomalyRewind {
+		return fmt.Sprintf("Committed offset of %s-%d moved backwards from %d to %d, %d records are reprocessed.", a.Topic, a.Partition, a.From, a.To, -a.Delta)
+	}
+	return fmt.Sprintf("Committed offset of %s-%d moved to %d beyond the log end offset %d, %d records are skipped.", a.Topic, a.Partition, a.To, a.LogEnd, a.Delta)
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[OffsetContinuityCheckState]           = (*OffsetContinuityCheckAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[OffsetContinuityCheckState] = (*OffsetContinuityCheckAction)(nil)
+)
+
+func NewOffsetContinuityCheckAction() action_kit_sdk.Action[OffsetContinuityCheckState] {
+	return &OffsetContinuityCheckAction{}
+}
+
+func (m *OffsetContinuityCheckAction) NewEmptyState() OffsetContinuityCheckState {
+	return OffsetContinuityCheckState{}
+}
+
+func (m *OffsetContinuityCheckAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.check-offset-continuity", kafkaConsumerTargetId),
+		Label:       "Check Offset Continuity",
+		Description: "Snapshot the committed offsets of the consumer group per partition and fail if a committed offset moves backwards (records are reprocessed) or beyond the log end offset (records are skipped), e.g. during a broker failover. Unlike the lag check, which only sees totals, the offending partitions and offset deltas are reported as messages and as artifact.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          kafkaConsumerTargetId,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionExactlyOne),
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "consumer group name",
+					Description: new("Find consumer group by cluster and name"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.consumer-group.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("How long the check runs. The committed offsets are polled continuously for this duration."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("60s"),
+				Required:     new(true),
+			},
+			{
+				Name:        "topics",
+				Label:       "Topics",
+				Description: new("The topics to check. Leave empty to check all topics the consumer group committed offsets for."),
+				Type:        action_kit_api.ActionParameterTypeStringArray,
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ParameterOptionsFromTargetAttribute{
+						Attribute: "kafka.consumer-group.topics",
+					},
+				}),
+				Required: new(false),
+			},
+			{
+				Name:         "failEarly",
+				Label:        "Fail early",
+				Description:  new("If enabled, the check fails as soon as a committed offset rewinds or skips. If disabled, the check keeps polling for the whole duration and only fails at the end of the step."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Advanced:     new(true),
+				Required:     new(false),
+			},
+		},
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("1s"),
+		}),
+	}
+}
+
+func (m *OffsetContinuityCheckAction) Prepare(_ context.Context, state *OffsetContinuityCheckState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	if len(request.Target.Attributes["kafka.consumer-group.name"]) == 0 {
+		return nil, fmt.Errorf("the target is missing the kafka.consumer-group.name attribute")
+	}
+	duration := request.Config["duration"].(float64)
+
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	state.ConsumerGroupName = request.Target.Attributes["kafka.consumer-group.name"][0]
+	if request.Config["topics"] != nil {
+		state.Topics = extutil.ToStringArray(request.Config["topics"])
+	}
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+	state.End = time.Now().Add(time.Millisecond * time.Duration(duration))
+	state.FailEarly = true
+	if request.Config["failEarly"] != nil {
+		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
+	}
+
+	return nil, nil
+}
+
+func (m *OffsetContinuityCheckAction) Start(ctx context.Context, state *OffsetContinuityCheckState) (*action_kit_api.StartResult, error) {
+	statusResult, err := OffsetContinuityCheckStatus(ctx, state)
+	if statusResult == nil {
+		return nil, err
+	}
+	return &action_kit_api.StartResult{
+		Artifacts: statusResult.Artifacts,
+		Error:     statusResult.Error,
+		Messages:  statusResult.Messages,
+	}, err
+}
+
+func (m *OffsetContinuityCheckAction) Status(ctx context.Context, state *OffsetContinuityCheckState) (*action_kit_api.StatusResult, error) {
+	return OffsetContinuityCheckStatus(ctx, state)
+}
+
+func OffsetContinuityCheckStatus(ctx context.Context, state *OffsetContinuityCheckState) (*action_kit_api.StatusResult, error) {
+	now := time.Now()
+
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	groupLag, err := describeGroupLag(ctx, client, state.ConsumerGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies := detectOffsetAnomalies(state, groupLag, now)
+	state.Anomalies = append(state.Anomalies, anomalies...)
+
+	completed := now.After(state.End)
+	var checkError *action_kit_api.ActionKitError
+	if len(anomalies) > 0 {
+		if state.FailEarly {
+			checkError = &action_kit_api.ActionKitError{
+				Title:  anomalies[0].String(),
+				Status: extutil.Ptr(action_kit_api.Failed),
+			}
+		} else {
+			state.DeviationSeen = true
+			if state.DeviationTitle == "" {
+				state.DeviationTitle = anomalies[0].String()
+			}
+		}
+	}
+	if !state.FailEarly && completed && state.DeviationSeen {
+		checkError = &action_kit_api.ActionKitError{
+			Title:  state.DeviationTitle,
+			Status: extutil.Ptr(action_kit_api.Failed),
+		}
+	}
+
+	messages := make([]action_kit_api.Message, 0, len(anomalies))
+	for _, anomaly := range anomalies {
+		messages = append(messages, action_kit_api.Message{
+			Level:     extutil.Ptr(action_kit_api.Warn),
+			Message:   anomaly.String(),
+			Timestamp: new(anomaly.Time),
+		})
+	}
+
+	var artifacts *[]action_kit_api.Artifact
+	if (completed || checkError != nil) && len(state.Anomalies) > 0 {
+		artifact, err := toOffsetAnomaliesArtifact(state.ConsumerGroupName, state.Anomalies)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = new([]action_kit_api.Artifact{artifact})
+	}
+
+	return &action_kit_api.StatusResult{
+		Completed: completed,
+		Error:     checkError,
+		Messages:  new(messages),
+		Artifacts: artifacts,
+	}, nil
+}
+
+// detectOffsetAnomalies compares the committed offsets to the ones seen by the previous call and remembers them. A
+// skip is only reported when the committed offset changes, so a partition stuck beyond the log end is reported once.
+func detectOffsetAnomalies(state *OffsetContinuityCheckState, groupLag kadm.GroupLag, now time.Time) []offsetAnomaly {
+	if state.Committed == nil {
+		state.Committed = map[string]int64{}
+	}
+	var anomalies []offsetAnomaly
+	for _, lag := range groupLag.Sorted() {
+		if len(state.Topics) > 0 && !slices.Contains(state.Topics, lag.Topic) {
+			continue
+		}
+		if lag.Commit.At < 0 {
+			continue
+		}
+		key := fmt.Sprintf("%s-%d", lag.Topic, lag.Partition)
+		previous, seen := state.Committed[key]
+		state.Committed[key] = lag.Commit.At
+		if !seen {
+			previous = -1
+		}
+
+		if seen && lag.Commit.At < previous {
+			anomalies = append(anomalies, offsetAnomaly{
+				Kind:      offsetAnomalyRewind,
+				Topic:     lag.Topic,
+				Partition: lag.Partition,
+				From:      previous,
+				To:        lag.Commit.At,
+				LogEnd:    lag.End.Offset,
+				Delta:     lag.Commit.At - previous,
+				Time:      now,
+			})
+		} else if lag.End.Err == nil && lag.Commit.At > lag.End.Offset && lag.Commit.At != previous {
+			anomalies = append(anomalies, offsetAnomaly{
+				Kind:      offsetAnomalySkip,
+				Topic:     lag.Topic,
+				Partition: lag.Partition,
+				From:      previous,
+				To:        lag.Commit.At,
+				LogEnd:    lag.End.Offset,
+				Delta:     lag.Commit.At - lag.End.Offset,
+				Time:      now,
+			})
+		}
+	}
+	return anomalies
+}
+
+func toOffsetAnomaliesArtifact(group string, anomalies []offsetAnomaly) (action_kit_api.Artifact, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	rows := [][]string{{"consumer_group", "topic", "partition", "kind", "from_offset", "to_offset", "log_end_offset", "delta", "time"}}
+	for _, anomaly := range anomalies {
+		rows = append(rows, []string{
+			group,
+			anomaly.Topic,
+			strconv.Itoa(int(anomaly.Partition)),
+			anomaly.Kind,
+			strconv.FormatInt(anomaly.From, 10),
+			strconv.FormatInt(anomaly.To, 10),
+			strconv.FormatInt(anomaly.LogEnd, 10),
+			strconv.FormatInt(anomaly.Delta, 10),
+			anomaly.Time.Format(time.RFC3339),
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return action_kit_api.Artifact{}, fmt.Errorf("failed to write offset anomalies: %w", err)
+	}
+	return action_kit_api.Artifact{
+		Label: "offset-anomalies.csv",
+		Data:  base64.StdEncoding.EncodeToString(buffer.Bytes()),
+	}, nil
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestCheckOffsetContinuity_Describe(t *testing.T) {
+	desc := (&OffsetContinuityCheckAction{}).Describe()
+
+	assert.Equal(t, "Check Offset Continuity", desc.Label)
+	assert.Equal(t, kafkaConsumerTargetId+".check-offset-continuity", desc.Id)
+	assert.Equal(t, kafkaConsumerTargetId, desc.TargetSelection.TargetType)
+	assert.Equal(t, "Kafka", *desc.Technology)
+}
+
+func committedLag(topic string, partition int32, committed int64, end int64) kadm.GroupMemberLag {
+	return kadm.GroupMemberLag{
+		Topic:     topic,
+		Partition: partition,
+		Commit:    kadm.Offset{At: committed},
+		End:       kadm.ListedOffset{Topic: topic, Partition: partition, Offset: end},
+	}
+}
+
+func TestDetectOffsetAnomalies(t *testing.T) {
+	//Given
+	now := time.Now()
+	state := &OffsetContinuityCheckState{ConsumerGroupName: "steadybit"}
+	initial := kadm.GroupLag{
+		"orders": {
+			0: committedLag("orders", 0, 100, 120),
+			1: committedLag("orders", 1, 50, 50),
+			2: committedLag("orders", 2, -1, 10),
+		},
+	}
+	failover := kadm.GroupLag{
+		"orders": {
+			0: committedLag("orders", 0, 80, 130),
+			1: committedLag("orders", 1, 60, 55),
+			2: committedLag("orders", 2, 5, 10),
+		},
+	}
+
+	//When
+	assert.Empty(t, detectOffsetAnomalies(state, initial, now))
+	anomalies := detectOffsetAnomalies(state, failover, now)
+	repeated := detectOffsetAnomalies(state, failover, now)
+
+	//Then
+	require.Len(t, anomalies, 2)
+	assert.Equal(t, offsetAnomaly{Kind: offsetAnomalyRewind, Topic: "orders", Partition: 0, From: 100, To: 80, LogEnd: 130, Delta: -20, Time: now}, anomalies[0])
+	assert.Equal(t, "Committed offset of orders-0 moved backwards from 100 to 80, 20 records are reprocessed.", anomalies[0].String())
+	assert.Equal(t, offsetAnomaly{Kind: offsetAnomalySkip, Topic: "orders", Partition: 1, From: 50, To: 60, LogEnd: 55, Delta: 5, Time: now}, anomalies[1])
+	assert.Equal(t, "Committed offset of orders-1 moved to 60 beyond the log end offset 55, 5 records are skipped.", anomalies[1].String())
+	assert.Empty(t, repeated)
+}
+
+func TestDetectOffsetAnomalies_OnlySelectedTopics(t *testing.T) {
+	state := &OffsetContinuityCheckState{Topics: []string{"payments"}, Committed: map[string]int64{"orders-0": 100}}
+
+	anomalies := detectOffsetAnomalies(state, kadm.GroupLag{"orders": {0: committedLag("orders", 0, 10, 130)}}, time.Now())
+
+	assert.Empty(t, anomalies)
+	assert.Equal(t, map[string]int64{"orders-0": 100}, state.Committed)
+}
+
+func TestToOffsetAnomaliesArtifact(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	artifact, err := toOffsetAnomaliesArtifact("steadybit", []offsetAnomaly{
+		{Kind: offsetAnomalyRewind, Topic: "orders", Partition: 0, From: 100, To: 80, LogEnd: 130, Delta: -20, Time: now},
+	})
+	require.NoError(t, err)
+
+	data, err := base64.StdEncoding.DecodeString(artifact.Data)
+	require.NoError(t, err)
+	assert.Equal(t, "offset-anomalies.csv", artifact.Label)
+	assert.Equal(t, "consumer_group,topic,partition,kind,from_offset,to_offset,log_end_offset,delta,time\nsteadybit,orders,0,rewind,100,80,130,-20,2025-03-01T12:00:00Z\n", string(data))
+}
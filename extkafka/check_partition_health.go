@@ -0,0 +1,353 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+type PartitionHealthCheckAction struct{}
+
+type PartitionHealthCheckState struct {
+	Topics            []string
+	MinInSyncReplicas map[string]int
+	// Thresholds contains the maximum number of partitions allowed per partitionHealth kind
+	Thresholds     map[string]int
+	GracePeriod    time.Duration
+	ViolatingSince map[string]time.Time
+	End            time.Time
+	FailEarly      bool
+	DeviationSeen  bool
+	DeviationTitle string
+	BrokerHosts    []string
+	ClusterName    string
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[PartitionHealthCheckState]           = (*PartitionHealthCheckAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[PartitionHealthCheckState] = (*PartitionHealthCheckAction)(nil)
+)
+
+func NewPartitionHealthCheckAction() action_kit_sdk.Action[PartitionHealthCheckState] {
+	return &PartitionHealthCheckAction{}
+}
+
+func (m *PartitionHealthCheckAction) NewEmptyState() PartitionHealthCheckState {
+	return PartitionHealthCheckState{}
+}
+
+func (m *PartitionHealthCheckAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.check-partition-health", kafkaClusterTargetId),
+		Label:       "Check Partition Health",
+		Description: "Continuously count the under-replicated, under min ISR and offline partitions of the whole cluster or selected topics. Fails if a count stays above its threshold for longer than the grace period, which makes it usable as an experiment guardrail. For individual partition changes, use Check Partitions instead.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          kafkaClusterTargetId,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionExactlyOne),
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "by cluster name",
+					Description: new("Find cluster by name"),
+					Query:       "kafka.cluster.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("How long the check runs. The partition health is polled continuously for this duration."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+			},
+			{
+				Name:        "topics",
+				Label:       "Topics",
+				Description: new("Optional. The topics to check. If left empty, all topics of the cluster, including internal ones, are checked."),
+				Type:        action_kit_api.ActionParameterTypeStringArray,
+				Required:    new(false),
+			},
+			{
+				Name:         "maxUnderReplicated",
+				Label:        "Max Under-Replicated Partitions",
+				Description:  new("The number of partitions allowed to have fewer in-sync replicas than replicas."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("0"),
+				Required:     new(true),
+			},
+			{
+				Name:         "maxUnderMinIsr",
+				Label:        "Max Under Min ISR Partitions",
+				Description:  new("The number of partitions allowed to have fewer in-sync replicas than min.insync.replicas of their topic. Producers using acks=all can't write to these partitions."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("0"),
+				Required:     new(true),
+			},
+			{
+				Name:         "maxOffline",
+				Label:        "Max Offline Partitions",
+				Description:  new("The number of partitions allowed to have no leader."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("0"),
+				Required:     new(true),
+			},
+			{
+				Name:         "gracePeriod",
+				Label:        "Grace Period",
+				Description:  new("How long a count may stay above its threshold before the check fails, e.g. to tolerate a leader election."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "failEarly",
+				Label:        "Fail early",
+				Description:  new("If enabled, the check fails as soon as a threshold is violated for longer than the grace period. If disabled, the check keeps polling for the whole duration and only fails at the end of the step."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Advanced:     new(true),
+				Required:     new(false),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Unhealthy Partitions",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_partition_health",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Partitions"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "partitions",
+							Title: "Partitions",
+						},
+					},
+				}),
+			},
+		}),
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("2s"),
+		}),
+	}
+}
+
+func (m *PartitionHealthCheckAction) Prepare(ctx context.Context, state *PartitionHealthCheckState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	duration := request.Config["duration"].(float64)
+
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+	state.End = time.Now().Add(time.Millisecond * time.Duration(duration))
+	state.GracePeriod = time.Duration(extutil.ToInt64(request.Config["gracePeriod"])) * time.Millisecond
+	state.Thresholds = map[string]int{
+		partitionHealthUnderReplicated: extutil.ToInt(request.Config["maxUnderReplicated"]),
+		partitionHealthUnderMinIsr:     extutil.ToInt(request.Config["maxUnderMinIsr"]),
+		partitionHealthOffline:         extutil.ToInt(request.Config["maxOffline"]),
+	}
+	for kind, threshold := range state.Thresholds {
+		if threshold < 0 {
+			return nil, fmt.Errorf("the max number of %s partitions must not be negative", kind)
+		}
+	}
+	state.ViolatingSince = make(map[string]time.Time)
+	state.FailEarly = true
+	if request.Config["failEarly"] != nil {
+		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
+	}
+	if request.Config["topics"] != nil {
+		for _, topic := range extutil.ToStringArray(request.Config["topics"]) {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				state.Topics = append(state.Topics, topic)
+			}
+		}
+	}
+
+	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	metadata, err := client.Metadata(ctx, state.Topics...)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve topics from Kafka. Full response: %v", err), err))
+	}
+	for _, topic := range state.Topics {
+		if detail, ok := metadata.Topics[topic]; !ok || detail.Err != nil {
+			return nil, fmt.Errorf("topic %s not found in cluster %s", topic, clusterName)
+		}
+	}
+
+	state.MinInSyncReplicas, err = describeMinInSyncReplicas(ctx, client, metadata.Topics.Names()...)
+	if err != nil {
+		return nil, new(extension_kit.ToError("Failed to describe min.insync.replicas of the topics.", err))
+	}
+
+	return nil, nil
+}
+
+func (m *PartitionHealthCheckAction) Start(ctx context.Context, state *PartitionHealthCheckState) (*action_kit_api.StartResult, error) {
+	statusResult, err := PartitionHealthCheckStatus(ctx, state)
+	if statusResult == nil {
+		return nil, err
+	}
+	return &action_kit_api.StartResult{
+		Error:    statusResult.Error,
+		Messages: statusResult.Messages,
+		Metrics:  statusResult.Metrics,
+	}, err
+}
+
+func (m *PartitionHealthCheckAction) Status(ctx context.Context, state *PartitionHealthCheckState) (*action_kit_api.StatusResult, error) {
+	return PartitionHealthCheckStatus(ctx, state)
+}
+
+func PartitionHealthCheckStatus(ctx context.Context, state *PartitionHealthCheckState) (*action_kit_api.StatusResult, error) {
+	now := time.Now()
+
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	metadata, err := client.Metadata(ctx, state.Topics...)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve topics from Kafka. Full response: %v", err), err))
+	}
+	addMissingMinInSyncReplicas(ctx, client, state, metadata.Topics)
+
+	health := countPartitionHealth(metadata.Topics, state.MinInSyncReplicas)
+
+	completed := now.After(state.End)
+	var checkError *action_kit_api.ActionKitError
+	for _, deviation := range evaluatePartitionHealth(state, health, now) {
+		if state.FailEarly {
+			checkError = &action_kit_api.ActionKitError{
+				Title:  deviation,
+				Status: extutil.Ptr(action_kit_api.Failed),
+			}
+			break
+		}
+		state.DeviationSeen = true
+		if state.DeviationTitle == "" {
+			state.DeviationTitle = deviation
+		}
+	}
+
+	if !state.FailEarly && completed && state.DeviationSeen {
+		checkError = &action_kit_api.ActionKitError{
+			Title:  state.DeviationTitle,
+			Status: extutil.Ptr(action_kit_api.Failed),
+		}
+	}
+
+	return &action_kit_api.StatusResult{
+		Completed: completed,
+		Error:     checkError,
+		Metrics:   new(toPartitionHealthMetrics(state.ClusterName, health, now)),
+	}, nil
+}
+
+// addMissingMinInSyncReplicas describes min.insync.replicas of topics created after the check started. Topics whose
+// config can't be described are remembered with 0, so they aren't described again on every poll.
+func addMissingMinInSyncReplicas(ctx context.Context, client *kadm.Client, state *PartitionHealthCheckState, topics kadm.TopicDetails) {
+	var missing []string
+	for _, topic := range topics.Names() {
+		if _, ok := state.MinInSyncReplicas[topic]; !ok {
+			missing = append(missing, topic)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	described, err := describeMinInSyncReplicas(ctx, client, missing...)
+	if err != nil {
+		return
+	}
+	for _, topic := range missing {
+		state.MinInSyncReplicas[topic] = described[topic]
+	}
+}
+
+// evaluatePartitionHealth returns a deviation for each kind whose count stayed above its threshold for longer than the
+// grace period.
+func evaluatePartitionHealth(state *PartitionHealthCheckState, health partitionHealth, now time.Time) []string {
+	var deviations []string
+	for _, kind := range []string{partitionHealthOffline, partitionHealthUnderMinIsr, partitionHealthUnderReplicated} {
+		partitions := health.byKind()[kind]
+		if len(partitions) <= state.Thresholds[kind] {
+			delete(state.ViolatingSince, kind)
+			continue
+		}
+		since, ok := state.ViolatingSince[kind]
+		if !ok {
+			since = now
+			state.ViolatingSince[kind] = now
+		}
+		if now.Sub(since) >= state.GracePeriod {
+			deviations = append(deviations, fmt.Sprintf("%d partitions are %s for %s, at most %d allowed: %s",
+				len(partitions), kind, now.Sub(since).Round(time.Second), state.Thresholds[kind], summarizePartitions(partitions)))
+		}
+	}
+	return deviations
+}
+
+func summarizePartitions(partitions []string) string {
+	const limit = 10
+	if len(partitions) <= limit {
+		return strings.Join(partitions, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(partitions[:limit], ", "), len(partitions)-limit)
+}
+
+func toPartitionHealthMetrics(clusterName string, health partitionHealth, now time.Time) []action_kit_api.Metric {
+	metrics := make([]action_kit_api.Metric, 0, 3)
+	for _, kind := range []string{partitionHealthUnderReplicated, partitionHealthUnderMinIsr, partitionHealthOffline} {
+		partitions := health.byKind()[kind]
+		metrics = append(metrics, action_kit_api.Metric{
+			Name: new("kafka_partition_health"),
+			Metric: map[string]string{
+				"id":         fmt.Sprintf("%s - %s", clusterName, kind),
+				"partitions": summarizePartitions(partitions),
+			},
+			Timestamp: now,
+			Value:     float64(len(partitions)),
+		})
+	}
+	return metrics
+}
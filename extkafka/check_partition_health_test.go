@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func TestCheckPartitionHealth_Describe(t *testing.T) {
+	desc := (&PartitionHealthCheckAction{}).Describe()
+
+	assert.Equal(t, "Check Partition Health", desc.Label)
+	assert.Equal(t, kafkaClusterTargetId+".check-partition-health", desc.Id)
+	assert.Equal(t, kafkaClusterTargetId, desc.TargetSelection.TargetType)
+	assert.Equal(t, "Kafka", *desc.Technology)
+}
+
+func TestCountPartitionHealth(t *testing.T) {
+	//Given
+	topics := kadm.TopicDetails{
+		"orders": {Topic: "orders", Partitions: kadm.PartitionDetails{
+			0: {Partition: 0, Leader: 1, Replicas: []int32{1, 2, 3}, ISR: []int32{1, 2, 3}},
+			1: {Partition: 1, Leader: 2, Replicas: []int32{2, 3, 1}, ISR: []int32{2, 3}},
+			2: {Partition: 2, Leader: 3, Replicas: []int32{3, 1, 2}, ISR: []int32{3}},
+			3: {Partition: 3, Leader: -1, Replicas: []int32{1, 2, 3}, ISR: []int32{}, Err: kerr.LeaderNotAvailable},
+		}},
+		"payments": {Topic: "payments", Partitions: kadm.PartitionDetails{
+			0: {Partition: 0, Leader: 1, Replicas: []int32{1, 2}, ISR: []int32{1}},
+		}},
+	}
+
+	//When
+	health := countPartitionHealth(topics, map[string]int{"orders": 2})
+
+	//Then
+	assert.Equal(t, []string{"orders-1", "orders-2", "payments-0"}, health.UnderReplicated)
+	assert.Equal(t, []string{"orders-2"}, health.UnderMinIsr)
+	assert.Equal(t, []string{"orders-3"}, health.Offline)
+}
+
+func TestEvaluatePartitionHealth(t *testing.T) {
+	//Given
+	start := time.Now()
+	state := &PartitionHealthCheckState{
+		ClusterName:    "c1",
+		GracePeriod:    30 * time.Second,
+		ViolatingSince: map[string]time.Time{},
+		Thresholds: map[string]int{
+			partitionHealthUnderReplicated: 1,
+			partitionHealthUnderMinIsr:     0,
+			partitionHealthOffline:         0,
+		},
+	}
+	health := partitionHealth{UnderReplicated: []string{"orders-1"}, UnderMinIsr: []string{"orders-2"}}
+
+	//When
+	first := evaluatePartitionHealth(state, health, start)
+	second := evaluatePartitionHealth(state, health, start.Add(31*time.Second))
+	recovered := evaluatePartitionHealth(state, partitionHealth{}, start.Add(33*time.Second))
+
+	//Then
+	assert.Empty(t, first)
+	require.Len(t, second, 1)
+	assert.Equal(t, "1 partitions are under-min-isr for 31s, at most 0 allowed: orders-2", second[0])
+	assert.Empty(t, recovered)
+	assert.Empty(t, state.ViolatingSince)
+}
+
+func TestToPartitionHealthMetrics(t *testing.T) {
+	metrics := toPartitionHealthMetrics("c1", partitionHealth{Offline: []string{"orders-0", "orders-1"}}, time.Now())
+
+	require.Len(t, metrics, 3)
+	assert.Equal(t, "c1 - offline", metrics[2].Metric["id"])
+	assert.Equal(t, "orders-0, orders-1", metrics[2].Metric["partitions"])
+	assert.Equal(t, float64(2), metrics[2].Value)
+	assert.Equal(t, float64(0), metrics[0].Value)
+}
+
+func TestSummarizePartitions(t *testing.T) {
+	partitions := []string{"t-0", "t-1", "t-2", "t-3", "t-4", "t-5", "t-6", "t-7", "t-8", "t-9", "t-10", "t-11"}
+
+	assert.Equal(t, "t-0, t-1, t-2, t-3, t-4, t-5, t-6, t-7, t-8, t-9 and 2 more", summarizePartitions(partitions))
+}
@@ -0,0 +1,453 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type ProduceCanaryCheckAction struct{}
+
+type ProduceCanaryCheckState struct {
+	Topic          string
+	Partitions     []int32
+	ProbeInterval  time.Duration
+	ProbeTimeout   time.Duration
+	MaxUnwritable  time.Duration
+	End            time.Time
+	FailEarly      bool
+	DeviationSeen  bool
+	DeviationTitle string
+	ExecutionID    uuid.UUID
+	BrokerHosts    []string
+	ClusterName    string
+}
+
+type CanaryRunData struct {
+	cancel     context.CancelFunc // cancels the prober of this execution
+	ctx        context.Context    // context for the prober of this execution
+	mu         sync.Mutex         // guards partitions
+	partitions map[int32]*canaryPartitionStatus
+}
+
+// canaryPartitionStatus holds the probe results of a single partition.
+type canaryPartitionStatus struct {
+	Partition   int32
+	Probes      int
+	Failures    int
+	InFlight    bool
+	LastSuccess time.Time // the time the canary started, until the first probe succeeded
+	LastLatency time.Duration
+	LastError   string // error of the last completed probe, empty if it succeeded
+}
+
+const canaryHeader = "steadybit-canary"
+
+var (
+	CanaryRunDataMap = sync.Map{} //make(map[uuid.UUID]*CanaryRunData)
+)
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[ProduceCanaryCheckState]           = (*ProduceCanaryCheckAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[ProduceCanaryCheckState] = (*ProduceCanaryCheckAction)(nil)
+	_ action_kit_sdk.ActionWithStop[ProduceCanaryCheckState]   = (*ProduceCanaryCheckAction)(nil)
+)
+
+func NewProduceCanaryCheckAction() action_kit_sdk.Action[ProduceCanaryCheckState] {
+	return &ProduceCanaryCheckAction{}
+}
+
+func (m *ProduceCanaryCheckAction) NewEmptyState() ProduceCanaryCheckState {
+	return ProduceCanaryCheckState{}
+}
+
+func (m *ProduceCanaryCheckAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.check-produce-canary", kafkaTopicTargetId),
+		Label:       "Check Produce Availability",
+		Description: "Produce a small probe record to every partition of the topic at a fixed interval, each explicitly addressed to its partition, and fail if a partition stays unwritable for longer than the threshold. Probes are produced with acks=all, so a partition under min ISR is reported as unwritable. Unlike the produce attacks' aggregate success rate, a single stuck partition is visible.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          kafkaTopicTargetId,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionExactlyOne),
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "default",
+					Description: new("Find topic by cluster and name"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.topic.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("How long the partitions are probed."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "probeInterval",
+				Label:        "Probe Interval",
+				Description:  new("How often a probe record is produced to each partition. A new probe isn't sent to a partition while its previous probe is still in flight."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("1s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "maxUnwritable",
+				Label:        "Max Unwritable Duration",
+				Description:  new("How long a partition may go without a successful probe before the check fails. Must be longer than the probe interval."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("10s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "probeTimeout",
+				Label:        "Probe Timeout",
+				Description:  new("How long a single probe may take until it is considered failed."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("5s"),
+				Advanced:     new(true),
+				Required:     new(true),
+			},
+			{
+				Name:         "failEarly",
+				Label:        "Fail early",
+				Description:  new("If enabled, the check fails as soon as a partition is unwritable for longer than the threshold. If disabled, the check keeps probing for the whole duration and only fails at the end of the step."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Advanced:     new(true),
+				Required:     new(false),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.StateOverTimeWidget{
+				Type:  action_kit_api.ComSteadybitWidgetStateOverTime,
+				Title: "Partition Produce Availability",
+				Identity: action_kit_api.StateOverTimeWidgetIdentityConfig{
+					From: "metric.id",
+				},
+				Label: action_kit_api.StateOverTimeWidgetLabelConfig{
+					From: "metric.id",
+				},
+				State: action_kit_api.StateOverTimeWidgetStateConfig{
+					From: "state",
+				},
+				Tooltip: action_kit_api.StateOverTimeWidgetTooltipConfig{
+					From: "tooltip",
+				},
+				Value: new(action_kit_api.StateOverTimeWidgetValueConfig{
+					Hide: new(true),
+				}),
+			},
+		}),
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("1s"),
+		}),
+	}
+}
+
+func (m *ProduceCanaryCheckAction) Prepare(ctx context.Context, state *ProduceCanaryCheckState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	duration := request.Config["duration"].(float64)
+
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	state.Topic = extutil.MustHaveValue(request.Target.Attributes, "kafka.topic.name")[0]
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+	state.ExecutionID = request.ExecutionId
+	state.End = time.Now().Add(time.Millisecond * time.Duration(duration))
+	state.ProbeInterval = time.Duration(extutil.ToInt64(request.Config["probeInterval"])) * time.Millisecond
+	state.ProbeTimeout = time.Duration(extutil.ToInt64(request.Config["probeTimeout"])) * time.Millisecond
+	state.MaxUnwritable = time.Duration(extutil.ToInt64(request.Config["maxUnwritable"])) * time.Millisecond
+	if state.ProbeInterval <= 0 || state.ProbeTimeout <= 0 {
+		return nil, fmt.Errorf("the probe interval and timeout must be positive")
+	}
+	if state.MaxUnwritable <= state.ProbeInterval {
+		return nil, fmt.Errorf("the max unwritable duration must be longer than the probe interval")
+	}
+	state.FailEarly = true
+	if request.Config["failEarly"] != nil {
+		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
+	}
+
+	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	metadata, err := client.Metadata(ctx, state.Topic)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve topic %s from Kafka. Full response: %v", state.Topic, err), err))
+	}
+	topic, ok := metadata.Topics[state.Topic]
+	if !ok || topic.Err != nil || len(topic.Partitions) == 0 {
+		return nil, fmt.Errorf("topic %s not found in cluster %s", state.Topic, clusterName)
+	}
+	state.Partitions = topic.Partitions.Numbers()
+	slices.Sort(state.Partitions)
+
+	return nil, nil
+}
+
+func (m *ProduceCanaryCheckAction) Start(_ context.Context, state *ProduceCanaryCheckState) (*action_kit_api.StartResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	client, err := createNewClientWithConfig(state.BrokerHosts, clusterConfig,
+		kgo.RecordPartitioner(kgo.ManualPartitioner()),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		kgo.ProducerLinger(0),
+		kgo.RecordDeliveryTimeout(state.ProbeTimeout),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runData := &CanaryRunData{
+		cancel:     cancel,
+		ctx:        ctx,
+		partitions: make(map[int32]*canaryPartitionStatus, len(state.Partitions)),
+	}
+	now := time.Now()
+	for _, partition := range state.Partitions {
+		runData.partitions[partition] = &canaryPartitionStatus{Partition: partition, LastSuccess: now}
+	}
+	CanaryRunDataMap.Store(state.ExecutionID, runData)
+
+	go runCanaryProber(runData, client, state)
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Probing %d partition(s) of topic %s every %s", len(state.Partitions), state.Topic, state.ProbeInterval),
+		}},
+	}, nil
+}
+
+func (m *ProduceCanaryCheckAction) Status(_ context.Context, state *ProduceCanaryCheckState) (*action_kit_api.StatusResult, error) {
+	runData, err := loadCanaryRunData(state.ExecutionID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load canary run data")
+		return nil, err
+	}
+
+	now := time.Now()
+	partitions := runData.snapshot()
+	completed := now.After(state.End)
+
+	var checkError *action_kit_api.ActionKitError
+	for _, deviation := range evaluateCanary(state, partitions, now) {
+		if state.FailEarly {
+			checkError = &action_kit_api.ActionKitError{
+				Title:  deviation,
+				Status: extutil.Ptr(action_kit_api.Failed),
+			}
+			break
+		}
+		state.DeviationSeen = true
+		if state.DeviationTitle == "" {
+			state.DeviationTitle = deviation
+		}
+	}
+	if !state.FailEarly && completed && state.DeviationSeen {
+		checkError = &action_kit_api.ActionKitError{
+			Title:  state.DeviationTitle,
+			Status: extutil.Ptr(action_kit_api.Failed),
+		}
+	}
+
+	return &action_kit_api.StatusResult{
+		Completed: completed,
+		Error:     checkError,
+		Metrics:   new(toCanaryMetrics(state, partitions, now)),
+	}, nil
+}
+
+func (m *ProduceCanaryCheckAction) Stop(_ context.Context, state *ProduceCanaryCheckState) (*action_kit_api.StopResult, error) {
+	runData, err := loadCanaryRunData(state.ExecutionID)
+	if err != nil {
+		log.Debug().Err(err).Msg("Canary run data not found, stop was already called")
+		return nil, nil
+	}
+	runData.cancel()
+	CanaryRunDataMap.Delete(state.ExecutionID)
+
+	return &action_kit_api.StopResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: describeCanaryResults(state.Topic, runData.snapshot()),
+		}},
+	}, nil
+}
+
+func loadCanaryRunData(executionID uuid.UUID) (*CanaryRunData, error) {
+	runData, ok := CanaryRunDataMap.Load(executionID)
+	if !ok {
+		return nil, fmt.Errorf("failed to load canary run data")
+	}
+	return runData.(*CanaryRunData), nil
+}
+
+// runCanaryProber produces a probe to every partition without one in flight on each tick, until the run is cancelled.
+func runCanaryProber(runData *CanaryRunData, client *kgo.Client, state *ProduceCanaryCheckState) {
+	defer client.Close()
+	ticker := time.NewTicker(state.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, partition := range state.Partitions {
+			runData.mu.Lock()
+			status := runData.partitions[partition]
+			if status.InFlight {
+				runData.mu.Unlock()
+				continue
+			}
+			status.InFlight = true
+			runData.mu.Unlock()
+
+			sent := time.Now()
+			record := &kgo.Record{
+				Topic:     state.Topic,
+				Partition: partition,
+				Key:       []byte(canaryHeader),
+				Value:     []byte(sent.UTC().Format(time.RFC3339Nano)),
+				Headers:   []kgo.RecordHeader{{Key: canaryHeader, Value: []byte(state.ExecutionID.String())}},
+			}
+			client.Produce(runData.ctx, record, func(_ *kgo.Record, err error) {
+				runData.recordProbe(partition, sent, time.Now(), err)
+			})
+		}
+
+		select {
+		case <-runData.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *CanaryRunData) recordProbe(partition int32, sent time.Time, received time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := r.partitions[partition]
+	status.InFlight = false
+	status.Probes++
+	if err != nil {
+		status.Failures++
+		status.LastError = err.Error()
+		return
+	}
+	status.LastError = ""
+	status.LastSuccess = received
+	status.LastLatency = received.Sub(sent)
+}
+
+func (r *CanaryRunData) snapshot() []canaryPartitionStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]canaryPartitionStatus, 0, len(r.partitions))
+	for _, status := range r.partitions {
+		result = append(result, *status)
+	}
+	slices.SortFunc(result, func(a, b canaryPartitionStatus) int { return int(a.Partition - b.Partition) })
+	return result
+}
+
+// evaluateCanary returns a deviation for every partition without a successful probe for longer than MaxUnwritable.
+func evaluateCanary(state *ProduceCanaryCheckState, partitions []canaryPartitionStatus, now time.Time) []string {
+	var deviations []string
+	for _, p := range partitions {
+		if unwritable := now.Sub(p.LastSuccess); unwritable > state.MaxUnwritable {
+			deviation := fmt.Sprintf("Partition %d of topic %s was unwritable for %s, at most %s allowed.", p.Partition, state.Topic, unwritable.Round(time.Second), state.MaxUnwritable)
+			if p.LastError != "" {
+				deviation = fmt.Sprintf("%s Last error: %s", deviation, p.LastError)
+			}
+			deviations = append(deviations, deviation)
+		}
+	}
+	return deviations
+}
+
+func toCanaryMetrics(state *ProduceCanaryCheckState, partitions []canaryPartitionStatus, now time.Time) []action_kit_api.Metric {
+	metrics := make([]action_kit_api.Metric, 0, len(partitions))
+	for _, p := range partitions {
+		unwritable := now.Sub(p.LastSuccess)
+		metricState := "success"
+		if p.LastError != "" || unwritable > state.MaxUnwritable {
+			metricState = "danger"
+		} else if unwritable > 2*state.ProbeInterval {
+			// the last probe is still in flight for longer than expected
+			metricState = "warn"
+		}
+
+		tooltip := fmt.Sprintf("Probes: %d, failed: %d\nLast latency: %dms", p.Probes, p.Failures, p.LastLatency.Milliseconds())
+		if p.LastError != "" {
+			tooltip = fmt.Sprintf("%s\nLast error: %s", tooltip, p.LastError)
+		}
+		if metricState != "success" {
+			tooltip = fmt.Sprintf("%s\nUnwritable for: %s", tooltip, unwritable.Round(time.Millisecond))
+		}
+
+		metrics = append(metrics, action_kit_api.Metric{
+			Name: new("kafka_produce_canary"),
+			Metric: map[string]string{
+				"metric.id": fmt.Sprintf("%s-%d", state.Topic, p.Partition),
+				"state":     metricState,
+				"tooltip":   tooltip,
+			},
+			Timestamp: now,
+			Value:     float64(p.LastLatency.Milliseconds()),
+		})
+	}
+	return metrics
+}
+
+func describeCanaryResults(topic string, partitions []canaryPartitionStatus) string {
+	probes, failures := 0, 0
+	var failing []string
+	for _, p := range partitions {
+		probes += p.Probes
+		failures += p.Failures
+		if p.Failures > 0 {
+			failing = append(failing, fmt.Sprintf("%d (%d/%d failed)", p.Partition, p.Failures, p.Probes))
+		}
+	}
+	summary := fmt.Sprintf("Sent %d probe(s) to %d partition(s) of topic %s, %d failed", probes, len(partitions), topic, failures)
+	if len(failing) > 0 {
+		summary = fmt.Sprintf("%s. Partitions with failed probes: %s", summary, strings.Join(failing, ", "))
+	}
+	return summary
+}
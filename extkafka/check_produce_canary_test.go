@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckProduceCanary_Describe(t *testing.T) {
+	desc := (&ProduceCanaryCheckAction{}).Describe()
+
+	assert.Equal(t, "Check Produce Availability", desc.Label)
+	assert.Equal(t, kafkaTopicTargetId+".check-produce-canary", desc.Id)
+	assert.Equal(t, kafkaTopicTargetId, desc.TargetSelection.TargetType)
+	assert.Equal(t, "Kafka", *desc.Technology)
+}
+
+func TestCheckProduceCanary_PrepareRejectsShortMaxUnwritable(t *testing.T) {
+	//Given
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {SeedBrokers: "localhost:9092"},
+	})
+	action := ProduceCanaryCheckAction{}
+	state := action.NewEmptyState()
+	request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"kafka.topic.name":   {"orders"},
+				"kafka.cluster.name": {"test-cluster"},
+			},
+		},
+		Config:      map[string]any{"duration": 30000, "probeInterval": 5000, "maxUnwritable": 5000, "probeTimeout": 5000},
+		ExecutionId: uuid.New(),
+	})
+
+	//When
+	_, err := action.Prepare(t.Context(), &state, request)
+
+	//Then
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "longer than the probe interval")
+}
+
+func TestCanaryRunData_recordProbe(t *testing.T) {
+	//Given
+	start := time.Now()
+	runData := &CanaryRunData{partitions: map[int32]*canaryPartitionStatus{
+		0: {Partition: 0, LastSuccess: start, InFlight: true},
+		1: {Partition: 1, LastSuccess: start, InFlight: true},
+	}}
+
+	//When
+	runData.recordProbe(1, start, start.Add(15*time.Millisecond), nil)
+	runData.recordProbe(0, start, start.Add(5*time.Second), errors.New("NOT_ENOUGH_REPLICAS"))
+
+	//Then
+	partitions := runData.snapshot()
+	require.Len(t, partitions, 2)
+	assert.Equal(t, canaryPartitionStatus{Partition: 0, Probes: 1, Failures: 1, LastSuccess: start, LastError: "NOT_ENOUGH_REPLICAS"}, partitions[0])
+	assert.Equal(t, canaryPartitionStatus{Partition: 1, Probes: 1, LastSuccess: start.Add(15 * time.Millisecond), LastLatency: 15 * time.Millisecond}, partitions[1])
+}
+
+func TestEvaluateCanary(t *testing.T) {
+	//Given
+	now := time.Now()
+	state := &ProduceCanaryCheckState{Topic: "orders", ProbeInterval: time.Second, MaxUnwritable: 10 * time.Second}
+	partitions := []canaryPartitionStatus{
+		{Partition: 0, LastSuccess: now.Add(-time.Second)},
+		{Partition: 1, LastSuccess: now.Add(-12 * time.Second), LastError: "NOT_ENOUGH_REPLICAS"},
+		{Partition: 2, LastSuccess: now.Add(-3 * time.Second)},
+	}
+
+	//When
+	deviations := evaluateCanary(state, partitions, now)
+	metrics := toCanaryMetrics(state, partitions, now)
+
+	//Then
+	assert.Equal(t, []string{"Partition 1 of topic orders was unwritable for 12s, at most 10s allowed. Last error: NOT_ENOUGH_REPLICAS"}, deviations)
+	require.Len(t, metrics, 3)
+	assert.Equal(t, "orders-0", metrics[0].Metric["metric.id"])
+	assert.Equal(t, "success", metrics[0].Metric["state"])
+	assert.Equal(t, "danger", metrics[1].Metric["state"])
+	assert.Contains(t, metrics[1].Metric["tooltip"], "NOT_ENOUGH_REPLICAS")
+	assert.Equal(t, "warn", metrics[2].Metric["state"])
+}
+
+func TestDescribeCanaryResults(t *testing.T) {
+	summary := describeCanaryResults("orders", []canaryPartitionStatus{
+		{Partition: 0, Probes: 10},
+		{Partition: 1, Probes: 8, Failures: 3},
+	})
+
+	assert.Equal(t, "Sent 18 probe(s) to 2 partition(s) of topic orders, 3 failed. Partitions with failed probes: 1 (3/8 failed)", summary)
+}
@@ -0,0 +1,296 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+)
+
+type CheckQuorumAction struct{}
+
+type CheckQuorumState struct {
+	LeaderID          int32
+	LeaderEpoch       int32
+	End               time.Time
+	AllowLeaderChange bool
+	MaxVoterLag       int64
+	FailEarly         bool
+	DeviationSeen     bool
+	DeviationTitle    string
+	BrokerHosts       []string
+	ClusterName       string
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[CheckQuorumState]           = (*CheckQuorumAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[CheckQuorumState] = (*CheckQuorumAction)(nil)
+)
+
+func NewQuorumCheckAction() action_kit_sdk.Action[CheckQuorumState] {
+	return &CheckQuorumAction{}
+}
+
+func (m *CheckQuorumAction) NewEmptyState() CheckQuorumState {
+	return CheckQuorumState{}
+}
+
+func (m *CheckQuorumAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.check-quorum", kafkaClusterTargetId),
+		Label:       "Check Controller Quorum",
+		Description: "Monitor the KRaft controller quorum during an experiment. Fails if the quorum leader changes unexpectedly, no leader is elected or a voter lags behind the high watermark of the metadata log.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          kafkaClusterTargetId,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionExactlyOne),
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "by cluster name",
+					Description: new("Find cluster by name"),
+					Query:       "kafka.cluster.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("How long the check runs. The controller quorum is polled continuously for this duration."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "allowLeaderChange",
+				Label:        "Allow Leader Change",
+				Description:  new("Whether a new quorum leader may be elected during the check, e.g. when the active controller is the target of an attack."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("false"),
+				Required:     new(false),
+			},
+			{
+				Name:         "maxVoterLag",
+				Label:        "Max Voter Lag",
+				Description:  new("The maximum number of records a voter may lag behind the high watermark of the metadata log."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1000"),
+				Required:     new(true),
+			},
+			{
+				Name:         "failEarly",
+				Label:        "Fail early",
+				Description:  new("If enabled, the check fails as soon as a deviation is observed. If disabled, the check keeps polling for the whole duration and only fails at the end of the step."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Advanced:     new(true),
+				Required:     new(false),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Controller Quorum Voter Lag",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_quorum_voter_lag",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Grouping: new(action_kit_api.LineChartWidgetGroupingConfig{
+					ShowSummary: new(true),
+					Groups: []action_kit_api.LineChartWidgetGroup{
+						{
+							Title: "Within Threshold",
+							Color: "success",
+							Matcher: action_kit_api.LineChartWidgetGroupMatcherKeyEqualsValue{
+								Key:   "state",
+								Value: "success",
+							},
+						},
+						{
+							Title: "Above Threshold",
+							Color: "danger",
+							Matcher: action_kit_api.LineChartWidgetGroupMatcherKeyEqualsValue{
+								Key:   "state",
+								Value: "danger",
+							},
+						},
+					},
+				}),
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Lag (records)"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "leader",
+							Title: "Leader",
+						},
+					},
+				}),
+			},
+		}),
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("2s"),
+		}),
+	}
+}
+
+func (m *CheckQuorumAction) Prepare(ctx context.Context, state *CheckQuorumState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	duration := request.Config["duration"].(float64)
+
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+	state.End = time.Now().Add(time.Millisecond * time.Duration(duration))
+	state.AllowLeaderChange = extutil.ToBool(request.Config["allowLeaderChange"])
+	state.MaxVoterLag = extutil.ToInt64(request.Config["maxVoterLag"])
+	if state.MaxVoterLag < 0 {
+		return nil, fmt.Errorf("the max voter lag must not be negative")
+	}
+	state.FailEarly = true
+	if request.Config["failEarly"] != nil {
+		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
+	}
+
+	client, err := createNewClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	quorum, err := describeQuorum(ctx, client)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to describe the controller quorum of cluster %s, is it running in KRaft mode?", clusterName), err))
+	}
+	state.LeaderID = quorum.LeaderID
+	state.LeaderEpoch = quorum.LeaderEpoch
+
+	return nil, nil
+}
+
+func (m *CheckQuorumAction) Start(ctx context.Context, state *CheckQuorumState) (*action_kit_api.StartResult, error) {
+	statusResult, err := QuorumCheckStatus(ctx, state)
+	if statusResult == nil {
+		return nil, err
+	}
+	return &action_kit_api.StartResult{
+		Error:    statusResult.Error,
+		Messages: statusResult.Messages,
+		Metrics:  statusResult.Metrics,
+	}, err
+}
+
+func (m *CheckQuorumAction) Status(ctx context.Context, state *CheckQuorumState) (*action_kit_api.StatusResult, error) {
+	return QuorumCheckStatus(ctx, state)
+}
+
+func QuorumCheckStatus(ctx context.Context, state *CheckQuorumState) (*action_kit_api.StatusResult, error) {
+	now := time.Now()
+
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	client, err := createNewClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	quorum, err := describeQuorum(ctx, client)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to describe the controller quorum of cluster %s.", state.ClusterName), err))
+	}
+
+	completed := now.After(state.End)
+	var checkError *action_kit_api.ActionKitError
+	for _, deviation := range evaluateQuorum(state, quorum) {
+		if state.FailEarly {
+			checkError = &action_kit_api.ActionKitError{
+				Title:  deviation,
+				Status: extutil.Ptr(action_kit_api.Failed),
+			}
+			break
+		}
+		state.DeviationSeen = true
+		if state.DeviationTitle == "" {
+			state.DeviationTitle = deviation
+		}
+	}
+	state.LeaderID = quorum.LeaderID
+	state.LeaderEpoch = quorum.LeaderEpoch
+
+	if !state.FailEarly && completed && state.DeviationSeen {
+		checkError = &action_kit_api.ActionKitError{
+			Title:  state.DeviationTitle,
+			Status: extutil.Ptr(action_kit_api.Failed),
+		}
+	}
+
+	return &action_kit_api.StatusResult{
+		Completed: completed,
+		Error:     checkError,
+		Metrics:   new(toQuorumVoterLagMetrics(state, quorum, now)),
+	}, nil
+}
+
+// evaluateQuorum compares the current quorum with the state of the previous poll and returns the deviations.
+func evaluateQuorum(state *CheckQuorumState, quorum *quorumInfo) []string {
+	var deviations []string
+	if quorum.LeaderID < 0 {
+		deviations = append(deviations, fmt.Sprintf("The controller quorum of cluster %s has no leader.", state.ClusterName))
+	} else if !state.AllowLeaderChange && quorum.LeaderEpoch != state.LeaderEpoch {
+		deviations = append(deviations, fmt.Sprintf("The controller quorum leader changed from %d (epoch %d) to %d (epoch %d).",
+			state.LeaderID, state.LeaderEpoch, quorum.LeaderID, quorum.LeaderEpoch))
+	}
+	for _, voter := range quorum.Voters {
+		if lag := quorum.Lag(voter); lag > state.MaxVoterLag {
+			deviations = append(deviations, fmt.Sprintf("Controller quorum voter %d lags %d records behind the high watermark, max %d allowed.",
+				voter.ReplicaID, lag, state.MaxVoterLag))
+		}
+	}
+	return deviations
+}
+
+func toQuorumVoterLagMetrics(state *CheckQuorumState, quorum *quorumInfo, now time.Time) []action_kit_api.Metric {
+	metrics := make([]action_kit_api.Metric, 0, len(quorum.Voters))
+	for _, voter := range quorum.Voters {
+		lag := quorum.Lag(voter)
+		metricState := "success"
+		if lag > state.MaxVoterLag {
+			metricState = "danger"
+		}
+		metrics = append(metrics, action_kit_api.Metric{
+			Name: new("kafka_quorum_voter_lag"),
+			Metric: map[string]string{
+				"id":     fmt.Sprintf("%s - Voter %d", state.ClusterName, voter.ReplicaID),
+				"state":  metricState,
+				"leader": fmt.Sprintf("%d", quorum.LeaderID),
+			},
+			Timestamp: now,
+			Value:     float64(lag),
+		})
+	}
+	return metrics
+}
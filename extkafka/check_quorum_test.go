@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestCheckQuorum_Describe(t *testing.T) {
+	desc := (&CheckQuorumAction{}).Describe()
+
+	assert.Equal(t, "Check Controller Quorum", desc.Label)
+	assert.Equal(t, kafkaClusterTargetId+".check-quorum", desc.Id)
+	assert.Equal(t, kafkaClusterTargetId, desc.TargetSelection.TargetType)
+	assert.Equal(t, "Kafka", *desc.Technology)
+}
+
+func TestAddQuorumAttributes(t *testing.T) {
+	//Given
+	partition := kmsg.NewDescribeQuorumResponseTopicPartition()
+	partition.LeaderID = 1
+	partition.LeaderEpoch = 7
+	partition.HighWatermark = 500
+	partition.CurrentVoters = []kmsg.DescribeQuorumResponseTopicPartitionReplicaState{
+		{ReplicaID: 1, LogEndOffset: 500},
+		{ReplicaID: 2, LogEndOffset: 480},
+		{ReplicaID: 3, LogEndOffset: -1},
+	}
+	partition.Observers = []kmsg.DescribeQuorumResponseTopicPartitionReplicaState{
+		{ReplicaID: 4, LogEndOffset: 499},
+	}
+	attributes := map[string][]string{}
+
+	//When
+	addQuorumAttributes(attributes, toQuorumInfo(partition))
+
+	//Then
+	assert.Equal(t, []string{"1"}, attributes["kafka.cluster.quorum-leader-id"])
+	assert.Equal(t, []string{"7"}, attributes["kafka.cluster.quorum-leader-epoch"])
+	assert.Equal(t, []string{"500"}, attributes["kafka.cluster.quorum-high-watermark"])
+	assert.Equal(t, []string{"1", "2", "3"}, attributes["kafka.cluster.quorum-voters"])
+	assert.Equal(t, []string{"4"}, attributes["kafka.cluster.quorum-observers"])
+	assert.Equal(t, []string{"1->lag=0", "2->lag=20", "3->lag=-1"}, attributes["kafka.cluster.quorum-voter-lag"])
+}
+
+func TestEvaluateQuorum(t *testing.T) {
+	quorum := func(leader, epoch int32, voterOffsets ...int64) *quorumInfo {
+		info := &quorumInfo{LeaderID: leader, LeaderEpoch: epoch, HighWatermark: 100}
+		for i, offset := range voterOffsets {
+			info.Voters = append(info.Voters, quorumReplica{ReplicaID: int32(i + 1), LogEndOffset: offset})
+		}
+		return info
+	}
+
+	tests := []struct {
+		name     string
+		state    CheckQuorumState
+		quorum   *quorumInfo
+		expected []string
+	}{
+		{
+			name:   "healthy quorum",
+			state:  CheckQuorumState{LeaderID: 1, LeaderEpoch: 3, MaxVoterLag: 10},
+			quorum: quorum(1, 3, 100, 95, 100),
+		},
+		{
+			name:     "unexpected leader change",
+			state:    CheckQuorumState{LeaderID: 1, LeaderEpoch: 3, MaxVoterLag: 10},
+			quorum:   quorum(2, 4, 100, 100, 100),
+			expected: []string{"The controller quorum leader changed from 1 (epoch 3) to 2 (epoch 4)."},
+		},
+		{
+			name:   "allowed leader change",
+			state:  CheckQuorumState{LeaderID: 1, LeaderEpoch: 3, MaxVoterLag: 10, AllowLeaderChange: true},
+			quorum: quorum(2, 4, 100, 100, 100),
+		},
+		{
+			name:     "no leader",
+			state:    CheckQuorumState{ClusterName: "c1", LeaderID: 1, LeaderEpoch: 3, MaxVoterLag: 10, AllowLeaderChange: true},
+			quorum:   quorum(-1, 4, 100, 100, 100),
+			expected: []string{"The controller quorum of cluster c1 has no leader."},
+		},
+		{
+			name:     "voter lag above threshold",
+			state:    CheckQuorumState{LeaderID: 1, LeaderEpoch: 3, MaxVoterLag: 10},
+			quorum:   quorum(1, 3, 100, 50, -1),
+			expected: []string{"Controller quorum voter 2 lags 50 records behind the high watermark, max 10 allowed."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, evaluateQuorum(&tt.state, tt.quorum))
+		})
+	}
+}
+
+func TestToQuorumVoterLagMetrics(t *testing.T) {
+	info := &quorumInfo{LeaderID: 1, HighWatermark: 100, Voters: []quorumReplica{{ReplicaID: 1, LogEndOffset: 100}, {ReplicaID: 2, LogEndOffset: 40}}}
+
+	metrics := toQuorumVoterLagMetrics(&CheckQuorumState{ClusterName: "c1", MaxVoterLag: 10}, info, time.Now())
+
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "c1 - Voter 2", metrics[1].Metric["id"])
+	assert.Equal(t, "danger", metrics[1].Metric["state"])
+	assert.Equal(t, float64(60), metrics[1].Value)
+	assert.Equal(t, "success", metrics[0].Metric["state"])
+}
@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+type RackAwarenessCheckAction struct{}
+
+type RackAwarenessCheckState struct {
+	Topic       string
+	AllTopics   bool
+	MinRacks    int
+	BrokerHosts []string
+	ClusterName string
+}
+
+// rackViolation is a partition whose replica placement isn't rack-safe.
+type rackViolation struct {
+	Topic     string
+	Partition int32
+	Replicas  []int32
+	Racks     []string
+	Reasons   []string
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[RackAwarenessCheckState] = (*RackAwarenessCheckAction)(nil)
+)
+
+func NewRackAwarenessCheckAction() action_kit_sdk.Action[RackAwarenessCheckState] {
+	return &RackAwarenessCheckAction{}
+}
+
+func (m *RackAwarenessCheckAction) NewEmptyState() RackAwarenessCheckState {
+	return RackAwarenessCheckState{}
+}
+
+func (m *RackAwarenessCheckAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.check-rack-awareness", kafkaTopicTargetId),
+		Label:       "Check Rack-Aware Replica Placement",
+		Description: "Verify that the replicas of every partition span at least the given number of racks (broker.rack) and that the in-sync replicas would survive the loss of any single rack, i.e. the partition stays online and at or above min.insync.replicas. Use it as a pre-flight step before rack or availability zone failures. Violating partitions are reported as messages and as artifact.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          kafkaTopicTargetId,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionExactlyOne),
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "default",
+					Description: new("Find topic by cluster and name"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.topic.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInstantaneous,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "minRacks",
+				Label:        "Min Racks",
+				Description:  new("The minimum number of racks the replicas of every partition must span."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("2"),
+				MinValue:     new(1),
+				Required:     new(true),
+			},
+			{
+				Name:         "allTopics",
+				Label:        "Check all topics",
+				Description:  new("If enabled, all topics of the cluster are checked instead of only the targeted topic."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("false"),
+				Required:     new(false),
+			},
+		},
+	}
+}
+
+func (m *RackAwarenessCheckAction) Prepare(_ context.Context, state *RackAwarenessCheckState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	state.Topic = extutil.MustHaveValue(request.Target.Attributes, "kafka.topic.name")[0]
+	state.AllTopics = extutil.ToBool(request.Config["allTopics"])
+	state.MinRacks = extutil.ToInt(request.Config["minRacks"])
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+
+	return nil, nil
+}
+
+func (m *RackAwarenessCheckAction) Start(ctx context.Context, state *RackAwarenessCheckState) (*action_kit_api.StartResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	var topics []string
+	if !state.AllTopics {
+		topics = []string{state.Topic}
+	}
+	metadata, err := client.Metadata(ctx, topics...)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve metadata from Kafka. Full response: %v", err), err))
+	}
+	if !slices.ContainsFunc(metadata.Brokers, func(broker kadm.BrokerDetail) bool { return broker.Rack != nil }) {
+		return nil, fmt.Errorf("no broker of cluster %s has a rack configured", state.ClusterName)
+	}
+	minInSyncReplicas, err := describeMinInSyncReplicas(ctx, client, metadata.Topics.Names()...)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to describe the min.insync.replicas of the topics. Full response: %v", err), err))
+	}
+
+	violations := validateRackAwareness(metadata.Topics, metadata.Brokers, minInSyncReplicas, state.MinRacks)
+	checked := fmt.Sprintf("topic %s", state.Topic)
+	if state.AllTopics {
+		checked = fmt.Sprintf("%d topics", len(metadata.Topics))
+	}
+	if len(violations) == 0 {
+		return &action_kit_api.StartResult{
+			Messages: &[]action_kit_api.Message{{
+				Level:   extutil.Ptr(action_kit_api.Info),
+				Message: fmt.Sprintf("The replica placement of %s is rack-safe.", checked),
+			}},
+		}, nil
+	}
+
+	artifact, err := toRackViolationsArtifact(violations)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]action_kit_api.Message, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, action_kit_api.Message{
+			Level:   extutil.Ptr(action_kit_api.Warn),
+			Message: fmt.Sprintf("Partition %s-%d on racks %s: %s.", violation.Topic, violation.Partition, strings.Join(violation.Racks, ","), strings.Join(violation.Reasons, ", ")),
+		})
+	}
+	return &action_kit_api.StartResult{
+		Error: &action_kit_api.ActionKitError{
+			Title:  fmt.Sprintf("The replica placement of %s isn't rack-safe, %d partition(s) violate it.", checked, len(violations)),
+			Status: extutil.Ptr(action_kit_api.Failed),
+		},
+		Messages:  &messages,
+		Artifacts: new([]action_kit_api.Artifact{artifact}),
+	}, nil
+}
+
+// validateRackAwareness returns the partitions whose replicas span fewer than minRacks racks, are placed on brokers
+// without rack, or whose in-sync replicas wouldn't survive the loss of a rack.
+func validateRackAwareness(topics kadm.TopicDetails, brokers kadm.BrokerDetails, minInSyncReplicas map[string]int, minRacks int) []rackViolation {
+	rackOf := map[int32]string{}
+	brokersByRack := map[string][]int32{}
+	for _, broker := range brokers {
+		if broker.Rack != nil {
+			rackOf[broker.NodeID] = *broker.Rack
+			brokersByRack[*broker.Rack] = append(brokersByRack[*broker.Rack], broker.NodeID)
+		}
+	}
+
+	violations := map[string]*rackViolation{}
+	violationOf := func(topic string, partition kadm.PartitionDetail) *rackViolation {
+		key := fmt.Sprintf("%s-%d", topic, partition.Partition)
+		if violations[key] == nil {
+			var racks []string
+			for _, replica := range partition.Replicas {
+				if rack, ok := rackOf[replica]; ok && !slices.Contains(racks, rack) {
+					racks = append(racks, rack)
+				}
+			}
+			slices.Sort(racks)
+			violations[key] = &rackViolation{Topic: topic, Partition: partition.Partition, Replicas: partition.Replicas, Racks: racks}
+		}
+		return violations[key]
+	}
+
+	for _, topic := range topics.Sorted() {
+		if topic.Err != nil {
+			continue
+		}
+		for _, partition := range topic.Partitions.Sorted() {
+			var withoutRack []int32
+			racks := map[string]bool{}
+			for _, replica := range partition.Replicas {
+				if rack, ok := rackOf[replica]; ok {
+					racks[rack] = true
+				} else {
+					withoutRack = append(withoutRack, replica)
+				}
+			}
+			if len(withoutRack) > 0 {
+				violation := violationOf(topic.Topic, partition)
+				violation.Reasons = append(violation.Reasons, fmt.Sprintf("replicas on brokers %s without rack", joinInt32s(withoutRack)))
+			}
+			if len(racks) < minRacks {
+				violation := violationOf(topic.Topic, partition)
+				violation.Reasons = append(violation.Reasons, fmt.Sprintf("replicas span %d rack(s), at least %d required", len(racks), minRacks))
+			}
+		}
+	}
+
+	for _, rack := range slices.Sorted(maps.Keys(brokersByRack)) {
+		impact := analyzeBrokerLoss(topics, minInSyncReplicas, brokersByRack[rack], nil)
+		for _, topicImpact := range impact.Topics {
+			for _, partition := range topicImpact.Offline {
+				violation := violationOf(topicImpact.Topic, topics[topicImpact.Topic].Partitions[partition])
+				violation.Reasons = append(violation.Reasons, fmt.Sprintf("offline if rack %s fails", rack))
+			}
+			for _, partition := range topicImpact.UnderMinIsr {
+				violation := violationOf(topicImpact.Topic, topics[topicImpact.Topic].Partitions[partition])
+				violation.Reasons = append(violation.Reasons, fmt.Sprintf("under min.insync.replicas if rack %s fails", rack))
+			}
+		}
+	}
+
+	result := make([]rackViolation, 0, len(violations))
+	for _, violation := range violations {
+		result = append(result, *violation)
+	}
+	slices.SortFunc(result, func(a, b rackViolation) int {
+		if c := strings.Compare(a.Topic, b.Topic); c != 0 {
+			return c
+		}
+		return int(a.Partition - b.Partition)
+	})
+	return result
+}
+
+func toRackViolationsArtifact(violations []rackViolation) (action_kit_api.Artifact, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	rows := [][]string{{"topic", "partition", "replicas", "racks", "violations"}}
+	for _, violation := range violations {
+		rows = append(rows, []string{
+			violation.Topic,
+			strconv.Itoa(int(violation.Partition)),
+			joinInt32s(violation.Replicas),
+			strings.Join(violation.Racks, ","),
+			strings.Join(violation.Reasons, "; "),
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return action_kit_api.Artifact{}, fmt.Errorf("failed to write rack awareness violations: %w", err)
+	}
+	return action_kit_api.Artifact{
+		Label: "rack-awareness-violations.csv",
+		Data:  base64.StdEncoding.EncodeToString(buffer.Bytes()),
+	}, nil
+}
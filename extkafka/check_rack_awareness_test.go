@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestCheckRackAwareness_Describe(t *testing.T) {
+	desc := (&RackAwarenessCheckAction{}).Describe()
+
+	assert.Equal(t, "Check Rack-Aware Replica Placement", desc.Label)
+	assert.Equal(t, kafkaTopicTargetId+".check-rack-awareness", desc.Id)
+	assert.Equal(t, kafkaTopicTargetId, desc.TargetSelection.TargetType)
+	assert.Equal(t, "Kafka", *desc.Technology)
+}
+
+func TestValidateRackAwareness(t *testing.T) {
+	//Given
+	brokers := kadm.BrokerDetails{
+		{NodeID: 1, Rack: new("eu-1a")},
+		{NodeID: 2, Rack: new("eu-1b")},
+		{NodeID: 3, Rack: new("eu-1c")},
+		{NodeID: 4, Rack: new("eu-1a")},
+		{NodeID: 5},
+	}
+	topics := kadm.TopicDetails{
+		"orders": {
+			Topic: "orders",
+			Partitions: kadm.PartitionDetails{
+				// rack-safe: three racks, two in-sync replicas survive any rack
+				0: {Partition: 0, Leader: 1, Replicas: []int32{1, 2, 3}, ISR: []int32{1, 2, 3}},
+				// both replicas in eu-1a
+				1: {Partition: 1, Leader: 1, Replicas: []int32{1, 4}, ISR: []int32{1, 4}},
+				// spans two racks, but only eu-1b is in sync
+				2: {Partition: 2, Leader: 2, Replicas: []int32{2, 3}, ISR: []int32{2}},
+			},
+		},
+		"audit": {
+			Topic: "audit",
+			Partitions: kadm.PartitionDetails{
+				0: {Partition: 0, Leader: 3, Replicas: []int32{3, 5}, ISR: []int32{3, 5}},
+			},
+		},
+	}
+
+	//When
+	violations := validateRackAwareness(topics, brokers, map[string]int{"orders": 2}, 2)
+
+	//Then
+	assert.Equal(t, []rackViolation{
+		{Topic: "audit", Partition: 0, Replicas: []int32{3, 5}, Racks: []string{"eu-1c"}, Reasons: []string{
+			"replicas on brokers 5 without rack",
+			"replicas span 1 rack(s), at least 2 required",
+		}},
+		{Topic: "orders", Partition: 1, Replicas: []int32{1, 4}, Racks: []string{"eu-1a"}, Reasons: []string{
+			"replicas span 1 rack(s), at least 2 required",
+			"offline if rack eu-1a fails",
+		}},
+		{Topic: "orders", Partition: 2, Replicas: []int32{2, 3}, Racks: []string{"eu-1b", "eu-1c"}, Reasons: []string{
+			"under min.insync.replicas if rack eu-1a fails",
+			"offline if rack eu-1b fails",
+			"under min.insync.replicas if rack eu-1c fails",
+		}},
+	}, violations)
+
+	artifact, err := toRackViolationsArtifact(violations[:1])
+	require.NoError(t, err)
+	data, err := base64.StdEncoding.DecodeString(artifact.Data)
+	require.NoError(t, err)
+	assert.Equal(t, "topic,partition,replicas,racks,violations\naudit,0,\"3,5\",eu-1c,\"replicas on brokers 5 without rack; replicas span 1 rack(s), at least 2 required\"\n", string(data))
+}
@@ -0,0 +1,264 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+)
+
+type StreamsRestoreCheckAction struct{}
+
+type StreamsRestoreCheckState struct {
+	ApplicationID  string
+	MaxLag         int64
+	End            time.Time
+	FailEarly      bool
+	DeviationSeen  bool
+	DeviationTitle string
+	BrokerHosts    []string
+	ClusterName    string
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[StreamsRestoreCheckState]           = (*StreamsRestoreCheckAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[StreamsRestoreCheckState] = (*StreamsRestoreCheckAction)(nil)
+)
+
+func NewStreamsRestoreCheckAction() action_kit_sdk.Action[StreamsRestoreCheckState] {
+	return &StreamsRestoreCheckAction{}
+}
+
+func (m *StreamsRestoreCheckAction) NewEmptyState() StreamsRestoreCheckState {
+	return StreamsRestoreCheckState{}
+}
+
+func (m *StreamsRestoreCheckAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.check-streams-restore", kafkaConsumerTargetId),
+		Label:       "Check Streams Restore Lag",
+		Description: "Fail the experiment if a Kafka Streams application falls behind by more than a threshold while it restores its state. The restore consumers don't commit offsets, so the restore itself isn't visible to the brokers. Instead the check measures the lag the application builds up on the topics it commits offsets for: its input and repartition topics and changelog topics reusing a source topic. This lag grows while tasks are restoring and drains once they are running again.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          kafkaConsumerTargetId,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionExactlyOne),
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "streams application id",
+					Description: new("Find Kafka Streams application by cluster and application id"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.consumer-group.streams.application-id=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("How long the check runs. The application's lag is polled continuously for this duration."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("60s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "maxLag",
+				Label:        "Max Lag",
+				Description:  new("The maximum number of records the application may lag behind, summed over all its topics."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1000"),
+				Required:     new(true),
+			},
+			{
+				Name:         "failEarly",
+				Label:        "Fail early",
+				Description:  new("If enabled, the check fails as soon as the lag exceeds the threshold. If disabled, the check keeps polling for the whole duration and only fails at the end of the step."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Advanced:     new(true),
+				Required:     new(false),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Kafka Streams Lag",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_streams_lag",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Lag"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "topic_kind",
+							Title: "Topic Kind",
+						},
+					},
+				}),
+			},
+		}),
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("2s"),
+		}),
+	}
+}
+
+func (m *StreamsRestoreCheckAction) Prepare(_ context.Context, state *StreamsRestoreCheckState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	if len(request.Target.Attributes["kafka.consumer-group.streams.application-id"]) == 0 {
+		return nil, fmt.Errorf("the consumer group isn't a Kafka Streams application, no internal topics were discovered")
+	}
+	duration := request.Config["duration"].(float64)
+
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	state.ApplicationID = request.Target.Attributes["kafka.consumer-group.streams.application-id"][0]
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+	state.End = time.Now().Add(time.Millisecond * time.Duration(duration))
+	state.MaxLag = extutil.ToInt64(request.Config["maxLag"])
+	state.FailEarly = true
+	if request.Config["failEarly"] != nil {
+		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
+	}
+
+	return nil, nil
+}
+
+func (m *StreamsRestoreCheckAction) Start(ctx context.Context, state *StreamsRestoreCheckState) (*action_kit_api.StartResult, error) {
+	statusResult, err := StreamsRestoreCheckStatus(ctx, state)
+	if statusResult == nil {
+		return nil, err
+	}
+	return &action_kit_api.StartResult{
+		Error:    statusResult.Error,
+		Messages: statusResult.Messages,
+		Metrics:  statusResult.Metrics,
+	}, err
+}
+
+func (m *StreamsRestoreCheckAction) Status(ctx context.Context, state *StreamsRestoreCheckState) (*action_kit_api.StatusResult, error) {
+	return StreamsRestoreCheckStatus(ctx, state)
+}
+
+func StreamsRestoreCheckStatus(ctx context.Context, state *StreamsRestoreCheckState) (*action_kit_api.StatusResult, error) {
+	now := time.Now()
+
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	lags, err := client.Lag(ctx, state.ApplicationID)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve the lag of Kafka Streams application %s. Full response: %v", state.ApplicationID, err), err))
+	}
+	groupLag, ok := lags[state.ApplicationID]
+	if !ok {
+		return nil, fmt.Errorf("no lag reported for Kafka Streams application %s", state.ApplicationID)
+	}
+	if err := groupLag.Error(); err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Error when fetching or describing Kafka Streams application %s: %s", state.ApplicationID, err.Error()), err))
+	}
+
+	topicLags := summarizeTopicLags(groupLag.Lag, nil)
+
+	completed := now.After(state.End)
+	var checkError *action_kit_api.ActionKitError
+	if deviation := evaluateStreamsLag(state, topicLags); deviation != "" {
+		if state.FailEarly {
+			checkError = &action_kit_api.ActionKitError{
+				Title:  deviation,
+				Status: extutil.Ptr(action_kit_api.Failed),
+			}
+		} else {
+			state.DeviationSeen = true
+			if state.DeviationTitle == "" {
+				state.DeviationTitle = deviation
+			}
+		}
+	}
+	if !state.FailEarly && completed && state.DeviationSeen {
+		checkError = &action_kit_api.ActionKitError{
+			Title:  state.DeviationTitle,
+			Status: extutil.Ptr(action_kit_api.Failed),
+		}
+	}
+
+	return &action_kit_api.StatusResult{
+		Completed: completed,
+		Error:     checkError,
+		Metrics:   new(toStreamsLagMetrics(state.ApplicationID, topicLags, now)),
+	}, nil
+}
+
+// evaluateStreamsLag returns a deviation if the total lag of the application exceeds the threshold.
+func evaluateStreamsLag(state *StreamsRestoreCheckState, topicLags []topicLag) string {
+	var total int64
+	var worst topicLag
+	for _, lag := range topicLags {
+		total += lag.Total
+		if lag.Total > worst.Total {
+			worst = lag
+		}
+	}
+	if total <= state.MaxLag {
+		return ""
+	}
+	return fmt.Sprintf("Kafka Streams application %s lags %d records behind, at most %d allowed. Most lag on %s topic %s: %d.",
+		state.ApplicationID, total, state.MaxLag, streamsTopicKind(state.ApplicationID, worst.Topic), worst.Topic, worst.Total)
+}
+
+func streamsTopicKind(applicationID string, topic string) string {
+	switch {
+	case !strings.HasPrefix(topic, applicationID+"-"):
+		return "input"
+	case isStreamsChangelogTopic(topic):
+		return "changelog"
+	case isStreamsInternalTopic(topic):
+		return "repartition"
+	default:
+		return "input"
+	}
+}
+
+func toStreamsLagMetrics(applicationID string, topicLags []topicLag, now time.Time) []action_kit_api.Metric {
+	metrics := make([]action_kit_api.Metric, 0, len(topicLags))
+	for _, lag := range topicLags {
+		metrics = append(metrics, action_kit_api.Metric{
+			Name: new("kafka_streams_lag"),
+			Metric: map[string]string{
+				"id":         applicationID + "-" + lag.Topic,
+				"topic_kind": streamsTopicKind(applicationID, lag.Topic),
+			},
+			Timestamp: now,
+			Value:     float64(lag.Total),
+		})
+	}
+	return metrics
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStreamsRestore_Describe(t *testing.T) {
+	desc := (&StreamsRestoreCheckAction{}).Describe()
+
+	assert.Equal(t, "Check Streams Restore Lag", desc.Label)
+	assert.Equal(t, kafkaConsumerTargetId+".check-streams-restore", desc.Id)
+	assert.Equal(t, kafkaConsumerTargetId, desc.TargetSelection.TargetType)
+	assert.Equal(t, "Kafka", *desc.Technology)
+}
+
+func TestCheckStreamsRestore_PrepareRequiresStreamsApplication(t *testing.T) {
+	//Given
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {SeedBrokers: "localhost:9092"},
+	})
+	action := StreamsRestoreCheckAction{}
+	state := action.NewEmptyState()
+	request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"kafka.consumer-group.name": {"orders-consumer"},
+				"kafka.cluster.name":        {"test-cluster"},
+			},
+		},
+		Config:      map[string]any{"duration": 60000, "maxLag": 100},
+		ExecutionId: uuid.New(),
+	})
+
+	//When
+	_, err := action.Prepare(t.Context(), &state, request)
+
+	//Then
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "isn't a Kafka Streams application")
+}
+
+func TestEvaluateStreamsLag(t *testing.T) {
+	state := &StreamsRestoreCheckState{ApplicationID: "order-enricher", MaxLag: 100}
+	lags := []topicLag{
+		{Topic: "orders", Total: 30},
+		{Topic: "order-enricher-KSTREAM-KEY-SELECT-0000000001-repartition", Total: 90},
+	}
+
+	assert.Equal(t, "Kafka Streams application order-enricher lags 120 records behind, at most 100 allowed. Most lag on repartition topic order-enricher-KSTREAM-KEY-SELECT-0000000001-repartition: 90.", evaluateStreamsLag(state, lags))
+	assert.Empty(t, evaluateStreamsLag(state, lags[:1]))
+
+	metrics := toStreamsLagMetrics("order-enricher", lags, time.Now())
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "input", metrics[0].Metric["topic_kind"])
+	assert.Equal(t, "repartition", metrics[1].Metric["topic_kind"])
+	assert.Equal(t, "changelog", streamsTopicKind("order-enricher", "order-enricher-store-changelog"))
+}
@@ -6,6 +6,7 @@ package extkafka
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -18,20 +19,37 @@ import (
 	"github.com/steadybit/extension-kit/extbuild"
 	"github.com/steadybit/extension-kit/extutil"
 	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
 )
 
 type ConsumerGroupLagCheckAction struct{}
 
 type ConsumerGroupLagCheckState struct {
 	ConsumerGroupName string
-	Topic             string
+	Topics            []string // all topics the group has committed offsets for, if empty
 	End               time.Time
 	AcceptableLag     int64
+	MaxPartitionLag   int64         // 0 if disabled
+	MaxTimeLag        time.Duration // 0 if disabled
 	StateCheckSuccess bool
 	StateCheckFailed  bool
 	FailEarly         bool
-	BrokerHosts       []string
-	ClusterName       string // Cluster name for multi-cluster support
+	// DeviationTitle remembers the first threshold breach, reported once the step ends in 'fail at end' mode.
+	DeviationTitle string
+	BrokerHosts    []string
+	ClusterName    string // Cluster name for multi-cluster support
+}
+
+// topicLag is the lag of a consumer group on a single topic.
+type topicLag struct {
+	Topic            string
+	Total            int64
+	MaxPartition     int32
+	MaxPartitionLag  int64
+	MaxTimePartition int32
+	MaxTimeLag       time.Duration // age of the oldest record at a committed offset, 0 if not fetched
+	// lagging partitions whose record at the committed offset couldn't be read, so their time lag is unknown
+	UnknownTimeLagPartitions []int32
 }
 
 // Make sure action implements all required interfaces
@@ -52,7 +70,7 @@ func (m *ConsumerGroupLagCheckAction) Describe() action_kit_api.ActionDescriptio
 	return action_kit_api.ActionDescription{
 		Id:          fmt.Sprintf("%s.check-lag", kafkaConsumerTargetId),
 		Label:       "Check Topic Lag",
-		Description: "Fail the experiment if consumer lag exceeds a threshold for a specific topic. Lag is the offset difference between the topic's latest offset and the consumer group's committed offset. For consumer group state monitoring (Stable, Dead, etc.), use Check Consumer State instead.",
+		Description: "Fail the experiment if consumer lag exceeds a threshold for the selected topics or all topics of the group. Lag is the offset difference between the topic's latest offset and the consumer group's committed offset, checked as total per topic, per partition and optionally as time lag, the age of the record at the committed offset. For consumer group state monitoring (Stable, Dead, etc.), use Check Consumer State instead.",
 		Version:     extbuild.GetSemverVersionStringOrUnknown(),
 		Icon:        new(kafkaIcon),
 		TargetSelection: new(action_kit_api.TargetSelection{
@@ -80,11 +98,25 @@ func (m *ConsumerGroupLagCheckAction) Describe() action_kit_api.ActionDescriptio
 				Required:     new(true),
 			},
 			{
-				Name:        "topic",
-				Label:       "Topic to track lag",
-				Description: new("The name of the Kafka topic to monitor lag for. The topic's latest offset will be compared against the consumer group's committed offset."),
-				Type:        action_kit_api.ActionParameterTypeString,
-				Required:    new(true),
+				Name:               "topic",
+				Label:              "Topic to track lag",
+				Description:        new("The name of the Kafka topic to monitor lag for. The topic's latest offset will be compared against the consumer group's committed offset."),
+				Type:               action_kit_api.ActionParameterTypeString,
+				Required:           new(false),
+				Deprecated:         new(true),
+				DeprecationMessage: new("Use 'Topics to track lag' instead, which supports multiple topics."),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ParameterOptionsFromTargetAttribute{
+						Attribute: "kafka.consumer-group.topics",
+					},
+				}),
+			},
+			{
+				Name:        "topics",
+				Label:       "Topics to track lag",
+				Description: new("The Kafka topics to monitor lag for. If left empty, all topics the consumer group has committed offsets for are monitored."),
+				Type:        action_kit_api.ActionParameterTypeStringArray,
+				Required:    new(false),
 				Options: new([]action_kit_api.ParameterOption{
 					action_kit_api.ParameterOptionsFromTargetAttribute{
 						Attribute: "kafka.consumer-group.topics",
@@ -94,11 +126,25 @@ func (m *ConsumerGroupLagCheckAction) Describe() action_kit_api.ActionDescriptio
 			{
 				Name:         "acceptableLag",
 				Label:        "Lag alert threshold",
-				Description:  new("Maximum acceptable offset difference between the topic's latest offset and the consumer group's committed offset. If lag exceeds this value, the experiment fails. Specified as a number of messages."),
+				Description:  new("Maximum acceptable offset difference between a topic's latest offset and the consumer group's committed offset, summed over all partitions of the topic. If lag exceeds this value, the experiment fails. Specified as a number of messages."),
 				Type:         action_kit_api.ActionParameterTypeInteger,
 				Required:     new(true),
 				DefaultValue: new("10"),
 			},
+			{
+				Name:        "maxPartitionLag",
+				Label:       "Partition lag alert threshold",
+				Description: new("Optional. Maximum acceptable lag of a single partition, which catches a single stuck partition hidden in the total. Specified as a number of messages, 0 disables the threshold."),
+				Type:        action_kit_api.ActionParameterTypeInteger,
+				Required:    new(false),
+			},
+			{
+				Name:        "maxTimeLag",
+				Label:       "Time lag alert threshold",
+				Description: new("Optional. Maximum acceptable age of the record at the committed offset of a partition, i.e. how long ago the oldest unconsumed record was produced. Requires reading that record on every poll, partitions whose record can't be read are reported as deviation. Leave empty to disable."),
+				Type:        action_kit_api.ActionParameterTypeDuration,
+				Required:    new(false),
+			},
 			{
 				Name:         "failEarly",
 				Label:        "Fail early",
@@ -150,6 +196,32 @@ func (m *ConsumerGroupLagCheckAction) Describe() action_kit_api.ActionDescriptio
 							From:  "topic",
 							Title: "Topic",
 						},
+						{
+							From:  "max_partition_lag",
+							Title: "Max Partition Lag",
+						},
+					},
+				}),
+			},
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Consumer Group Time Lag",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_consumer_group_time_lag",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Time Lag (s)"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "consumer",
+							Title: "Consumer",
+						},
+						{
+							From:  "partition",
+							Title: "Oldest Partition",
+						},
 					},
 				}),
 			},
@@ -164,8 +236,18 @@ func (m *ConsumerGroupLagCheckAction) Prepare(_ context.Context, state *Consumer
 	if len(request.Target.Attributes["kafka.consumer-group.name"]) == 0 {
 		return nil, fmt.Errorf("the target is missing the kafka.consumer-group.name attribute")
 	}
-	state.Topic = extutil.ToString(request.Config["topic"])
+	state.Topics = nil
+	for _, topic := range append(extutil.ToStringArray(request.Config["topics"]), extutil.ToString(request.Config["topic"])) {
+		if topic = strings.TrimSpace(topic); topic != "" && !slices.Contains(state.Topics, topic) {
+			state.Topics = append(state.Topics, topic)
+		}
+	}
 	state.AcceptableLag = extutil.ToInt64(request.Config["acceptableLag"])
+	state.MaxPartitionLag = extutil.ToInt64(request.Config["maxPartitionLag"])
+	state.MaxTimeLag = time.Duration(extutil.ToInt64(request.Config["maxTimeLag"])) * time.Millisecond
+	if state.MaxPartitionLag < 0 || state.MaxTimeLag < 0 {
+		return nil, fmt.Errorf("the lag thresholds must not be negative")
+	}
 	state.StateCheckFailed = false
 	// Default to failing at the end to preserve the previous behavior for experiments that don't set
 	// this parameter (this check historically only reported a lag breach once the step ended).
@@ -223,58 +305,53 @@ func ConsumerGroupLagCheckStatus(ctx context.Context, state *ConsumerGroupLagChe
 	}
 	defer client.Close()
 
-	lags, err := client.Lag(ctx, state.ConsumerGroupName)
+	groupLag, err := describeGroupLag(ctx, client, state.ConsumerGroupName)
 	if err != nil {
-		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve consumer groups from Kafka for name %s. Full response: %v", state.ConsumerGroupName, err), err))
-	}
-
-	var groupLag kadm.DescribedGroupLag
-	if len(lags.Sorted()) == 0 {
-		log.Err(err).Msgf("No lags for consumer group with that name %s.", state.ConsumerGroupName)
-	} else if len(lags.Sorted()) > 1 {
-		log.Err(err).Msgf("More than 1 lag description for consumer group with that name %s.", state.ConsumerGroupName)
-	} else {
-		groupLag = lags.Sorted()[0]
-	}
-
-	if groupLag.FetchErr != nil {
-		return nil, new(extension_kit.ToError(fmt.Sprintf("Error when fetching or describing the consumer group %s: %s", state.ConsumerGroupName, groupLag.FetchErr.Error()), groupLag.FetchErr))
+		return nil, err
 	}
-	if groupLag.DescribeErr != nil {
-		return nil, new(extension_kit.ToError(fmt.Sprintf("Error when fetching or describing the consumer group %s: %s", state.ConsumerGroupName, groupLag.DescribeErr.Error()), groupLag.DescribeErr))
-
+	topicLags := summarizeTopicLags(groupLag, state.Topics)
+	if state.MaxTimeLag > 0 {
+		ages, err := fetchCommittedRecordAges(ctx, state.BrokerHosts, clusterConfig, groupLag, state.Topics, now)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to fetch the time lag of consumer group %s", state.ConsumerGroupName)
+		}
+		addTimeLags(topicLags, groupLag, ages)
 	}
-	topicLag := groupLag.Lag.TotalByTopic()[state.Topic].Lag
 
 	completed := now.After(state.End)
 	var checkError *action_kit_api.ActionKitError
-	breaching := topicLag >= state.AcceptableLag
-	if breaching {
+	breaches := findLagBreaches(state, topicLags)
+	if len(breaches) > 0 {
 		state.StateCheckFailed = true
+		if state.DeviationTitle == "" {
+			state.DeviationTitle = breaches[0]
+		}
 	} else {
 		state.StateCheckSuccess = true
 	}
 	if state.FailEarly {
 		// Fail while the lag is currently over the threshold (present tense), evaluated per poll so the
 		// check recovers when the lag drops back - consistent with the other fail-early checks.
-		if breaching {
+		if len(breaches) > 0 {
 			checkError = new(action_kit_api.ActionKitError{
-				Title: fmt.Sprintf("Consumer Group Lag is higher than the acceptable threshold '%d'.",
-					state.AcceptableLag),
+				Title:  fmt.Sprintf("Consumer Group Lag is higher than the acceptable threshold: %s.", breaches[0]),
 				Status: extutil.Ptr(action_kit_api.Failed),
 			})
 		}
 	} else if completed && state.StateCheckFailed {
 		// Otherwise only report a breach once the step ends (past tense - it may have recovered).
 		checkError = new(action_kit_api.ActionKitError{
-			Title: fmt.Sprintf("Consumer Group Lag was higher at least once than acceptable threshold  '%d'.",
-				state.AcceptableLag),
+			Title:  fmt.Sprintf("Consumer Group Lag was higher at least once than the acceptable threshold: %s.", state.DeviationTitle),
 			Status: extutil.Ptr(action_kit_api.Failed),
 		})
 	}
 
-	metrics := []action_kit_api.Metric{
-		*toMetric(topicLag, state, now),
+	metrics := make([]action_kit_api.Metric, 0, len(topicLags))
+	for _, lag := range topicLags {
+		metrics = append(metrics, *toMetric(lag, state, now))
+		if state.MaxTimeLag > 0 {
+			metrics = append(metrics, *toTimeLagMetric(lag, state, now))
+		}
 	}
 
 	return &action_kit_api.StatusResult{
@@ -284,16 +361,187 @@ func ConsumerGroupLagCheckStatus(ctx context.Context, state *ConsumerGroupLagChe
 	}, nil
 }
 
-func toMetric(topicLag int64, stateGroupLag *ConsumerGroupLagCheckState, now time.Time) *action_kit_api.Metric {
+// describeGroupLag returns the per partition lag of the consumer group. A group without any lag description is
+// reported with an empty lag.
+func describeGroupLag(ctx context.Context, client *kadm.Client, groupName string) (kadm.GroupLag, error) {
+	lags, err := client.Lag(ctx, groupName)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve consumer groups from Kafka for name %s. Full response: %v", groupName, err), err))
+	}
+
+	var groupLag kadm.DescribedGroupLag
+	if len(lags.Sorted()) == 0 {
+		log.Err(err).Msgf("No lags for consumer group with that name %s.", groupName)
+	} else if len(lags.Sorted()) > 1 {
+		log.Err(err).Msgf("More than 1 lag description for consumer group with that name %s.", groupName)
+	} else {
+		groupLag = lags.Sorted()[0]
+	}
+
+	if groupLag.FetchErr != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Error when fetching or describing the consumer group %s: %s", groupName, groupLag.FetchErr.Error()), groupLag.FetchErr))
+	}
+	if groupLag.DescribeErr != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Error when fetching or describing the consumer group %s: %s", groupName, groupLag.DescribeErr.Error()), groupLag.DescribeErr))
+	}
+	return groupLag.Lag, nil
+}
+
+// summarizeTopicLags aggregates the lag of the given topics, or all topics of the group if none are given. Topics
+// without any lag information are reported with a lag of 0.
+func summarizeTopicLags(groupLag kadm.GroupLag, topics []string) []topicLag {
+	if len(topics) == 0 {
+		for topic := range groupLag {
+			topics = append(topics, topic)
+		}
+		slices.Sort(topics)
+	}
+
+	result := make([]topicLag, 0, len(topics))
+	for _, topic := range topics {
+		lag := topicLag{Topic: topic, MaxPartition: -1, MaxTimePartition: -1}
+		for partition, memberLag := range groupLag[topic] {
+			if memberLag.Lag < 0 {
+				continue
+			}
+			lag.Total += memberLag.Lag
+			if lag.MaxPartition < 0 || memberLag.Lag > lag.MaxPartitionLag || (memberLag.Lag == lag.MaxPartitionLag && partition < lag.MaxPartition) {
+				lag.MaxPartitionLag = memberLag.Lag
+				lag.MaxPartition = partition
+			}
+		}
+		result = append(result, lag)
+	}
+	return result
+}
+
+// addTimeLags adds the fetched ages to the topic lags. Lagging partitions without an age have an unknown time lag,
+// which must not pass as no time lag.
+func addTimeLags(topicLags []topicLag, groupLag kadm.GroupLag, ages map[string]map[int32]time.Duration) {
+	for i := range topicLags {
+		for partition, age := range ages[topicLags[i].Topic] {
+			if age > topicLags[i].MaxTimeLag {
+				topicLags[i].MaxTimeLag = age
+				topicLags[i].MaxTimePartition = partition
+			}
+		}
+		for partition, memberLag := range groupLag[topicLags[i].Topic] {
+			if _, ok := ages[topicLags[i].Topic][partition]; !ok && hasCommittedRecord(memberLag) {
+				topicLags[i].UnknownTimeLagPartitions = append(topicLags[i].UnknownTimeLagPartitions, partition)
+			}
+		}
+		slices.Sort(topicLags[i].UnknownTimeLagPartitions)
+	}
+}
+
+// hasCommittedRecord returns whether a record is waiting at the committed offset, whose age is the time lag.
+func hasCommittedRecord(memberLag kadm.GroupMemberLag) bool {
+	return memberLag.Lag > 0 && memberLag.Commit.At >= 0
+}
+
+// findLagBreaches describes every threshold exceeded by the topic lags.
+func findLagBreaches(state *ConsumerGroupLagCheckState, topicLags []topicLag) []string {
+	var breaches []string
+	for _, lag := range topicLags {
+		if lag.Total >= state.AcceptableLag {
+			breaches = append(breaches, fmt.Sprintf("lag of topic %s is %d, threshold %d", lag.Topic, lag.Total, state.AcceptableLag))
+		}
+		if state.MaxPartitionLag > 0 && lag.MaxPartitionLag > state.MaxPartitionLag {
+			breaches = append(breaches, fmt.Sprintf("lag of partition %s-%d is %d, threshold %d", lag.Topic, lag.MaxPartition, lag.MaxPartitionLag, state.MaxPartitionLag))
+		}
+		if state.MaxTimeLag > 0 && lag.MaxTimeLag > state.MaxTimeLag {
+			breaches = append(breaches, fmt.Sprintf("time lag of partition %s-%d is %s, threshold %s", lag.Topic, lag.MaxTimePartition, lag.MaxTimeLag.Round(time.Second), state.MaxTimeLag))
+		}
+		if state.MaxTimeLag > 0 && len(lag.UnknownTimeLagPartitions) > 0 {
+			breaches = append(breaches, fmt.Sprintf("time lag of topic %s partitions %s is unknown, the records at the committed offsets couldn't be read", lag.Topic, joinInt32s(lag.UnknownTimeLagPartitions)))
+		}
+	}
+	return breaches
+}
+
+// fetchCommittedRecordAges reads the record at the committed offset of every lagging partition and returns how long
+// ago it was produced. Partitions whose record can't be read in time are left out, see addTimeLags.
+func fetchCommittedRecordAges(ctx context.Context, brokers []string, clusterConfig *config.ClusterConfig, groupLag kadm.GroupLag, topics []string, now time.Time) (map[string]map[int32]time.Duration, error) {
+	offsets := make(map[string]map[int32]kgo.Offset)
+	for _, memberLag := range groupLag.Sorted() {
+		if !hasCommittedRecord(memberLag) || (len(topics) > 0 && !slices.Contains(topics, memberLag.Topic)) {
+			continue
+		}
+		if offsets[memberLag.Topic] == nil {
+			offsets[memberLag.Topic] = make(map[int32]kgo.Offset)
+		}
+		offsets[memberLag.Topic][memberLag.Partition] = kgo.NewOffset().At(memberLag.Commit.At)
+	}
+	ages := make(map[string]map[int32]time.Duration)
+	if len(offsets) == 0 {
+		return ages, nil
+	}
+
+	client, err := createNewClientWithConfig(brokers, clusterConfig, kgo.ConsumePartitions(offsets))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	pollCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	remaining := 0
+	for _, partitions := range offsets {
+		remaining += len(partitions)
+	}
+	for remaining > 0 {
+		fetches := client.PollFetches(pollCtx)
+		if pollCtx.Err() != nil {
+			log.Debug().Msgf("Stopped fetching the time lag after timeout, %d partition(s) missing", remaining)
+			break
+		}
+		if errs := fetches.Errors(); len(errs) > 0 {
+			return ages, fmt.Errorf("failed to fetch topic %s partition %d: %w", errs[0].Topic, errs[0].Partition, errs[0].Err)
+		}
+		fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+			if len(p.Records) == 0 || ages[p.Topic][p.Partition] != 0 {
+				return
+			}
+			if ages[p.Topic] == nil {
+				ages[p.Topic] = make(map[int32]time.Duration)
+			}
+			// the first record is the oldest not consumed one, the committed offset may point to a removed record
+			ages[p.Topic][p.Partition] = max(now.Sub(p.Records[0].Timestamp), time.Millisecond)
+			remaining--
+			client.PauseFetchPartitions(map[string][]int32{p.Topic: {p.Partition}})
+		})
+	}
+	return ages, nil
+}
+
+func toMetric(lag topicLag, stateGroupLag *ConsumerGroupLagCheckState, now time.Time) *action_kit_api.Metric {
+	fulfilled := lag.Total < stateGroupLag.AcceptableLag &&
+		(stateGroupLag.MaxPartitionLag <= 0 || lag.MaxPartitionLag <= stateGroupLag.MaxPartitionLag)
 	return new(action_kit_api.Metric{
 		Name: new("kafka_consumer_group_lag"),
 		Metric: map[string]string{
-			"lag_constraints_fulfilled": strconv.FormatBool(topicLag < stateGroupLag.AcceptableLag),
+			"lag_constraints_fulfilled": strconv.FormatBool(fulfilled),
 			"consumer":                  stateGroupLag.ConsumerGroupName,
-			"topic":                     stateGroupLag.Topic,
-			"id":                        stateGroupLag.ConsumerGroupName + "-" + stateGroupLag.Topic,
+			"topic":                     lag.Topic,
+			"max_partition_lag":         fmt.Sprintf("%d (partition %d)", lag.MaxPartitionLag, lag.MaxPartition),
+			"id":                        stateGroupLag.ConsumerGroupName + "-" + lag.Topic,
+		},
+		Timestamp: now,
+		Value:     float64(lag.Total),
+	})
+}
+
+func toTimeLagMetric(lag topicLag, stateGroupLag *ConsumerGroupLagCheckState, now time.Time) *action_kit_api.Metric {
+	return new(action_kit_api.Metric{
+		Name: new("kafka_consumer_group_time_lag"),
+		Metric: map[string]string{
+			"consumer":  stateGroupLag.ConsumerGroupName,
+			"topic":     lag.Topic,
+			"partition": strconv.Itoa(int(lag.MaxTimePartition)),
+			"id":        stateGroupLag.ConsumerGroupName + "-" + lag.Topic,
 		},
 		Timestamp: now,
-		Value:     float64(topicLag),
+		Value:     lag.MaxTimeLag.Seconds(),
 	})
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/steadybit/extension-kit/extutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
 	"github.com/twmb/franz-go/pkg/kfake"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
@@ -29,7 +30,7 @@ func TestCheckTopicLag_Describe(t *testing.T) {
 	response := action.Describe()
 
 	//Then
-	assert.Equal(t, "Fail the experiment if consumer lag exceeds a threshold for a specific topic. Lag is the offset difference between the topic's latest offset and the consumer group's committed offset. For consumer group state monitoring (Stable, Dead, etc.), use Check Consumer State instead.", response.Description)
+	assert.Equal(t, "Fail the experiment if consumer lag exceeds a threshold for the selected topics or all topics of the group. Lag is the offset difference between the topic's latest offset and the consumer group's committed offset, checked as total per topic, per partition and optionally as time lag, the age of the record at the committed offset. For consumer group state monitoring (Stable, Dead, etc.), use Check Consumer State instead.", response.Description)
 	assert.Equal(t, "Check Topic Lag", response.Label)
 	assert.Equal(t, kafkaConsumerTargetId, response.TargetSelection.TargetType)
 	assert.Equal(t, fmt.Sprintf("%s.check-lag", kafkaConsumerTargetId), response.Id)
@@ -69,7 +70,7 @@ func TestCheckConsumerGroupLag_Prepare(t *testing.T) {
 			wantedState: &ConsumerGroupLagCheckState{
 				ConsumerGroupName: "steadybit",
 				StateCheckSuccess: true,
-				Topic:             "steadybit",
+				Topics:            []string{"steadybit"},
 				AcceptableLag:     1,
 			},
 		},
@@ -107,7 +108,7 @@ func TestCheckConsumerGroupLag_Prepare(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.wantedState.AcceptableLag, state.AcceptableLag)
 				assert.Equal(t, state.ConsumerGroupName, state.ConsumerGroupName)
-				assert.Equal(t, state.Topic, state.Topic)
+				assert.Equal(t, tt.wantedState.Topics, state.Topics)
 				assert.False(t, state.StateCheckSuccess)
 				assert.False(t, state.FailEarly) // defaults to false for this check (preserves fail-at-end behavior)
 				assert.NotNil(t, state.End)
@@ -175,7 +176,7 @@ func TestCheckConsumerGroupLag_Status(t *testing.T) {
 				ConsumerGroupName: "steadybit",
 				AcceptableLag:     int64(15),
 				StateCheckSuccess: true,
-				Topic:             "steadybit",
+				Topics:            []string{"steadybit"},
 			},
 		},
 		{
@@ -198,7 +199,7 @@ func TestCheckConsumerGroupLag_Status(t *testing.T) {
 				ConsumerGroupName: "steadybit",
 				AcceptableLag:     int64(1),
 				StateCheckSuccess: true,
-				Topic:             "steadybit",
+				Topics:            []string{"steadybit"},
 			},
 		},
 	}
@@ -218,7 +219,7 @@ func TestCheckConsumerGroupLag_Status(t *testing.T) {
 				assert.NoError(t, errPrepare)
 				assert.NoError(t, errStatus)
 				assert.Equal(t, tt.wantedState.AcceptableLag, state.AcceptableLag)
-				assert.Equal(t, tt.wantedState.Topic, state.Topic)
+				assert.Equal(t, tt.wantedState.Topics, state.Topics)
 				assert.Equal(t, tt.wantedState.ConsumerGroupName, state.ConsumerGroupName)
 				assert.False(t, statusResult.Completed)
 				assert.NotNil(t, state.End)
@@ -234,10 +235,139 @@ func TestCheckConsumerGroupLag_Status(t *testing.T) {
 				assert.NoError(t, errPrepare)
 				assert.NoError(t, errStatus)
 				assert.Equal(t, tt.wantedState.AcceptableLag, state.AcceptableLag)
-				assert.Equal(t, tt.wantedState.Topic, state.Topic)
+				assert.Equal(t, tt.wantedState.Topics, state.Topics)
 				assert.Equal(t, tt.wantedState.ConsumerGroupName, state.ConsumerGroupName)
 				assert.NotNil(t, state.End)
 			}
 		})
 	}
 }
+
+func TestCheckConsumerGroupLag_PrepareTopics(t *testing.T) {
+	//Given
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {SeedBrokers: "localhost:9092"},
+	})
+	action := ConsumerGroupLagCheckAction{}
+	state := ConsumerGroupLagCheckState{}
+	request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"kafka.consumer-group.name": {"steadybit"},
+				"kafka.cluster.name":        {"test-cluster"},
+			},
+		},
+		Config: map[string]any{
+			"duration":        10000,
+			"topic":           "orders",
+			"topics":          []string{"payments", "orders"},
+			"acceptableLag":   "100",
+			"maxPartitionLag": "20",
+			"maxTimeLag":      30000,
+		},
+		ExecutionId: uuid.New(),
+	})
+
+	//When
+	_, err := action.Prepare(t.Context(), &state, request)
+
+	//Then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments", "orders"}, state.Topics)
+	assert.Equal(t, int64(20), state.MaxPartitionLag)
+	assert.Equal(t, 30*time.Second, state.MaxTimeLag)
+}
+
+func TestSummarizeTopicLags(t *testing.T) {
+	//Given
+	groupLag := kadm.GroupLag{
+		"orders": {
+			0: {Topic: "orders", Partition: 0, Lag: 5},
+			1: {Topic: "orders", Partition: 1, Lag: 40},
+			2: {Topic: "orders", Partition: 2, Lag: -1},
+		},
+		"payments": {
+			0: {Topic: "payments", Partition: 0, Lag: 0},
+		},
+	}
+
+	//When
+	all := summarizeTopicLags(groupLag, nil)
+	selected := summarizeTopicLags(groupLag, []string{"orders", "unknown"})
+
+	//Then
+	assert.Equal(t, []topicLag{
+		{Topic: "orders", Total: 45, MaxPartition: 1, MaxPartitionLag: 40, MaxTimePartition: -1},
+		{Topic: "payments", Total: 0, MaxPartition: 0, MaxPartitionLag: 0, MaxTimePartition: -1},
+	}, all)
+	require.Len(t, selected, 2)
+	assert.Equal(t, "orders", selected[0].Topic)
+	assert.Equal(t, topicLag{Topic: "unknown", MaxPartition: -1, MaxTimePartition: -1}, selected[1])
+}
+
+func TestFindLagBreaches(t *testing.T) {
+	//Given
+	state := &ConsumerGroupLagCheckState{AcceptableLag: 100, MaxPartitionLag: 30, MaxTimeLag: time.Minute}
+	lags := []topicLag{
+		{Topic: "orders", Total: 45, MaxPartition: 1, MaxPartitionLag: 40},
+		{Topic: "payments", Total: 150, MaxPartition: 0, MaxPartitionLag: 25},
+	}
+	groupLag := kadm.GroupLag{
+		"orders": {
+			0: {Topic: "orders", Partition: 0, Lag: 5},
+			1: {Topic: "orders", Partition: 1, Lag: 40},
+		},
+		"payments": {
+			0: {Topic: "payments", Partition: 0, Lag: 100},
+			1: {Topic: "payments", Partition: 1, Lag: 50},
+		},
+	}
+	addTimeLags(lags, groupLag, map[string]map[int32]time.Duration{
+		"orders":   {0: 5 * time.Second},
+		"payments": {0: 90 * time.Second, 1: 10 * time.Second},
+	})
+
+	//When
+	breaches := findLagBreaches(state, lags)
+
+	//Then
+	assert.Equal(t, []string{
+		"lag of partition orders-1 is 40, threshold 30",
+		"time lag of topic orders partitions 1 is unknown, the records at the committed offsets couldn't be read",
+		"lag of topic payments is 150, threshold 100",
+		"time lag of partition payments-0 is 1m30s, threshold 1m0s",
+	}, breaches)
+}
+
+func TestFetchCommittedRecordAges(t *testing.T) {
+	c, err := kfake.NewCluster(
+		kfake.SeedTopics(1, "steadybit"),
+		kfake.NumBrokers(1),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+	brokers := c.ListenAddrs()
+	clusterConfig := &config.ClusterConfig{SeedBrokers: strings.Join(brokers, ",")}
+
+	//Given
+	now := time.Now().Truncate(time.Millisecond)
+	client, err := kgo.NewClient(kgo.SeedBrokers(brokers...))
+	require.NoError(t, err)
+	defer client.Close()
+	for _, age := range []time.Duration{time.Minute, 30 * time.Second, 10 * time.Second} {
+		require.NoError(t, client.ProduceSync(t.Context(), &kgo.Record{Topic: "steadybit", Value: []byte("value"), Timestamp: now.Add(-age)}).FirstErr())
+	}
+	adminClient := kadm.NewClient(client)
+	offsets := kadm.Offsets{}
+	offsets.Add(kadm.Offset{Topic: "steadybit", Partition: 0, At: 1, LeaderEpoch: -1})
+	require.NoError(t, adminClient.CommitAllOffsets(t.Context(), "steadybit-group", offsets))
+	groupLag, err := describeGroupLag(t.Context(), adminClient, "steadybit-group")
+	require.NoError(t, err)
+
+	//When
+	ages, err := fetchCommittedRecordAges(t.Context(), brokers, clusterConfig, groupLag, nil, now)
+
+	//Then
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[int32]time.Duration{"steadybit": {0: 30 * time.Second}}, ages)
+}
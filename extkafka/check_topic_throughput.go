@@ -0,0 +1,370 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+type TopicThroughputCheckAction struct{}
+
+type TopicThroughputCheckState struct {
+	Topic               string
+	End                 time.Time
+	MinRecordsPerSecond float64
+	Window              time.Duration // the throughput is averaged over this window, zero to use every single sample
+	MaxStalled          time.Duration // zero to not check for stalled partitions
+	FailEarly           bool
+	DeviationSeen       bool
+	DeviationTitle      string
+	// SampledAt and EndOffsets are the end offsets per partition of the previous status call.
+	SampledAt  time.Time
+	EndOffsets map[int32]int64
+	// AdvancedAt is the time the end offset of a partition last moved, or the first sample if it didn't yet.
+	AdvancedAt map[int32]time.Time
+	// Samples are the records produced between two status calls, as long as they are within the window.
+	Samples     []throughputSample
+	BrokerHosts []string
+	ClusterName string
+}
+
+// throughputSample is the number of records produced to the topic during Elapsed, ending at SampledAt.
+type throughputSample struct {
+	SampledAt time.Time
+	Elapsed   time.Duration
+	Produced  int64
+}
+
+// topicThroughput is the produce rate in records per second. Partitions are the rates since the previous sample,
+// Total is the rate of the whole topic averaged over the window.
+type topicThroughput struct {
+	Total      float64
+	Partitions map[int32]float64
+	// WindowFilled is false until the samples cover the whole window, before the total isn't evaluated.
+	WindowFilled bool
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[TopicThroughputCheckState]           = (*TopicThroughputCheckAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[TopicThroughputCheckState] = (*TopicThroughputCheckAction)(nil)
+)
+
+func NewTopicThroughputCheckAction() action_kit_sdk.Action[TopicThroughputCheckState] {
+	return &TopicThroughputCheckAction{}
+}
+
+func (m *TopicThroughputCheckAction) NewEmptyState() TopicThroughputCheckState {
+	return TopicThroughputCheckState{}
+}
+
+func (m *TopicThroughputCheckAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.check-throughput", kafkaTopicTargetId),
+		Label:       "Check Topic Throughput",
+		Description: "Sample the end offsets of the topic's partitions and derive the produce rate in records per second, per partition and for the whole topic. Fail if the throughput drops below a floor or a partition stops advancing for longer than a threshold. This observes the effect on the real application traffic without producing synthetic load. Transactional markers and aborted records are counted as records.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          kafkaTopicTargetId,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionExactlyOne),
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "default",
+					Description: new("Find topic by cluster and name"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.topic.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("How long the check runs. The end offsets are sampled continuously for this duration."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("60s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "minRecordsPerSecond",
+				Label:        "Min Records per Second",
+				Description:  new("The minimum throughput of the whole topic, averaged over the evaluation window. Set to 0 to not check the throughput."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1"),
+				Required:     new(true),
+			},
+			{
+				Name:         "window",
+				Label:        "Evaluation Window",
+				Description:  new("The throughput of the topic is averaged over this window before it is compared with the minimum, so bursty producers don't fail the check. It's evaluated once the check ran for the window."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("10s"),
+				Advanced:     new(true),
+				Required:     new(true),
+			},
+			{
+				Name:         "maxStalled",
+				Label:        "Max Stalled Duration",
+				Description:  new("How long a single partition may go without new records. Set to 0 to not check for stalled partitions, e.g. for topics with idle partitions."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+			},
+			{
+				Name:         "failEarly",
+				Label:        "Fail early",
+				Description:  new("If enabled, the check fails as soon as a threshold is violated. If disabled, the check keeps sampling for the whole duration and only fails at the end of the step."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Advanced:     new(true),
+				Required:     new(false),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Topic Throughput",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_topic_throughput",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Records/s"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "scope",
+							Title: "Scope",
+						},
+					},
+				}),
+			},
+		}),
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("2s"),
+		}),
+	}
+}
+
+func (m *TopicThroughputCheckAction) Prepare(_ context.Context, state *TopicThroughputCheckState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	duration := request.Config["duration"].(float64)
+
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	state.Topic = extutil.MustHaveValue(request.Target.Attributes, "kafka.topic.name")[0]
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+	state.End = time.Now().Add(time.Millisecond * time.Duration(duration))
+	state.MinRecordsPerSecond = float64(extutil.ToInt64(request.Config["minRecordsPerSecond"]))
+	state.Window = time.Duration(extutil.ToInt64(request.Config["window"])) * time.Millisecond
+	state.MaxStalled = time.Duration(extutil.ToInt64(request.Config["maxStalled"])) * time.Millisecond
+	state.FailEarly = true
+	if request.Config["failEarly"] != nil {
+		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
+	}
+
+	return nil, nil
+}
+
+func (m *TopicThroughputCheckAction) Start(ctx context.Context, state *TopicThroughputCheckState) (*action_kit_api.StartResult, error) {
+	statusResult, err := TopicThroughputCheckStatus(ctx, state)
+	if statusResult == nil {
+		return nil, err
+	}
+	return &action_kit_api.StartResult{
+		Error:    statusResult.Error,
+		Messages: statusResult.Messages,
+		Metrics:  statusResult.Metrics,
+	}, err
+}
+
+func (m *TopicThroughputCheckAction) Status(ctx context.Context, state *TopicThroughputCheckState) (*action_kit_api.StatusResult, error) {
+	return TopicThroughputCheckStatus(ctx, state)
+}
+
+func TopicThroughputCheckStatus(ctx context.Context, state *TopicThroughputCheckState) (*action_kit_api.StatusResult, error) {
+	now := time.Now()
+
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	endOffsets, err := client.ListEndOffsets(ctx, state.Topic)
+	if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to list the end offsets of topic %s. Full response: %v", state.Topic, err), err))
+	}
+	// Listing can partially succeed, partitions without a leader during an outage are counted as not advancing.
+	throughput := sampleThroughput(state, endOffsets, now)
+
+	completed := now.After(state.End)
+	var checkError *action_kit_api.ActionKitError
+	for _, deviation := range evaluateThroughput(state, throughput, now) {
+		if state.FailEarly {
+			checkError = &action_kit_api.ActionKitError{
+				Title:  deviation,
+				Status: extutil.Ptr(action_kit_api.Failed),
+			}
+			break
+		}
+		state.DeviationSeen = true
+		if state.DeviationTitle == "" {
+			state.DeviationTitle = deviation
+		}
+	}
+	if !state.FailEarly && completed && state.DeviationSeen {
+		checkError = &action_kit_api.ActionKitError{
+			Title:  state.DeviationTitle,
+			Status: extutil.Ptr(action_kit_api.Failed),
+		}
+	}
+
+	var metrics []action_kit_api.Metric
+	if throughput != nil {
+		metrics = toThroughputMetrics(state.Topic, *throughput, now)
+	}
+
+	return &action_kit_api.StatusResult{
+		Completed: completed,
+		Error:     checkError,
+		Metrics:   new(metrics),
+	}, nil
+}
+
+// sampleThroughput remembers the end offsets and returns the throughput since the previous sample, nil for the first
+// one. A partition whose end offset moved backwards, e.g. after an unclean leader election, or whose end offset
+// couldn't be listed, e.g. without a leader, is counted as not advancing.
+func sampleThroughput(state *TopicThroughputCheckState, endOffsets kadm.ListedOffsets, now time.Time) *topicThroughput {
+	if state.EndOffsets == nil {
+		state.EndOffsets = map[int32]int64{}
+		state.AdvancedAt = map[int32]time.Time{}
+	}
+	var throughput *topicThroughput
+	elapsed := now.Sub(state.SampledAt)
+	if !state.SampledAt.IsZero() && elapsed > 0 {
+		throughput = &topicThroughput{Partitions: map[int32]float64{}}
+	}
+
+	var produced int64
+	endOffsets.Each(func(offset kadm.ListedOffset) {
+		previous, seen := state.EndOffsets[offset.Partition]
+		if !seen {
+			state.AdvancedAt[offset.Partition] = now
+		}
+		if offset.Err != nil {
+			if throughput != nil {
+				throughput.Partitions[offset.Partition] = 0
+			}
+			return
+		}
+		state.EndOffsets[offset.Partition] = offset.Offset
+		if !seen {
+			return
+		}
+		partitionProduced := max(offset.Offset-previous, 0)
+		if partitionProduced > 0 {
+			state.AdvancedAt[offset.Partition] = now
+		}
+		produced += partitionProduced
+		if throughput != nil {
+			throughput.Partitions[offset.Partition] = float64(partitionProduced) / elapsed.Seconds()
+		}
+	})
+
+	if throughput != nil {
+		state.Samples = append(state.Samples, throughputSample{SampledAt: now, Elapsed: elapsed, Produced: produced})
+		throughput.Total, throughput.WindowFilled = averageThroughput(state)
+	}
+	state.SampledAt = now
+	return throughput
+}
+
+// averageThroughput drops the oldest samples not needed to cover the window and returns the average rate of the
+// remaining ones, and whether they cover the whole window.
+func averageThroughput(state *TopicThroughputCheckState) (float64, bool) {
+	var produced int64
+	var elapsed time.Duration
+	for _, sample := range state.Samples {
+		produced += sample.Produced
+		elapsed += sample.Elapsed
+	}
+	for len(state.Samples) > 1 && elapsed-state.Samples[0].Elapsed >= state.Window {
+		produced -= state.Samples[0].Produced
+		elapsed -= state.Samples[0].Elapsed
+		state.Samples = state.Samples[1:]
+	}
+	return float64(produced) / elapsed.Seconds(), elapsed >= state.Window
+}
+
+func evaluateThroughput(state *TopicThroughputCheckState, throughput *topicThroughput, now time.Time) []string {
+	var deviations []string
+	if throughput != nil && throughput.WindowFilled && throughput.Total < state.MinRecordsPerSecond {
+		deviations = append(deviations, fmt.Sprintf("Throughput of topic %s dropped to %.1f records/s over %s, at least %.0f records/s expected.",
+			state.Topic, throughput.Total, state.Window, state.MinRecordsPerSecond))
+	}
+	if state.MaxStalled > 0 {
+		var stalled []string
+		for _, partition := range slices.Sorted(maps.Keys(state.AdvancedAt)) {
+			if since := now.Sub(state.AdvancedAt[partition]); since > state.MaxStalled {
+				stalled = append(stalled, fmt.Sprintf("%d (%s)", partition, since.Round(time.Second)))
+			}
+		}
+		if len(stalled) > 0 {
+			deviations = append(deviations, fmt.Sprintf("Partition(s) %s of topic %s stopped advancing for longer than %s.",
+				strings.Join(stalled, ", "), state.Topic, state.MaxStalled))
+		}
+	}
+	return deviations
+}
+
+func toThroughputMetrics(topic string, throughput topicThroughput, now time.Time) []action_kit_api.Metric {
+	metrics := []action_kit_api.Metric{{
+		Name: new("kafka_topic_throughput"),
+		Metric: map[string]string{
+			"id":    topic,
+			"scope": "topic",
+		},
+		Timestamp: now,
+		Value:     throughput.Total,
+	}}
+	for _, partition := range slices.Sorted(maps.Keys(throughput.Partitions)) {
+		metrics = append(metrics, action_kit_api.Metric{
+			Name: new("kafka_topic_throughput"),
+			Metric: map[string]string{
+				"id":    fmt.Sprintf("%s-%d", topic, partition),
+				"scope": "partition",
+			},
+			Timestamp: now,
+			Value:     throughput.Partitions[partition],
+		})
+	}
+	return metrics
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func TestCheckTopicThroughput_Describe(t *testing.T) {
+	desc := (&TopicThroughputCheckAction{}).Describe()
+
+	assert.Equal(t, "Check Topic Throughput", desc.Label)
+	assert.Equal(t, kafkaTopicTargetId+".check-throughput", desc.Id)
+	assert.Equal(t, kafkaTopicTargetId, desc.TargetSelection.TargetType)
+	assert.Equal(t, "Kafka", *desc.Technology)
+}
+
+func endOffsetsOf(topic string, offsets ...int64) kadm.ListedOffsets {
+	listed := kadm.ListedOffsets{topic: {}}
+	for partition, offset := range offsets {
+		listed[topic][int32(partition)] = kadm.ListedOffset{Topic: topic, Partition: int32(partition), Offset: offset}
+	}
+	return listed
+}
+
+func TestSampleThroughput(t *testing.T) {
+	//Given
+	start := time.Now()
+	state := &TopicThroughputCheckState{Topic: "orders", MinRecordsPerSecond: 10, MaxStalled: 5 * time.Second}
+
+	//When
+	first := sampleThroughput(state, endOffsetsOf("orders", 100, 200), start)
+	second := sampleThroughput(state, endOffsetsOf("orders", 140, 200), start.Add(2*time.Second))
+
+	//Then
+	assert.Nil(t, first)
+	require.NotNil(t, second)
+	assert.Equal(t, 20.0, second.Total)
+	assert.Equal(t, map[int32]float64{0: 20, 1: 0}, second.Partitions)
+	assert.Empty(t, evaluateThroughput(state, second, start.Add(2*time.Second)))
+
+	metrics := toThroughputMetrics("orders", *second, start)
+	require.Len(t, metrics, 3)
+	assert.Equal(t, map[string]string{"id": "orders", "scope": "topic"}, metrics[0].Metric)
+	assert.Equal(t, map[string]string{"id": "orders-1", "scope": "partition"}, metrics[2].Metric)
+}
+
+func TestEvaluateThroughput_FloorAndStalledPartitions(t *testing.T) {
+	//Given
+	start := time.Now()
+	state := &TopicThroughputCheckState{Topic: "orders", MinRecordsPerSecond: 10, Window: 6 * time.Second, MaxStalled: 5 * time.Second}
+	sampleThroughput(state, endOffsetsOf("orders", 100, 200), start)
+
+	//When
+	throughput := sampleThroughput(state, endOffsetsOf("orders", 110, 150), start.Add(6*time.Second))
+
+	//Then
+	assert.Equal(t, []string{
+		"Throughput of topic orders dropped to 1.7 records/s over 6s, at least 10 records/s expected.",
+		"Partition(s) 1 (6s) of topic orders stopped advancing for longer than 5s.",
+	}, evaluateThroughput(state, throughput, start.Add(6*time.Second)))
+}
+
+func TestEvaluateThroughput_AveragesOverWindow(t *testing.T) {
+	//Given
+	start := time.Now()
+	state := &TopicThroughputCheckState{Topic: "orders", MinRecordsPerSecond: 10, Window: 4 * time.Second}
+	sampleThroughput(state, endOffsetsOf("orders", 0), start)
+
+	//When
+	burst := sampleThroughput(state, endOffsetsOf("orders", 60), start.Add(2*time.Second))
+	idle := sampleThroughput(state, endOffsetsOf("orders", 60), start.Add(4*time.Second))
+	stillIdle := sampleThroughput(state, endOffsetsOf("orders", 60), start.Add(6*time.Second))
+
+	//Then
+	assert.False(t, burst.WindowFilled)
+	assert.Empty(t, evaluateThroughput(state, burst, start.Add(2*time.Second)))
+	assert.True(t, idle.WindowFilled)
+	assert.Equal(t, 15.0, idle.Total)
+	assert.Empty(t, evaluateThroughput(state, idle, start.Add(4*time.Second)))
+	assert.Equal(t, 0.0, stillIdle.Total)
+	assert.Len(t, evaluateThroughput(state, stillIdle, start.Add(6*time.Second)), 1)
+	assert.Len(t, state.Samples, 2)
+}
+
+func TestSampleThroughput_PartitionWithoutLeader(t *testing.T) {
+	//Given
+	start := time.Now()
+	state := &TopicThroughputCheckState{Topic: "orders", MaxStalled: 5 * time.Second}
+	sampleThroughput(state, endOffsetsOf("orders", 100, 200), start)
+	endOffsets := endOffsetsOf("orders", 120, 0)
+	endOffsets["orders"][1] = kadm.ListedOffset{Topic: "orders", Partition: 1, Err: kerr.NotLeaderForPartition}
+
+	//When
+	throughput := sampleThroughput(state, endOffsets, start.Add(6*time.Second))
+
+	//Then
+	require.NotNil(t, throughput)
+	assert.Equal(t, map[int32]float64{0: 20.0 / 6, 1: 0}, throughput.Partitions)
+	assert.Equal(t, int64(200), state.EndOffsets[1])
+	assert.Equal(t, []string{
+		"Partition(s) 1 (6s) of topic orders stopped advancing for longer than 5s.",
+	}, evaluateThroughput(state, throughput, start.Add(6*time.Second)))
+}
+
+func TestTopicThroughputCheckState_SurvivesSerialization(t *testing.T) {
+	start := time.Now()
+	state := &TopicThroughputCheckState{Topic: "orders"}
+	sampleThroughput(state, endOffsetsOf("orders", 100), start)
+
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	restored := &TopicThroughputCheckState{}
+	require.NoError(t, json.Unmarshal(data, restored))
+
+	throughput := sampleThroughput(restored, endOffsetsOf("orders", 150), start.Add(time.Second))
+	require.NotNil(t, throughput)
+	assert.InDelta(t, 50.0, throughput.Total, 0.001)
+}
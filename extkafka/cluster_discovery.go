@@ -0,0 +1,349 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_commons"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+type kafkaClusterDiscovery struct {
+}
+
+const (
+	clusterStatusConnected   = "connected"
+	clusterStatusUnreachable = "unreachable"
+	clusterStatusPending     = "pending"
+
+	clusterModeKRaft     = "kraft"
+	clusterModeZooKeeper = "zookeeper"
+	clusterModeUnknown   = "unknown"
+)
+
+var (
+	_ discovery_kit_sdk.TargetDescriber    = (*kafkaClusterDiscovery)(nil)
+	_ discovery_kit_sdk.AttributeDescriber = (*kafkaClusterDiscovery)(nil)
+)
+
+func NewKafkaClusterDiscovery(ctx context.Context) discovery_kit_sdk.TargetDiscovery {
+	discovery := &kafkaClusterDiscovery{}
+	return discovery_kit_sdk.NewCachedTargetDiscovery(discovery,
+		discovery_kit_sdk.WithRefreshTargetsNow(),
+		discovery_kit_sdk.WithRefreshTargetsInterval(ctx, time.Duration(config.Config.DiscoveryIntervalKafkaCluster)*time.Second),
+	)
+}
+
+func (r *kafkaClusterDiscovery) Describe() discovery_kit_api.DiscoveryDescription {
+	return discovery_kit_api.DiscoveryDescription{
+		Id: kafkaClusterTargetId,
+		Discover: discovery_kit_api.DescribingEndpointReferenceWithCallInterval{
+			CallInterval: new(fmt.Sprintf("%ds", config.Config.DiscoveryIntervalKafkaCluster)),
+		},
+	}
+}
+
+func (r *kafkaClusterDiscovery) DescribeTarget() discovery_kit_api.TargetDescription {
+	return discovery_kit_api.TargetDescription{
+		Id:       kafkaClusterTargetId,
+		Label:    discovery_kit_api.PluralLabel{One: "Kafka Cluster", Other: "Kafka Clusters"},
+		Category: new("kafka"),
+		Version:  extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:     new(kafkaIcon),
+		Table: discovery_kit_api.Table{
+			Columns: []discovery_kit_api.Column{
+				{Attribute: "steadybit.label"},
+				{Attribute: "kafka.cluster.status"},
+				{Attribute: "kafka.cluster.mode"},
+				{Attribute: "kafka.cluster.broker-count"},
+				{Attribute: "kafka.cluster.under-replicated-partitions"},
+				{Attribute: "kafka.cluster.offline-partitions"},
+			},
+			OrderBy: []discovery_kit_api.OrderBy{
+				{
+					Attribute: "steadybit.label",
+					Direction: "ASC",
+				},
+			},
+		},
+	}
+}
+
+func (r *kafkaClusterDiscovery) DescribeAttributes() []discovery_kit_api.AttributeDescription {
+	return []discovery_kit_api.AttributeDescription{
+		{
+			Attribute: "kafka.cluster.status",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster status",
+				Other: "Kafka cluster statuses",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.seed-brokers",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster seed broker",
+				Other: "Kafka cluster seed brokers",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.controller",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster controller",
+				Other: "Kafka cluster controllers",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.broker-count",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster broker count",
+				Other: "Kafka cluster broker counts",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.mode",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster mode",
+				Other: "Kafka cluster modes",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.version",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster version",
+				Other: "Kafka cluster versions",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.topic-count",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster topic count",
+				Other: "Kafka cluster topic counts",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.partition-count",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster partition count",
+				Other: "Kafka cluster partition counts",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.under-replicated-partitions",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster under-replicated partitions",
+				Other: "Kafka cluster under-replicated partitions",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.offline-partitions",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster offline partitions",
+				Other: "Kafka cluster offline partitions",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.quorum-leader-id",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster quorum leader id",
+				Other: "Kafka cluster quorum leader ids",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.quorum-leader-epoch",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster quorum leader epoch",
+				Other: "Kafka cluster quorum leader epochs",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.quorum-high-watermark",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster quorum high watermark",
+				Other: "Kafka cluster quorum high watermarks",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.quorum-voters",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster quorum voter",
+				Other: "Kafka cluster quorum voters",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.quorum-observers",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster quorum observer",
+				Other: "Kafka cluster quorum observers",
+			},
+		},
+		{
+			Attribute: "kafka.cluster.quorum-voter-lag",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka cluster quorum voter lag",
+				Other: "Kafka cluster quorum voter lags",
+			},
+		},
+	}
+}
+
+func (r *kafkaClusterDiscovery) DiscoverTargets(ctx context.Context) ([]discovery_kit_api.Target, error) {
+	return getAllClustersMultiCluster(ctx)
+}
+
+func getAllClustersMultiCluster(ctx context.Context) ([]discovery_kit_api.Target, error) {
+	RetryPendingClusters()
+	clusters := config.GetAllClusterConfigs()
+
+	resultChan := make(chan discovery_kit_api.Target, len(clusters))
+
+	// Discover from all clusters in parallel, an unreachable cluster is still reported as target
+	for clusterName, clusterConfig := range clusters {
+		go func(name string, cfg *config.ClusterConfig) {
+			resultChan <- discoverCluster(ctx, name, cfg)
+		}(clusterName, clusterConfig)
+	}
+
+	// Collect results
+	allTargets := make([]discovery_kit_api.Target, 0, len(clusters))
+	for i := 0; i < len(clusters); i++ {
+		allTargets = append(allTargets, <-resultChan)
+	}
+
+	for _, pending := range config.GetPendingClusters() {
+		allTargets = append(allTargets, toPendingClusterTarget(pending))
+	}
+
+	return discovery_kit_commons.ApplyAttributeExcludes(allTargets, config.Config.DiscoveryAttributesExcludesClusters), nil
+}
+
+func discoverCluster(ctx context.Context, clusterName string, clusterConfig *config.ClusterConfig) discovery_kit_api.Target {
+	target := toClusterTarget(clusterName, clusterConfig)
+
+	kafkaClient, err := createNewClientWithConfig(strings.Split(clusterConfig.SeedBrokers, ","), clusterConfig)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to initialize kafka client for cluster %s", clusterName)
+		return target
+	}
+	defer kafkaClient.Close()
+	client := kadm.NewClient(kafkaClient)
+
+	metadata, err := client.Metadata(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to get metadata for cluster %s", clusterName)
+		return target
+	}
+
+	mode := clusterModeUnknown
+	var versions []string
+	apiVersions, err := client.ApiVersions(ctx)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to get api versions for cluster %s", clusterName)
+	} else {
+		mode, versions = clusterModeAndVersions(apiVersions)
+	}
+
+	addClusterMetadataAttributes(target.Attributes, metadata, mode, versions)
+
+	// The controller quorum can only be described in KRaft mode
+	if mode != clusterModeZooKeeper {
+		quorum, err := describeQuorum(ctx, kafkaClient)
+		if err != nil {
+			log.Debug().Err(err).Msgf("Failed to describe the controller quorum of cluster %s", clusterName)
+		} else {
+			addQuorumAttributes(target.Attributes, quorum)
+		}
+	}
+	return target
+}
+
+func toClusterTarget(clusterName string, clusterConfig *config.ClusterConfig) discovery_kit_api.Target {
+	attributes := make(map[string][]string)
+	attributes["kafka.cluster.name"] = []string{clusterName}
+	attributes["kafka.cluster.status"] = []string{clusterStatusUnreachable}
+	attributes["kafka.cluster.seed-brokers"] = strings.Split(clusterConfig.SeedBrokers, ",")
+	if clusterConfig.ClusterID != "" {
+		attributes["kafka.cluster.id"] = []string{clusterConfig.ClusterID}
+	}
+
+	return discovery_kit_api.Target{
+		Id:         clusterName,
+		Label:      clusterName,
+		TargetType: kafkaClusterTargetId,
+		Attributes: attributes,
+	}
+}
+
+// toPendingClusterTarget reports a configured cluster that couldn't be reached since the extension started.
+func toPendingClusterTarget(pending config.PendingCluster) discovery_kit_api.Target {
+	target := toClusterTarget(pending.Name, pending.Config)
+	target.Attributes["kafka.cluster.status"] = []string{clusterStatusPending}
+	return target
+}
+
+func addClusterMetadataAttributes(attributes map[string][]string, metadata kadm.Metadata, mode string, versions []string) {
+	topics, partitions, underReplicated, offline := 0, 0, 0, 0
+	for _, topic := range metadata.Topics {
+		// Internal topics count towards the partition health, like __consumer_offsets being offline
+		if !topic.IsInternal {
+			topics++
+			partitions += len(topic.Partitions)
+		}
+		for _, partition := range topic.Partitions {
+			if isPartitionOffline(partition) {
+				offline++
+			} else if len(partition.ISR) < len(partition.Replicas) {
+				underReplicated++
+			}
+		}
+	}
+
+	attributes["kafka.cluster.status"] = []string{clusterStatusConnected}
+	attributes["kafka.cluster.id"] = []string{metadata.Cluster}
+	attributes["kafka.cluster.controller"] = []string{strconv.Itoa(int(metadata.Controller))}
+	attributes["kafka.cluster.broker-count"] = []string{strconv.Itoa(len(metadata.Brokers))}
+	attributes["kafka.cluster.mode"] = []string{mode}
+	if len(versions) > 0 {
+		attributes["kafka.cluster.version"] = versions
+	}
+	attributes["kafka.cluster.topic-count"] = []string{strconv.Itoa(topics)}
+	attributes["kafka.cluster.partition-count"] = []string{strconv.Itoa(partitions)}
+	attributes["kafka.cluster.under-replicated-partitions"] = []string{strconv.Itoa(underReplicated)}
+	attributes["kafka.cluster.offline-partitions"] = []string{strconv.Itoa(offline)}
+}
+
+// clusterModeAndVersions determines whether the cluster runs in KRaft mode, which is the case if the metadata.version
+// feature is finalized, and the distinct versions guessed from the API versions of the brokers.
+func clusterModeAndVersions(apiVersions kadm.BrokersApiVersions) (string, []string) {
+	mode := clusterModeUnknown
+	var versions []string
+	for _, brokerApiVersions := range apiVersions.Sorted() {
+		if brokerApiVersions.Err != nil {
+			continue
+		}
+		if mode == clusterModeUnknown {
+			mode = clusterModeZooKeeper
+		}
+		for _, feature := range brokerApiVersions.Raw().FinalizedFeatures {
+			if feature.Name == "metadata.version" {
+				mode = clusterModeKRaft
+			}
+		}
+		if version := brokerApiVersions.VersionGuess(); !slices.Contains(versions, version) {
+			versions = append(versions, version)
+		}
+	}
+	slices.Sort(versions)
+	return mode, versions
+}
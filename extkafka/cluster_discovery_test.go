@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"testing"
+
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func TestClusterDiscovery_DescribeTarget(t *testing.T) {
+	td := (&kafkaClusterDiscovery{}).DescribeTarget()
+	assert.Equal(t, kafkaClusterTargetId, td.Id)
+	assert.Equal(t, "Kafka Cluster", td.Label.One)
+	assert.Equal(t, "Kafka Clusters", td.Label.Other)
+	assert.Equal(t, "kafka", *td.Category)
+}
+
+func TestToClusterTarget(t *testing.T) {
+	//Given
+	clusterConfig := &config.ClusterConfig{ClusterID: "internal-id-42", SeedBrokers: "broker-1:9092,broker-2:9092"}
+	metadata := kadm.Metadata{
+		Cluster:    "internal-id-42",
+		Controller: 2,
+		Brokers:    kadm.BrokerDetails{{NodeID: 1}, {NodeID: 2}, {NodeID: 3}},
+		Topics: kadm.TopicDetails{
+			"orders": {Topic: "orders", Partitions: kadm.PartitionDetails{
+				0: {Partition: 0, Leader: 1, Replicas: []int32{1, 2, 3}, ISR: []int32{1, 2, 3}},
+				1: {Partition: 1, Leader: 2, Replicas: []int32{2, 3, 1}, ISR: []int32{2}},
+				2: {Partition: 2, Leader: -1, Replicas: []int32{3, 1, 2}, ISR: []int32{}, Err: kerr.LeaderNotAvailable},
+			}},
+			"__consumer_offsets": {Topic: "__consumer_offsets", IsInternal: true, Partitions: kadm.PartitionDetails{
+				0: {Partition: 0, Leader: 1, Replicas: []int32{1, 2}, ISR: []int32{1}},
+			}},
+		},
+	}
+
+	//When
+	tgt := toClusterTarget("cluster-42", clusterConfig)
+	addClusterMetadataAttributes(tgt.Attributes, metadata, clusterModeKRaft, []string{"v3.7"})
+
+	//Then
+	assert.Equal(t, "cluster-42", tgt.Id)
+	assert.Equal(t, "cluster-42", tgt.Label)
+	assert.Equal(t, kafkaClusterTargetId, tgt.TargetType)
+	assert.Equal(t, []string{"cluster-42"}, tgt.Attributes["kafka.cluster.name"])
+	assert.Equal(t, []string{"internal-id-42"}, tgt.Attributes["kafka.cluster.id"])
+	assert.Equal(t, []string{clusterStatusConnected}, tgt.Attributes["kafka.cluster.status"])
+	assert.Equal(t, []string{"broker-1:9092", "broker-2:9092"}, tgt.Attributes["kafka.cluster.seed-brokers"])
+	assert.Equal(t, []string{"2"}, tgt.Attributes["kafka.cluster.controller"])
+	assert.Equal(t, []string{"3"}, tgt.Attributes["kafka.cluster.broker-count"])
+	assert.Equal(t, []string{clusterModeKRaft}, tgt.Attributes["kafka.cluster.mode"])
+	assert.Equal(t, []string{"v3.7"}, tgt.Attributes["kafka.cluster.version"])
+	assert.Equal(t, []string{"1"}, tgt.Attributes["kafka.cluster.topic-count"])
+	assert.Equal(t, []string{"3"}, tgt.Attributes["kafka.cluster.partition-count"])
+	assert.Equal(t, []string{"2"}, tgt.Attributes["kafka.cluster.under-replicated-partitions"])
+	assert.Equal(t, []string{"1"}, tgt.Attributes["kafka.cluster.offline-partitions"])
+}
+
+func TestToPendingClusterTarget(t *testing.T) {
+	tgt := toPendingClusterTarget(config.PendingCluster{Index: 1, Name: "cluster-43", Config: &config.ClusterConfig{SeedBrokers: "broker-9:9092"}})
+
+	assert.Equal(t, "cluster-43", tgt.Id)
+	assert.Equal(t, []string{clusterStatusPending}, tgt.Attributes["kafka.cluster.status"])
+	assert.Equal(t, []string{"broker-9:9092"}, tgt.Attributes["kafka.cluster.seed-brokers"])
+	assert.NotContains(t, tgt.Attributes, "kafka.cluster.id")
+	assert.NotContains(t, tgt.Attributes, "kafka.cluster.broker-count")
+}
@@ -21,15 +21,19 @@ import (
 	"github.com/steadybit/extension-kafka/config"
 	"github.com/steadybit/extension-kit/extutil"
 	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 	"github.com/twmb/franz-go/pkg/sasl/plain"
 	"github.com/twmb/franz-go/pkg/sasl/scram"
 )
 
 const (
-	kafkaBrokerTargetId   = "com.steadybit.extension_kafka.broker"
-	kafkaConsumerTargetId = "com.steadybit.extension_kafka.consumer"
-	kafkaTopicTargetId    = "com.steadybit.extension_kafka.topic"
+	kafkaBrokerTargetId    = "com.steadybit.extension_kafka.broker"
+	kafkaConsumerTargetId  = "com.steadybit.extension_kafka.consumer"
+	kafkaTopicTargetId     = "com.steadybit.extension_kafka.topic"
+	kafkaScramUserTargetId = "com.steadybit.extension_kafka.scram-user"
+	kafkaClusterTargetId   = "com.steadybit.extension_kafka.cluster"
 )
 
 func init() {
@@ -59,7 +63,9 @@ type KafkaBrokerAttackState struct {
 	RecordHeaders            map[string]string
 	ConsumerGroup            string
 	BrokerHosts              []string
-	ClusterName              string // Cluster name for multi-cluster support
+	ClusterName              string   // Cluster name for multi-cluster support
+	Tombstone                bool     // Produce records with a null value instead of RecordValue
+	TombstoneKeys            []string // Keys of the tombstones, used round-robin
 }
 
 type AlterState struct {
@@ -197,8 +203,9 @@ func createNewAdminClient(brokers []string) (*kadm.Client, error) {
 	return kadm.NewClient(client), nil
 }
 
-// createNewClientWithConfig creates a Kafka client using a specific cluster configuration
-func createNewClientWithConfig(brokers []string, clusterConfig *config.ClusterConfig) (*kgo.Client, error) {
+// createNewClientWithConfig creates a Kafka client using a specific cluster configuration.
+// Additional options (e.g. consumer or producer settings) are appended to the connection options.
+func createNewClientWithConfig(brokers []string, clusterConfig *config.ClusterConfig, extraOpts ...kgo.Opt) (*kgo.Client, error) {
 	opts := []kgo.Opt{
 		kgo.SeedBrokers(brokers...),
 		kgo.ClientID("steadybit"),
@@ -240,6 +247,7 @@ func createNewClientWithConfig(brokers []string, clusterConfig *config.ClusterCo
 		tlsDialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: 10 * time.Second}}
 		opts = append(opts, kgo.Dialer(tlsDialer.DialContext))
 	}
+	opts = append(opts, extraOpts...)
 
 	client, err := kgo.NewClient(opts...)
 	if err != nil {
@@ -402,6 +410,90 @@ func describeConfigOf(ctx context.Context, adminClient *kadm.Client, configName
 	return configValue, nil
 }
 
+// describeDynamicConfigOf returns the value of a configuration property only if it is dynamically overridden for
+// the broker. Defaults and static values are reported as an empty string, so that restoring the returned value
+// removes the override instead of pinning the default.
+func describeDynamicConfigOf(ctx context.Context, adminClient *kadm.Client, configName string, brokerID int32) (string, error) {
+	configs, err := adminClient.DescribeBrokerConfigs(ctx, brokerID)
+	if err != nil {
+		return "", err
+	}
+
+	resourceConfig, err := configs.On(strconv.FormatInt(int64(brokerID), 10), nil)
+	if err != nil {
+		return "", err
+	}
+	if resourceConfig.Err != nil {
+		return "", fmt.Errorf("%w Response from Broker: %s", resourceConfig.Err, resourceConfig.ErrMessage)
+	}
+
+	for _, c := range resourceConfig.Configs {
+		if c.Key == configName && c.Source == kmsg.ConfigSourceDynamicBrokerConfig {
+			log.Debug().Msgf("Dynamic configuration value for key %s: %s, for broker node-id: %d", configName, c.MaybeValue(), brokerID)
+			return c.MaybeValue(), nil
+		}
+	}
+	return "", nil
+}
+
+// describeLogDirsOfBroker describes the online log directories of a single broker without listing the partitions in them.
+func describeLogDirsOfBroker(ctx context.Context, client *kgo.Client, brokerID int32) ([]kmsg.DescribeLogDirsResponseDir, error) {
+	allDirs, err := describeAllLogDirsOfBroker(ctx, client, brokerID, false)
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]kmsg.DescribeLogDirsResponseDir, 0, len(allDirs))
+	for _, dir := range allDirs {
+		if err := kerr.ErrorForCode(dir.ErrorCode); err != nil {
+			log.Debug().Err(err).Msgf("Log dir %s of broker %d is not available", dir.Dir, brokerID)
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// describeAllLogDirsOfBroker describes all log directories of a single broker, including offline ones with their
+// error code. The partitions in them are only listed if withPartitions is set, which is costly on brokers hosting
+// many partitions.
+func describeAllLogDirsOfBroker(ctx context.Context, client *kgo.Client, brokerID int32, withPartitions bool) ([]kmsg.DescribeLogDirsResponseDir, error) {
+	req := kmsg.NewPtrDescribeLogDirsRequest()
+	if !withPartitions {
+		// An empty (in contrast to a nil) topic list describes no partitions
+		req.Topics = []kmsg.DescribeLogDirsRequestTopic{}
+	}
+	response, err := client.Broker(int(brokerID)).RetriableRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(*kmsg.DescribeLogDirsResponse)
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	return resp.Dirs, nil
+}
+
+// describeTopicConfigOf returns the effective value of a topic configuration property, including values
+// inherited from the broker defaults. An empty string is returned if the property is unknown.
+func describeTopicConfigOf(ctx context.Context, adminClient *kadm.Client, configName string, topic string) (string, error) {
+	configs, err := describeTopicConfigValues(ctx, adminClient, []string{configName}, topic)
+	if err != nil {
+		return "", err
+	}
+	values, ok := configs[topic]
+	if !ok {
+		return "", fmt.Errorf("failed to describe the configuration of topic %s", topic)
+	}
+
+	if value, ok := values[configName]; ok {
+		log.Debug().Msgf("Configuration value for key %s: %s, for topic: %s", configName, value, topic)
+		return value, nil
+	}
+
+	log.Warn().Msgf("No value found for configuration key: %s, for topic: %s", configName, topic)
+	return "", nil
+}
+
 func alterConfigInt(ctx context.Context, brokers []string, configName string, configValue int, brokerID int32) error {
 	return alterConfigStr(ctx, brokers, configName, strconv.Itoa(configValue), brokerID)
 }
@@ -418,13 +510,64 @@ func alterConfigStr(ctx context.Context, brokers []string, configName string, co
 }
 
 func alterConfigStrWithConfig(ctx context.Context, brokers []string, configName string, configValue string, brokerID int32, clusterConfig *config.ClusterConfig) error {
+	return alterResourceConfigWithConfig(ctx, brokers, brokerConfigResource(brokerID), configName, configValue, clusterConfig)
+}
+
+func alterResourceConfigWithConfig(ctx context.Context, brokers []string, resource configResource, configName string, configValue string, clusterConfig *config.ClusterConfig) error {
 	_, err := retryOnTransientError(ctx, func() (string, error) {
-		return "", doAlterConfig(ctx, brokers, configName, configValue, brokerID, clusterConfig)
+		return "", doAlterResourceConfig(ctx, brokers, resource, configName, configValue, clusterConfig)
 	})
 	return err
 }
 
+// configResource is a broker or topic whose configuration is altered. describe returns the value that is compared
+// with the altered value to detect when the change was applied.
+type configResource struct {
+	name     string
+	alter    func(ctx context.Context, adminClient *kadm.Client, configs []kadm.AlterConfig) (kadm.AlterConfigsResponses, error)
+	describe func(ctx context.Context, adminClient *kadm.Client, configName string) (string, error)
+}
+
+func brokerConfigResource(brokerID int32) configResource {
+	return configResource{
+		name: fmt.Sprintf("broker node-id %d", brokerID),
+		alter: func(ctx context.Context, adminClient *kadm.Client, configs []kadm.AlterConfig) (kadm.AlterConfigsResponses, error) {
+			return adminClient.AlterBrokerConfigs(ctx, configs, brokerID)
+		},
+		describe: func(ctx context.Context, adminClient *kadm.Client, configName string) (string, error) {
+			return describeConfigOf(ctx, adminClient, configName, brokerID)
+		},
+	}
+}
+
+// dynamicBrokerConfigResource only compares the dynamic override of the broker, so that removing an override with an
+// empty value is detected even if the config has a non-empty default.
+func dynamicBrokerConfigResource(brokerID int32) configResource {
+	resource := brokerConfigResource(brokerID)
+	resource.describe = func(ctx context.Context, adminClient *kadm.Client, configName string) (string, error) {
+		return describeDynamicConfigOf(ctx, adminClient, configName, brokerID)
+	}
+	return resource
+}
+
+func topicConfigResource(topic string) configResource {
+	return configResource{
+		name: fmt.Sprintf("topic %s", topic),
+		alter: func(ctx context.Context, adminClient *kadm.Client, configs []kadm.AlterConfig) (kadm.AlterConfigsResponses, error) {
+			return adminClient.AlterTopicConfigs(ctx, configs, topic)
+		},
+		describe: func(ctx context.Context, adminClient *kadm.Client, configName string) (string, error) {
+			return describeTopicConfigOf(ctx, adminClient, configName, topic)
+		},
+	}
+}
+
 func doAlterConfig(ctx context.Context, brokers []string, configName string, configValue string, brokerID int32, clusterConfig *config.ClusterConfig) error {
+	return doAlterResourceConfig(ctx, brokers, brokerConfigResource(brokerID), configName, configValue, clusterConfig)
+}
+
+// doAlterResourceConfig sets the config of the resource, an empty value removes the override.
+func doAlterResourceConfig(ctx context.Context, brokers []string, resource configResource, configName string, configValue string, clusterConfig *config.ClusterConfig) error {
 	var adminClient *kadm.Client
 	var err error
 	if clusterConfig != nil {
@@ -441,7 +584,7 @@ func doAlterConfig(ctx context.Context, brokers []string, configName string, con
 	if configValue == "" {
 		op = kadm.DeleteConfig
 	}
-	responses, err := adminClient.AlterBrokerConfigs(ctx, []kadm.AlterConfig{{Name: configName, Value: new(configValue), Op: op}}, brokerID)
+	responses, err := resource.alter(ctx, adminClient, []kadm.AlterConfig{{Name: configName, Value: new(configValue), Op: op}})
 	if err != nil {
 		return err
 	}
@@ -461,17 +604,17 @@ func doAlterConfig(ctx context.Context, brokers []string, configName string, con
 	for {
 		elapsed := time.Since(now).Seconds()
 		if elapsed > 5 {
-			return fmt.Errorf("configuration change of %s to %s was not applied in time", configName, configValue)
+			return fmt.Errorf("configuration change of %s to %s for %s was not applied in time", configName, configValue, resource.name)
 		}
-		value, err := describeConfigOf(ctx, adminClient, configName, brokerID)
+		value, err := resource.describe(ctx, adminClient, configName)
 		if err != nil {
 			return err
 		}
 		if value == configValue {
-			log.Debug().Msgf("configuration change of %s to %s was applied after %.2fs", configName, configValue, elapsed)
+			log.Debug().Msgf("configuration change of %s to %s for %s was applied after %.2fs", configName, configValue, resource.name, elapsed)
 			return nil
 		}
-		log.Debug().Msgf("Configuration change of %s to %s was not applied yet, waiting", configName, configValue)
+		log.Debug().Msgf("Configuration change of %s to %s for %s was not applied yet, waiting", configName, configValue, resource.name)
 		time.Sleep(100 * time.Millisecond)
 	}
 }
@@ -0,0 +1,544 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type consumeFetchLoadAction struct{}
+
+type ConsumeFetchLoadState struct {
+	Topic                  string
+	ClusterName            string
+	BrokerHosts            []string
+	ExecutionID            uuid.UUID
+	Fetchers               int
+	StartFrom              string
+	StartTimestamp         int64 // Unix milliseconds, only used when StartFrom is fetchStartFromTimestamp
+	RestartWhenCaughtUp    bool
+	FetchMaxBytes          int32
+	FetchMaxPartitionBytes int32
+}
+
+type FetchLoadRunData struct {
+	cancel         context.CancelFunc // cancels all fetchers of this execution
+	ctx            context.Context    // context for all fetchers of this execution
+	started        time.Time          // when the fetchers were started
+	records        atomic.Uint64      // number of records fetched so far
+	bytes          atomic.Uint64      // number of bytes (keys, values and headers) fetched so far
+	errors         atomic.Uint64      // number of fetch errors so far
+	mu             sync.Mutex         // guards the fields below
+	lastSampleTime time.Time          // time of the last rate sample reported by Status
+	lastRecords    uint64             // records at the last rate sample
+	lastBytes      uint64             // bytes at the last rate sample
+}
+
+const (
+	fetchStartFromEarliest  = "earliest"
+	fetchStartFromTimestamp = "timestamp"
+
+	// The smallest fetch byte limit that can be configured
+	fetchMinBytesLimit = 1024
+)
+
+var (
+	FetchLoadRunDataMap = sync.Map{} //make(map[uuid.UUID]*FetchLoadRunData)
+)
+
+// Make sure Action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[ConsumeFetchLoadState]           = (*consumeFetchLoadAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[ConsumeFetchLoadState] = (*consumeFetchLoadAction)(nil)
+	_ action_kit_sdk.ActionWithStop[ConsumeFetchLoadState]   = (*consumeFetchLoadAction)(nil)
+)
+
+func NewConsumeFetchLoadAction() action_kit_sdk.Action[ConsumeFetchLoadState] {
+	return &consumeFetchLoadAction{}
+}
+
+func (l *consumeFetchLoadAction) NewEmptyState() ConsumeFetchLoadState {
+	return ConsumeFetchLoadState{}
+}
+
+// Describe returns the action description for the platform with all required information.
+func (l *consumeFetchLoadAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.consume-fetch-load", kafkaTopicTargetId),
+		Label:       "Consume (Fetch Load)",
+		Description: "Run parallel fetchers without a consumer group that read the topic as fast as possible from the earliest offset or a chosen timestamp. Catch-up reads of cold data evict the page cache of the brokers and may hurt producer latency. For loading the cluster with writes, use the produce actions instead.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: kafkaTopicTargetId,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "topic name",
+					Description: new("Find topic by cluster and name"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.topic.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Attack,
+		TimeControl: action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "fetchers",
+				Label:        "Number of fetchers",
+				Description:  new("The number of parallel fetchers. Every fetcher reads all partitions of the topic on its own."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("4"),
+				MinValue:     new(1),
+				MaxValue:     new(50),
+				Required:     new(true),
+			},
+			{
+				Name:         "startFrom",
+				Label:        "Start from",
+				Description:  new("Where the fetchers start reading. 'Earliest': the oldest record still retained. 'Timestamp': the first record at or after the given timestamp."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(fetchStartFromEarliest),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Earliest",
+						Value: fetchStartFromEarliest,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Timestamp",
+						Value: fetchStartFromTimestamp,
+					},
+				}),
+				Required: new(true),
+			},
+			{
+				Name:        "startTimestamp",
+				Label:       "Start timestamp",
+				Description: new("Only used with start from 'Timestamp'. Either an RFC 3339 timestamp (e.g. 2025-01-31T12:00:00Z) or Unix milliseconds."),
+				Type:        action_kit_api.ActionParameterTypeString,
+			},
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("How long the fetchers run. Records are fetched continuously for this duration."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+			},
+			{
+				Name:  "-",
+				Label: "-",
+				Type:  action_kit_api.ActionParameterTypeSeparator,
+				Order: new(5),
+			},
+			{
+				Name:         "restartWhenCaughtUp",
+				Label:        "Restart when caught up",
+				Description:  new("If enabled, a fetcher that reached the end of the topic starts over from the start position, keeping the reads on cold data for the whole duration."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Advanced:     new(true),
+			},
+			{
+				Name:         "fetchMaxBytes",
+				Label:        "Fetch max bytes",
+				Description:  new("The maximum number of bytes a broker returns for a single fetch request."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("52428800"),
+				MinValue:     new(fetchMinBytesLimit),
+				MaxValue:     new(math.MaxInt32),
+				Advanced:     new(true),
+			},
+			{
+				Name:         "fetchMaxPartitionBytes",
+				Label:        "Fetch max partition bytes",
+				Description:  new("The maximum number of bytes a broker returns for a single partition in a fetch request."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1048576"),
+				MinValue:     new(fetchMinBytesLimit),
+				MaxValue:     new(math.MaxInt32),
+				Advanced:     new(true),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Fetched Records / s",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_fetch_load_records_per_second",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Records / s"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "topic",
+							Title: "Topic",
+						},
+					},
+				}),
+			},
+			action_kit_api.LineChartWidget{
+				Type:  action_kit_api.ComSteadybitWidgetLineChart,
+				Title: "Fetched Bytes / s",
+				Identity: action_kit_api.LineChartWidgetIdentityConfig{
+					MetricName: "kafka_fetch_load_bytes_per_second",
+					From:       "id",
+					Mode:       action_kit_api.ComSteadybitWidgetLineChartIdentityModeSelect,
+				},
+				Tooltip: new(action_kit_api.LineChartWidgetTooltipConfig{
+					MetricValueTitle: new("Bytes / s"),
+					AdditionalContent: []action_kit_api.LineChartWidgetTooltipContent{
+						{
+							From:  "topic",
+							Title: "Topic",
+						},
+					},
+				}),
+			},
+		}),
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("1s"),
+		}),
+		Stop: new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (l *consumeFetchLoadAction) Prepare(_ context.Context, state *ConsumeFetchLoadState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	if len(request.Target.Attributes["kafka.topic.name"]) == 0 {
+		return nil, fmt.Errorf("the target is missing the kafka.topic.name attribute")
+	}
+	state.Topic = extutil.MustHaveValue(request.Target.Attributes, "kafka.topic.name")[0]
+
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+
+	state.Fetchers = extutil.ToInt(request.Config["fetchers"])
+	if state.Fetchers <= 0 {
+		return nil, fmt.Errorf("number of fetchers must be greater than zero")
+	}
+
+	state.StartFrom = extutil.ToString(request.Config["startFrom"])
+	switch state.StartFrom {
+	case fetchStartFromEarliest, "":
+		state.StartFrom = fetchStartFromEarliest
+	case fetchStartFromTimestamp:
+		startTimestamp := extutil.ToString(request.Config["startTimestamp"])
+		if strings.TrimSpace(startTimestamp) == "" {
+			return nil, fmt.Errorf("a start timestamp is required when starting from a timestamp")
+		}
+		state.StartTimestamp, err = parseTimestampMillis(startTimestamp)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown start position '%s'", state.StartFrom)
+	}
+
+	state.RestartWhenCaughtUp = true
+	if request.Config["restartWhenCaughtUp"] != nil {
+		state.RestartWhenCaughtUp = extutil.ToBool(request.Config["restartWhenCaughtUp"])
+	}
+	if state.FetchMaxBytes, err = fetchBytesOf(request.Config, "fetchMaxBytes"); err != nil {
+		return nil, err
+	}
+	if state.FetchMaxPartitionBytes, err = fetchBytesOf(request.Config, "fetchMaxPartitionBytes"); err != nil {
+		return nil, err
+	}
+
+	state.ExecutionID = request.ExecutionId
+	return nil, nil
+}
+
+// fetchBytesOf returns the byte limit of the given parameter, 0 if it isn't set. The fetch request only holds an int32.
+func fetchBytesOf(config map[string]any, name string) (int32, error) {
+	value := extutil.ToInt64(config[name])
+	if value == 0 {
+		return 0, nil
+	}
+	if value < fetchMinBytesLimit || value > math.MaxInt32 {
+		return 0, fmt.Errorf("%s must be between %d and %d", name, fetchMinBytesLimit, math.MaxInt32)
+	}
+	return int32(value), nil
+}
+
+func (l *consumeFetchLoadAction) Start(_ context.Context, state *ConsumeFetchLoadState) (*action_kit_api.StartResult, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runData := &FetchLoadRunData{
+		cancel:         cancel,
+		ctx:            ctx,
+		started:        time.Now(),
+		lastSampleTime: time.Now(),
+	}
+	FetchLoadRunDataMap.Store(state.ExecutionID, runData)
+
+	for i := 0; i < state.Fetchers; i++ {
+		go fetchLoadWorker(runData, state, i)
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Started %d fetcher(s) reading topic %s from %s", state.Fetchers, state.Topic, describeFetchStart(state)),
+		}},
+	}, nil
+}
+
+func (l *consumeFetchLoadAction) Status(_ context.Context, state *ConsumeFetchLoadState) (*action_kit_api.StatusResult, error) {
+	runData, err := loadFetchLoadRunData(state.ExecutionID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load fetch load run data")
+		return nil, err
+	}
+	return &action_kit_api.StatusResult{
+		Completed: false,
+		Metrics:   new(sampleFetchLoadMetrics(runData, state, time.Now())),
+	}, nil
+}
+
+func (l *consumeFetchLoadAction) Stop(_ context.Context, state *ConsumeFetchLoadState) (*action_kit_api.StopResult, error) {
+	runData, err := loadFetchLoadRunData(state.ExecutionID)
+	if err != nil {
+		log.Debug().Err(err).Msg("Fetch load run data not found, stop was already called")
+		return nil, nil
+	}
+	runData.cancel()
+	FetchLoadRunDataMap.Delete(state.ExecutionID)
+
+	now := time.Now()
+	metrics := sampleFetchLoadMetrics(runData, state, now)
+	elapsed := now.Sub(runData.started).Seconds()
+	records := runData.records.Load()
+	bytes := runData.bytes.Load()
+	var recordsPerSecond, bytesPerSecond float64
+	if elapsed > 0 {
+		recordsPerSecond = float64(records) / elapsed
+		bytesPerSecond = float64(bytes) / elapsed
+	}
+	log.Info().Msgf("Fetched %d records (%d bytes) from topic %s, %.0f records/s, %.0f bytes/s", records, bytes, state.Topic, recordsPerSecond, bytesPerSecond)
+
+	return &action_kit_api.StopResult{
+		Metrics: new(metrics),
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Fetched %d records (%d bytes) from topic %s: %.0f records/s, %.0f bytes/s on average, %d fetch error(s)", records, bytes, state.Topic, recordsPerSecond, bytesPerSecond, runData.errors.Load()),
+		}},
+	}, nil
+}
+
+func loadFetchLoadRunData(executionID uuid.UUID) (*FetchLoadRunData, error) {
+	runData, ok := FetchLoadRunDataMap.Load(executionID)
+	if !ok {
+		return nil, fmt.Errorf("failed to load fetch load run data")
+	}
+	return runData.(*FetchLoadRunData), nil
+}
+
+func fetchLoadWorker(runData *FetchLoadRunData, state *ConsumeFetchLoadState, fetcher int) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get cluster config")
+		return
+	}
+
+	for runData.ctx.Err() == nil {
+		caughtUp, err := fetchUntilCaughtUp(runData, state, clusterConfig)
+		if err != nil {
+			log.Error().Err(err).Msgf("Fetcher %d stopped", fetcher)
+			return
+		}
+		if !caughtUp {
+			return
+		}
+		if !state.RestartWhenCaughtUp {
+			log.Debug().Msgf("Fetcher %d caught up with topic %s", fetcher, state.Topic)
+			<-runData.ctx.Done()
+			return
+		}
+		log.Debug().Msgf("Fetcher %d caught up with topic %s, restarting from %s", fetcher, state.Topic, describeFetchStart(state))
+	}
+}
+
+// fetchUntilCaughtUp reads the topic with a fresh client until it read all records up to the end offsets the topic
+// had when the client was created (returns true) or the execution is cancelled (returns false). Polls that return no
+// records, e.g. because the brokers are slow or fetches fail, don't count as caught up.
+func fetchUntilCaughtUp(runData *FetchLoadRunData, state *ConsumeFetchLoadState, clusterConfig *config.ClusterConfig) (bool, error) {
+	client, err := createNewClientWithConfig(state.BrokerHosts, clusterConfig, fetchLoadClientOpts(state)...)
+	if err != nil {
+		return false, fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	remaining, err := retryOnTransientError(runData.ctx, func() (map[int32]int64, error) {
+		return listUnreadEndOffsets(runData.ctx, kadm.NewClient(client), state)
+	})
+	if runData.ctx.Err() != nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to list the offsets of topic %s: %w", state.Topic, err)
+	}
+
+	for len(remaining) > 0 {
+		fetches := client.PollFetches(runData.ctx)
+		if runData.ctx.Err() != nil {
+			return false, nil
+		}
+		if fetches.IsClientClosed() {
+			return false, nil
+		}
+
+		fetches.EachError(func(topic string, partition int32, err error) {
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+				return
+			}
+			runData.errors.Add(1)
+			log.Debug().Err(err).Msgf("Failed to fetch topic %s partition %d", topic, partition)
+		})
+
+		var records, bytes uint64
+		fetches.EachRecord(func(record *kgo.Record) {
+			if end, ok := remaining[record.Partition]; ok && record.Offset+1 >= end {
+				delete(remaining, record.Partition)
+			}
+			// Control records are only kept to track the offsets, a transaction marker may be the last offset
+			if record.Attrs.IsControl() {
+				return
+			}
+			records++
+			bytes += recordSize(record)
+		})
+		runData.records.Add(records)
+		runData.bytes.Add(bytes)
+	}
+	return true, nil
+}
+
+// listUnreadEndOffsets returns the end offset of every partition of the topic that has records after the start
+// position of the fetchers.
+func listUnreadEndOffsets(ctx context.Context, client *kadm.Client, state *ConsumeFetchLoadState) (map[int32]int64, error) {
+	var startOffsets kadm.ListedOffsets
+	var err error
+	if state.StartFrom == fetchStartFromTimestamp {
+		startOffsets, err = client.ListOffsetsAfterMilli(ctx, state.StartTimestamp, state.Topic)
+	} else {
+		startOffsets, err = client.ListStartOffsets(ctx, state.Topic)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := startOffsets.Error(); err != nil {
+		return nil, err
+	}
+	endOffsets, err := client.ListEndOffsets(ctx, state.Topic)
+	if err != nil {
+		return nil, err
+	}
+	if err := endOffsets.Error(); err != nil {
+		return nil, err
+	}
+
+	unread := make(map[int32]int64)
+	endOffsets.Each(func(end kadm.ListedOffset) {
+		if start, ok := startOffsets.Lookup(end.Topic, end.Partition); !ok || start.Offset < end.Offset {
+			unread[end.Partition] = end.Offset
+		}
+	})
+	return unread, nil
+}
+
+func fetchLoadClientOpts(state *ConsumeFetchLoadState) []kgo.Opt {
+	startOffset := kgo.NewOffset().AtStart()
+	if state.StartFrom == fetchStartFromTimestamp {
+		startOffset = kgo.NewOffset().AfterMilli(state.StartTimestamp)
+	}
+	opts := []kgo.Opt{
+		kgo.ConsumeTopics(state.Topic),
+		kgo.ConsumeResetOffset(startOffset),
+		kgo.KeepControlRecords(),
+	}
+	if state.FetchMaxBytes > 0 {
+		opts = append(opts, kgo.FetchMaxBytes(state.FetchMaxBytes))
+	}
+	if state.FetchMaxPartitionBytes > 0 {
+		opts = append(opts, kgo.FetchMaxPartitionBytes(state.FetchMaxPartitionBytes))
+	}
+	return opts
+}
+
+func recordSize(record *kgo.Record) uint64 {
+	size := uint64(len(record.Key) + len(record.Value))
+	for _, header := range record.Headers {
+		size += uint64(len(header.Key) + len(header.Value))
+	}
+	return size
+}
+
+func sampleFetchLoadMetrics(runData *FetchLoadRunData, state *ConsumeFetchLoadState, now time.Time) []action_kit_api.Metric {
+	runData.mu.Lock()
+	defer runData.mu.Unlock()
+
+	records := runData.records.Load()
+	bytes := runData.bytes.Load()
+	elapsed := now.Sub(runData.lastSampleTime).Seconds()
+
+	var recordsPerSecond, bytesPerSecond float64
+	if elapsed > 0 {
+		recordsPerSecond = float64(records-runData.lastRecords) / elapsed
+		bytesPerSecond = float64(bytes-runData.lastBytes) / elapsed
+	}
+	runData.lastSampleTime = now
+	runData.lastRecords = records
+	runData.lastBytes = bytes
+
+	return []action_kit_api.Metric{
+		*toFetchLoadMetric("kafka_fetch_load_records_per_second", recordsPerSecond, state, now),
+		*toFetchLoadMetric("kafka_fetch_load_bytes_per_second", bytesPerSecond, state, now),
+	}
+}
+
+func toFetchLoadMetric(name string, value float64, state *ConsumeFetchLoadState, now time.Time) *action_kit_api.Metric {
+	return new(action_kit_api.Metric{
+		Name: new(name),
+		Metric: map[string]string{
+			"topic":    state.Topic,
+			"fetchers": strconv.Itoa(state.Fetchers),
+			"id":       state.ClusterName + "-" + state.Topic,
+		},
+		Timestamp: now,
+		Value:     value,
+	})
+}
+
+func describeFetchStart(state *ConsumeFetchLoadState) string {
+	if state.StartFrom == fetchStartFromTimestamp {
+		return time.UnixMilli(state.StartTimestamp).UTC().Format(time.RFC3339)
+	}
+	return "the earliest offset"
+}
@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestConsumeFetchLoad_Describe(t *testing.T) {
+	//Given
+	action := consumeFetchLoadAction{}
+
+	//When
+	response := action.Describe()
+
+	//Then
+	assert.Equal(t, "Consume (Fetch Load)", response.Label)
+	assert.Equal(t, fmt.Sprintf("%s.consume-fetch-load", kafkaTopicTargetId), response.Id)
+	assert.Equal(t, kafkaTopicTargetId, response.TargetSelection.TargetType)
+	assert.Equal(t, new("Kafka"), response.Technology)
+	assert.Equal(t, action_kit_api.Attack, response.Kind)
+}
+
+func TestConsumeFetchLoad_Prepare(t *testing.T) {
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {
+			SeedBrokers: "localhost:9092",
+		},
+	})
+
+	tests := []struct {
+		name        string
+		config      map[string]any
+		wantedError string
+		wantedState *ConsumeFetchLoadState
+	}{
+		{
+			name: "Should start from earliest",
+			config: map[string]any{
+				"fetchers":  3,
+				"startFrom": fetchStartFromEarliest,
+				"duration":  10000,
+			},
+			wantedState: &ConsumeFetchLoadState{
+				Topic:               "steadybit",
+				Fetchers:            3,
+				StartFrom:           fetchStartFromEarliest,
+				RestartWhenCaughtUp: true,
+			},
+		},
+		{
+			name: "Should start from timestamp",
+			config: map[string]any{
+				"fetchers":            1,
+				"startFrom":           fetchStartFromTimestamp,
+				"startTimestamp":      "2025-01-31T12:00:00Z",
+				"restartWhenCaughtUp": false,
+				"fetchMaxBytes":       2048,
+				"duration":            10000,
+			},
+			wantedState: &ConsumeFetchLoadState{
+				Topic:          "steadybit",
+				Fetchers:       1,
+				StartFrom:      fetchStartFromTimestamp,
+				StartTimestamp: time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC).UnixMilli(),
+				FetchMaxBytes:  2048,
+			},
+		},
+		{
+			name: "Should return error for missing timestamp",
+			config: map[string]any{
+				"fetchers":  1,
+				"startFrom": fetchStartFromTimestamp,
+				"duration":  10000,
+			},
+			wantedError: "a start timestamp is required when starting from a timestamp",
+		},
+		{
+			name: "Should return error for zero fetchers",
+			config: map[string]any{
+				"fetchers": 0,
+				"duration": 10000,
+			},
+			wantedError: "number of fetchers must be greater than zero",
+		},
+		{
+			name: "Should return error for fetch max bytes exceeding int32",
+			config: map[string]any{
+				"fetchers":      1,
+				"fetchMaxBytes": int64(math.MaxInt32) + 1,
+				"duration":      10000,
+			},
+			wantedError: "fetchMaxBytes must be between 1024 and 2147483647",
+		},
+		{
+			name: "Should return error for too small fetch max partition bytes",
+			config: map[string]any{
+				"fetchers":               1,
+				"fetchMaxPartitionBytes": 512,
+				"duration":               10000,
+			},
+			wantedError: "fetchMaxPartitionBytes must be between 1024 and 2147483647",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//Given
+			action := consumeFetchLoadAction{}
+			state := action.NewEmptyState()
+			request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+				Target: &action_kit_api.Target{
+					Attributes: map[string][]string{
+						"kafka.topic.name":   {"steadybit"},
+						"kafka.cluster.name": {"test-cluster"},
+					},
+				},
+				Config:      tt.config,
+				ExecutionId: uuid.New(),
+			})
+
+			//When
+			_, err := action.Prepare(t.Context(), &state, request)
+
+			//Then
+			if tt.wantedError != "" {
+				assert.EqualError(t, err, tt.wantedError)
+			}
+			if tt.wantedState != nil {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantedState.Topic, state.Topic)
+				assert.Equal(t, tt.wantedState.Fetchers, state.Fetchers)
+				assert.Equal(t, tt.wantedState.StartFrom, state.StartFrom)
+				assert.Equal(t, tt.wantedState.StartTimestamp, state.StartTimestamp)
+				assert.Equal(t, tt.wantedState.RestartWhenCaughtUp, state.RestartWhenCaughtUp)
+				assert.Equal(t, tt.wantedState.FetchMaxBytes, state.FetchMaxBytes)
+				assert.Equal(t, []string{"localhost:9092"}, state.BrokerHosts)
+			}
+		})
+	}
+}
+
+func TestConsumeFetchLoad_sampleFetchLoadMetrics(t *testing.T) {
+	//Given
+	start := time.Now()
+	runData := &FetchLoadRunData{started: start, lastSampleTime: start}
+	runData.records.Add(200)
+	runData.bytes.Add(4000)
+	state := &ConsumeFetchLoadState{Topic: "steadybit", ClusterName: "test-cluster", Fetchers: 2}
+
+	//When
+	metrics := sampleFetchLoadMetrics(runData, state, start.Add(2*time.Second))
+
+	//Then
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "kafka_fetch_load_records_per_second", *metrics[0].Name)
+	assert.InDelta(t, 100, metrics[0].Value, 0.001)
+	assert.Equal(t, "kafka_fetch_load_bytes_per_second", *metrics[1].Name)
+	assert.InDelta(t, 2000, metrics[1].Value, 0.001)
+	assert.Equal(t, "test-cluster-steadybit", metrics[0].Metric["id"])
+}
+
+func TestConsumeFetchLoad_recordSize(t *testing.T) {
+	record := &kgo.Record{
+		Key:     []byte("key"),
+		Value:   []byte("value"),
+		Headers: []kgo.RecordHeader{{Key: "h", Value: []byte("v")}},
+	}
+	assert.Equal(t, uint64(10), recordSize(record))
+}
@@ -7,9 +7,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/rs/zerolog/log"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_commons"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
@@ -21,6 +23,13 @@ import (
 type kafkaConsumerGroupDiscovery struct {
 }
 
+// Group protocols as reported by the kafka.consumer-group.protocol attribute
+const (
+	consumerGroupProtocolClassic  = "classic"  // consumer groups rebalancing via JoinGroup/SyncGroup
+	consumerGroupProtocolConsumer = "consumer" // next-gen consumer groups (KIP-848)
+	consumerGroupProtocolShare    = "share"    // share groups (KIP-932)
+)
+
 var (
 	_ discovery_kit_sdk.TargetDescriber    = (*kafkaConsumerGroupDiscovery)(nil)
 	_ discovery_kit_sdk.AttributeDescriber = (*kafkaConsumerGroupDiscovery)(nil)
@@ -55,6 +64,7 @@ func (r *kafkaConsumerGroupDiscovery) DescribeTarget() discovery_kit_api.TargetD
 				{Attribute: "steadybit.label"},
 				{Attribute: "kafka.consumer-group.coordinator"},
 				{Attribute: "kafka.consumer-group.protocol-type"},
+				{Attribute: "kafka.consumer-group.protocol"},
 			},
 			OrderBy: []discovery_kit_api.OrderBy{
 				{
@@ -88,6 +98,13 @@ func (r *kafkaConsumerGroupDiscovery) DescribeAttributes() []discovery_kit_api.A
 				Other: "Kafka consumer group protocol types",
 			},
 		},
+		{
+			Attribute: "kafka.consumer-group.protocol",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka consumer group protocol",
+				Other: "Kafka consumer group protocols",
+			},
+		},
 		{
 			Attribute: "kafka.consumer-group.topics",
 			Label: discovery_kit_api.PluralLabel{
@@ -95,6 +112,34 @@ func (r *kafkaConsumerGroupDiscovery) DescribeAttributes() []discovery_kit_api.A
 				Other: "Kafka consumer group topics",
 			},
 		},
+		{
+			Attribute: "kafka.consumer-group.members",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka consumer group member count",
+				Other: "Kafka consumer group member counts",
+			},
+		},
+		{
+			Attribute: "kafka.consumer-group.streams.application-id",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka Streams application id",
+				Other: "Kafka Streams application ids",
+			},
+		},
+		{
+			Attribute: "kafka.consumer-group.streams.internal-topics",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka Streams internal topic",
+				Other: "Kafka Streams internal topics",
+			},
+		},
+		{
+			Attribute: "kafka.consumer-group.streams.threads",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka Streams thread count",
+				Other: "Kafka Streams thread counts",
+			},
+		},
 	}
 }
 
@@ -160,8 +205,44 @@ func discoverConsumerGroupsForCluster(ctx context.Context, clusterName string, c
 		return nil, fmt.Errorf("failed to describe consumer groups for cluster %s: %v", clusterName, err)
 	}
 
+	// Topics are only needed to detect Kafka Streams applications, the groups are still discovered without them
+	var topics []string
+	topicDetails, err := client.ListTopics(ctx)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to list topics for cluster %s, Kafka Streams applications aren't detected", clusterName)
+	} else {
+		topics = topicDetails.Names()
+	}
+
 	for _, group := range describedGroups.Sorted() {
-		result = append(result, toConsumerGroupTarget(group, clusterName, clusterConfig.ClusterID))
+		target := toConsumerGroupTarget(group, clusterName, clusterConfig.ClusterID)
+		addStreamsAttributes(target.Attributes, group.Group, topics, len(group.Members))
+		result = append(result, target)
+	}
+
+	// Next-gen consumer groups and share groups require Kafka 4.0+, older clusters reject the describe requests
+	consumerGroups, err := client.DescribeConsumerGroups(ctx)
+	if err != nil && !errors.As(err, &seList) {
+		log.Debug().Err(err).Msgf("Failed to describe next-gen consumer groups for cluster %s", clusterName)
+	}
+	for _, group := range consumerGroups.Sorted() {
+		if group.Err != nil || describedGroups[group.Group].Group != "" {
+			continue
+		}
+		target := toNextGenConsumerGroupTarget(group, clusterName, clusterConfig.ClusterID)
+		addStreamsAttributes(target.Attributes, group.Group, topics, len(group.Members))
+		result = append(result, target)
+	}
+
+	shareGroups, err := client.DescribeShareGroups(ctx)
+	if err != nil && !errors.As(err, &seList) {
+		log.Debug().Err(err).Msgf("Failed to describe share groups for cluster %s", clusterName)
+	}
+	for _, group := range shareGroups.Sorted() {
+		if group.Err != nil {
+			continue
+		}
+		result = append(result, toShareGroupTarget(group, clusterName, clusterConfig.ClusterID))
 	}
 
 	return result, nil
@@ -173,16 +254,30 @@ func getAllConsumerGroups(ctx context.Context) ([]discovery_kit_api.Target, erro
 }
 
 func toConsumerGroupTarget(group kadm.DescribedGroup, clusterName string, clusterID string) discovery_kit_api.Target {
-	id := fmt.Sprintf("%v-%s", group.Group, clusterName)
-	label := fmt.Sprintf("%v", group.Group)
+	return newConsumerGroupTarget(group.Group, clusterName, clusterID, group.Coordinator.Host, group.ProtocolType, consumerGroupProtocolClassic, group.AssignedPartitions().Topics(), len(group.Members))
+}
+
+func toNextGenConsumerGroupTarget(group kadm.DescribedConsumerGroup, clusterName string, clusterID string) discovery_kit_api.Target {
+	return newConsumerGroupTarget(group.Group, clusterName, clusterID, group.Coordinator.Host, "consumer", consumerGroupProtocolConsumer, group.AssignedPartitions().Topics(), len(group.Members))
+}
+
+func toShareGroupTarget(group kadm.DescribedShareGroup, clusterName string, clusterID string) discovery_kit_api.Target {
+	return newConsumerGroupTarget(group.GroupID, clusterName, clusterID, group.Coordinator.Host, "share", consumerGroupProtocolShare, group.AssignedPartitions().Topics(), len(group.Members))
+}
+
+func newConsumerGroupTarget(groupName string, clusterName string, clusterID string, coordinatorHost string, protocolType string, protocol string, topics []string, members int) discovery_kit_api.Target {
+	id := fmt.Sprintf("%v-%s", groupName, clusterName)
+	label := fmt.Sprintf("%v", groupName)
 
 	attributes := make(map[string][]string)
 	attributes["kafka.cluster.name"] = []string{clusterName}
 	attributes["kafka.cluster.id"] = []string{clusterID}
-	attributes["kafka.consumer-group.name"] = []string{fmt.Sprintf("%v", group.Group)}
-	attributes["kafka.consumer-group.coordinator"] = []string{fmt.Sprintf("%v", group.Coordinator.Host)}
-	attributes["kafka.consumer-group.protocol-type"] = []string{group.ProtocolType}
-	attributes["kafka.consumer-group.topics"] = group.AssignedPartitions().Topics()
+	attributes["kafka.consumer-group.name"] = []string{groupName}
+	attributes["kafka.consumer-group.coordinator"] = []string{coordinatorHost}
+	attributes["kafka.consumer-group.protocol-type"] = []string{protocolType}
+	attributes["kafka.consumer-group.protocol"] = []string{protocol}
+	attributes["kafka.consumer-group.topics"] = topics
+	attributes["kafka.consumer-group.members"] = []string{strconv.Itoa(members)}
 
 	return discovery_kit_api.Target{
 		Id:         id,
@@ -49,6 +49,12 @@ func TestDescribeAttributes(t *testing.T) {
 		"kafka.topic.partitions-replicas",
 		"kafka.topic.partitions-isr",
 		"kafka.topic.replication-factor",
+		"kafka.topic.min-insync-replicas",
+		"kafka.topic.broker-failures-tolerated",
+		"kafka.topic.unclean-leader-election",
+		"kafka.topic.rack-aware",
+		"kafka.topic.ephemeral",
+		"kafka.topic.streams-application-id",
 	}
 	require.Len(t, attrs, len(expected))
 	for _, want := range expected {
@@ -153,3 +159,35 @@ func TestDiscoverTargetsClusterName(t *testing.T) {
 		require.Equal(t, []string{"test"}, idValues)
 	}
 }
+
+func TestToConsumerGroupTargets(t *testing.T) {
+	//Given
+	coordinator := kadm.BrokerDetail{NodeID: 1, Host: "broker-1"}
+	classic := kadm.DescribedGroup{Group: "legacy", Coordinator: coordinator, ProtocolType: "consumer"}
+	nextGen := kadm.DescribedConsumerGroup{Group: "orders-service", Coordinator: coordinator, State: "Reconciling", Members: []kadm.ConsumerGroupMember{
+		{MemberID: "m1", Assignment: kadm.TopicsSet{"orders": {0: {}}}},
+	}}
+	share := kadm.DescribedShareGroup{GroupID: "workers", Coordinator: coordinator, GroupState: "Stable"}
+
+	//When
+	targets := []discovery_kit_api.Target{
+		toConsumerGroupTarget(classic, "cluster-1", "id-1"),
+		toNextGenConsumerGroupTarget(nextGen, "cluster-1", "id-1"),
+		toShareGroupTarget(share, "cluster-1", "id-1"),
+	}
+
+	//Then
+	assert.Equal(t, []string{consumerGroupProtocolClassic}, targets[0].Attributes["kafka.consumer-group.protocol"])
+	assert.Equal(t, []string{consumerGroupProtocolConsumer}, targets[1].Attributes["kafka.consumer-group.protocol"])
+	assert.Equal(t, []string{consumerGroupProtocolShare}, targets[2].Attributes["kafka.consumer-group.protocol"])
+	assert.Equal(t, "orders-service-cluster-1", targets[1].Id)
+	assert.Equal(t, []string{"orders"}, targets[1].Attributes["kafka.consumer-group.topics"])
+	assert.Equal(t, []string{"broker-1"}, targets[1].Attributes["kafka.consumer-group.coordinator"])
+	assert.Equal(t, []string{"share"}, targets[2].Attributes["kafka.consumer-group.protocol-type"])
+	assert.Equal(t, []string{"0"}, targets[0].Attributes["kafka.consumer-group.members"])
+	assert.Equal(t, []string{"1"}, targets[1].Attributes["kafka.consumer-group.members"])
+	for _, target := range targets {
+		assert.Equal(t, kafkaConsumerTargetId, target.TargetType)
+		assert.Equal(t, []string{"cluster-1"}, target.Attributes["kafka.cluster.name"])
+	}
+}
@@ -4,11 +4,15 @@
 package extkafka
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/steadybit/action-kit/go/action_kit_api/v2"
 	"github.com/steadybit/action-kit/go/action_kit_sdk"
@@ -22,13 +26,35 @@ import (
 type DeleteRecordsAttack struct{}
 
 type DeleteRecordsState struct {
-	TopicName   string
-	Partitions  []string
-	Offset      int64
-	BrokerHosts []string
-	ClusterName string // Cluster name for multi-cluster support
+	TopicName       string
+	Partitions      []string
+	AllPartitions   bool
+	Mode            string
+	Offset          int64
+	OlderThan       time.Duration // only used in the mode deleteRecordsModeOlderThan
+	BeforeTimestamp int64         // Unix milliseconds, only used in the modes deleteRecordsModeOlderThan and deleteRecordsModeBeforeTimestamp
+	Percentage      int64
+	BrokerHosts     []string
+	ClusterName     string // Cluster name for multi-cluster support
 }
 
+type deleteRecordsOffset struct {
+	Partition       int32
+	StartOffset     int64
+	EndOffset       int64
+	NewStartOffset  int64
+	LeaderEpoch     int32
+	DeletedRecords  int64
+	ResponseMessage string
+}
+
+const (
+	deleteRecordsModeNewest          = "newest"
+	deleteRecordsModeOlderThan       = "olderThan"
+	deleteRecordsModeBeforeTimestamp = "beforeTimestamp"
+	deleteRecordsModePercentage      = "percentage"
+)
+
 var _ action_kit_sdk.Action[DeleteRecordsState] = (*DeleteRecordsAttack)(nil)
 
 func NewDeleteRecordsAttack() action_kit_sdk.Action[DeleteRecordsState] {
@@ -43,7 +69,7 @@ func (k *DeleteRecordsAttack) Describe() action_kit_api.ActionDescription {
 	return action_kit_api.ActionDescription{
 		Id:          fmt.Sprintf("%s.delete-records", kafkaTopicTargetId),
 		Label:       "Trigger Delete Records",
-		Description: "Delete records from topic partitions by advancing the offset, simulating message loss for consumers. Records deleted this way are permanently inaccessible.",
+		Description: "Delete records from topic partitions by advancing the offset, simulating message loss for consumers. Records can be deleted relative to the newest offset, by age to model a retention misconfiguration, or by a percentage of each partition. Records deleted this way are permanently inaccessible.",
 		Version:     extbuild.GetSemverVersionStringOrUnknown(),
 		Icon:        new(kafkaIcon),
 		TargetSelection: new(action_kit_api.TargetSelection{
@@ -64,22 +90,75 @@ func (k *DeleteRecordsAttack) Describe() action_kit_api.ActionDescription {
 			{
 				Name:        "partitions",
 				Label:       "Partition to issue delete records requests",
-				Description: new("One or more partition IDs to delete records from. Only the selected partitions are affected."),
+				Description: new("One or more partition IDs to delete records from. Only the selected partitions are affected. Ignored if 'All partitions' is enabled."),
 				Type:        action_kit_api.ActionParameterTypeStringArray,
-				Required:    new(true),
 				Options: new([]action_kit_api.ParameterOption{
 					action_kit_api.ParameterOptionsFromTargetAttribute{
 						Attribute: "kafka.topic.partitions",
 					},
 				}),
 			},
+			{
+				Name:         "allPartitions",
+				Label:        "All partitions",
+				Description:  new("Delete records from all partitions of the topic."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("false"),
+			},
+			{
+				Name:         "mode",
+				Label:        "Delete",
+				Description:  new("Which records are deleted from every partition."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(deleteRecordsModeNewest),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "All but the X newest records",
+						Value: deleteRecordsModeNewest,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Records older than a duration",
+						Value: deleteRecordsModeOlderThan,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Records before a timestamp",
+						Value: deleteRecordsModeBeforeTimestamp,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "A percentage of the oldest records",
+						Value: deleteRecordsModePercentage,
+					},
+				}),
+				Required: new(true),
+			},
 			{
 				Label:        "X from newest Offset",
-				Description:  new("How many records to keep relative to the newest offset. 0 means advance to the latest offset, skipping all records. 10 means keep the 10 most recent records and delete everything before them. Applied per partition."),
+				Description:  new("Only for 'All but the X newest records'. How many records to keep relative to the newest offset. 0 means advance to the latest offset, skipping all records. 10 means keep the 10 most recent records and delete everything before them. Applied per partition."),
 				Name:         "offset",
 				Type:         action_kit_api.ActionParameterTypeInteger,
 				DefaultValue: new("0"),
-				Required:     new(true),
+			},
+			{
+				Label:        "Older than",
+				Description:  new("Only for 'Records older than a duration'. Records with a timestamp older than this duration are deleted, like a retention.ms of this duration would do."),
+				Name:         "olderThan",
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("1h"),
+			},
+			{
+				Label:       "Before timestamp",
+				Description: new("Only for 'Records before a timestamp'. Records with a timestamp before this one are deleted. Either an RFC 3339 timestamp (e.g. 2025-01-31T12:00:00Z) or Unix milliseconds."),
+				Name:        "beforeTimestamp",
+				Type:        action_kit_api.ActionParameterTypeString,
+			},
+			{
+				Label:        "Percentage",
+				Description:  new("Only for 'A percentage of the oldest records'. The percentage of the records currently in every partition that is deleted, starting with the oldest."),
+				Name:         "percentage",
+				Type:         action_kit_api.ActionParameterTypePercentage,
+				DefaultValue: new("50"),
+				MinValue:     new(1),
+				MaxValue:     new(100),
 			},
 		},
 	}
@@ -88,6 +167,7 @@ func (k *DeleteRecordsAttack) Describe() action_kit_api.ActionDescription {
 func (k *DeleteRecordsAttack) Prepare(_ context.Context, state *DeleteRecordsState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
 	state.TopicName = extutil.MustHaveValue(request.Target.Attributes, "kafka.topic.name")[0]
 	state.Partitions = extutil.ToStringArray(request.Config["partitions"])
+	state.AllPartitions = extutil.ToBool(request.Config["allPartitions"])
 	state.Offset = extutil.ToInt64(request.Config["offset"])
 
 	// Get cluster name from target
@@ -100,6 +180,33 @@ func (k *DeleteRecordsAttack) Prepare(_ context.Context, state *DeleteRecordsSta
 	state.ClusterName = clusterName
 	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
 
+	if !state.AllPartitions && len(state.Partitions) == 0 {
+		return nil, fmt.Errorf("select at least one partition or enable 'All partitions'")
+	}
+
+	state.Mode = extutil.ToString(request.Config["mode"])
+	switch state.Mode {
+	case deleteRecordsModeNewest, "":
+		state.Mode = deleteRecordsModeNewest
+	case deleteRecordsModeOlderThan:
+		state.OlderThan = time.Duration(extutil.ToInt64(request.Config["olderThan"])) * time.Millisecond
+		if state.OlderThan <= 0 {
+			return nil, fmt.Errorf("the duration records must be older than to be deleted must be greater than zero")
+		}
+	case deleteRecordsModeBeforeTimestamp:
+		state.BeforeTimestamp, err = parseTimestampMillis(extutil.ToString(request.Config["beforeTimestamp"]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp to delete records before: %w", err)
+		}
+	case deleteRecordsModePercentage:
+		state.Percentage = extutil.ToInt64(request.Config["percentage"])
+		if state.Percentage <= 0 || state.Percentage > 100 {
+			return nil, fmt.Errorf("the percentage of records to delete must be between 1 and 100")
+		}
+	default:
+		return nil, fmt.Errorf("unknown delete mode '%s'", state.Mode)
+	}
+
 	return nil, nil
 }
 
@@ -118,6 +225,13 @@ func (k *DeleteRecordsAttack) Start(ctx context.Context, state *DeleteRecordsSta
 	defer adminClient.Close()
 
 	// Get Current offset
+	startOffsets, err := adminClient.ListStartOffsets(ctx, state.TopicName)
+	if err != nil {
+		return nil, err
+	}
+	if startOffsets.Error() != nil {
+		return nil, startOffsets.Error()
+	}
 	endOffsets, err := adminClient.ListEndOffsets(ctx, state.TopicName)
 	if err != nil {
 		return nil, err
@@ -125,40 +239,68 @@ func (k *DeleteRecordsAttack) Start(ctx context.Context, state *DeleteRecordsSta
 	if endOffsets.Error() != nil {
 		return nil, endOffsets.Error()
 	}
-
-	var logMessages []string
-	// For each partition, fetch the offset
-	for _, partition := range state.Partitions {
-		var partitionInt int64
-		partitionInt, err = strconv.ParseInt(partition, 10, 64)
+	// The age is relative to the start of the attack, not to its preparation
+	if state.Mode == deleteRecordsModeOlderThan {
+		state.BeforeTimestamp = time.Now().Add(-state.OlderThan).UnixMilli()
+	}
+	var timestampOffsets kadm.ListedOffsets
+	if state.Mode == deleteRecordsModeOlderThan || state.Mode == deleteRecordsModeBeforeTimestamp {
+		timestampOffsets, err = adminClient.ListOffsetsAfterMilli(ctx, state.BeforeTimestamp, state.TopicName)
 		if err != nil {
-			return nil, extension_kit.ToError(fmt.Sprintf("Failed to convert partition %s to int32", partition), err)
+			return nil, err
 		}
-
-		endOffset, found := endOffsets.Lookup(state.TopicName, int32(partitionInt))
-		if !found {
-			return nil, extension_kit.ToError(fmt.Sprintf("Failed to find offset for topic %s and partition %s", state.TopicName, partition), nil)
+		if timestampOffsets.Error() != nil {
+			return nil, timestampOffsets.Error()
 		}
+	}
 
-		newOffsets := kadm.Offsets{}
-		newOffset := max(endOffset.Offset-state.Offset, 0)
-		newOffsets.Add(kadm.Offset{Topic: endOffset.Topic, Partition: endOffset.Partition, LeaderEpoch: endOffset.LeaderEpoch, At: newOffset})
+	partitions, err := deleteRecordsPartitions(state, endOffsets)
+	if err != nil {
+		return nil, err
+	}
 
-		var offsetResponses kadm.DeleteRecordsResponses
-		offsetResponses, err = adminClient.DeleteRecords(ctx, newOffsets)
+	offsets := make([]deleteRecordsOffset, 0, len(partitions))
+	newOffsets := kadm.Offsets{}
+	for _, partition := range partitions {
+		offset, err := computeDeleteRecordsOffset(state, partition, startOffsets, endOffsets, timestampOffsets)
 		if err != nil {
 			return nil, err
 		}
+		offsets = append(offsets, offset)
+		newOffsets.Add(kadm.Offset{Topic: state.TopicName, Partition: partition, LeaderEpoch: offset.LeaderEpoch, At: offset.NewStartOffset})
+	}
 
-		for _, newOffsetResponse := range offsetResponses.Sorted() {
-			if newOffsetResponse.Err != nil {
-				errs = append(errs, newOffsetResponse.Err)
-			}
+	offsetResponses, err := adminClient.DeleteRecords(ctx, newOffsets)
+	if err != nil {
+		return nil, err
+	}
+
+	var logMessages []string
+	for i, offset := range offsets {
+		response, found := offsetResponses.Lookup(state.TopicName, offset.Partition)
+		if !found {
+			response.Err = fmt.Errorf("no result in the delete records response")
 		}
-		if len(errs) > 0 {
-			return nil, errors.Join(errs...)
+		if response.Err != nil {
+			errs = append(errs, fmt.Errorf("partition %d: %w", offset.Partition, response.Err))
+			offsets[i].ResponseMessage = response.Err.Error()
+			continue
 		}
-		logMessages = append(logMessages, fmt.Sprintf("Trigger delete records for topic %s for partition %v, moving offset at %d", state.TopicName, partition, newOffset))
+		logMessages = append(logMessages, fmt.Sprintf("Trigger delete records for topic %s for partition %d, moving offset at %d", state.TopicName, offset.Partition, offset.NewStartOffset))
+	}
+
+	artifact, err := toDeleteRecordsArtifact(state.TopicName, offsets)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return &action_kit_api.StartResult{
+			Error: &action_kit_api.ActionKitError{
+				Title:  fmt.Sprintf("Failed to delete records of topic %s", state.TopicName),
+				Detail: new(errors.Join(errs...).Error()),
+			},
+			Artifacts: new([]action_kit_api.Artifact{artifact}),
+		}, nil
 	}
 
 	return &action_kit_api.StartResult{
@@ -166,6 +308,87 @@ func (k *DeleteRecordsAttack) Start(ctx context.Context, state *DeleteRecordsSta
 			Level:   extutil.Ptr(action_kit_api.Info),
 			Message: strings.Join(logMessages, "\n"),
 		}},
+		Artifacts: new([]action_kit_api.Artifact{artifact}),
+	}, nil
+
+}
+
+// deleteRecordsPartitions returns the selected partitions, or all partitions of the topic.
+func deleteRecordsPartitions(state *DeleteRecordsState, endOffsets kadm.ListedOffsets) ([]int32, error) {
+	var partitions []int32
+	if state.AllPartitions {
+		endOffsets.Each(func(offset kadm.ListedOffset) {
+			partitions = append(partitions, offset.Partition)
+		})
+		return partitions, nil
+	}
+	for _, partition := range state.Partitions {
+		partitionInt, err := strconv.ParseInt(partition, 10, 32)
+		if err != nil {
+			return nil, extension_kit.ToError(fmt.Sprintf("Failed to convert partition %s to int32", partition), err)
+		}
+		partitions = append(partitions, int32(partitionInt))
+	}
+	return partitions, nil
+}
+
+// computeDeleteRecordsOffset computes the offset the records of the partition are deleted before, according to the mode.
+func computeDeleteRecordsOffset(state *DeleteRecordsState, partition int32, startOffsets, endOffsets, timestampOffsets kadm.ListedOffsets) (deleteRecordsOffset, error) {
+	endOffset, found := endOffsets.Lookup(state.TopicName, partition)
+	if !found {
+		return deleteRecordsOffset{}, extension_kit.ToError(fmt.Sprintf("Failed to find offset for topic %s and partition %d", state.TopicName, partition), nil)
+	}
+	startOffset := int64(0)
+	if listed, found := startOffsets.Lookup(state.TopicName, partition); found {
+		startOffset = listed.Offset
+	}
+
+	var newOffset int64
+	switch state.Mode {
+	case deleteRecordsModeOlderThan, deleteRecordsModeBeforeTimestamp:
+		// Without records after the timestamp, the end offset is listed and all records are deleted
+		timestampOffset, found := timestampOffsets.Lookup(state.TopicName, partition)
+		if !found {
+			return deleteRecordsOffset{}, extension_kit.ToError(fmt.Sprintf("Failed to find offset at timestamp %d for topic %s and partition %d", state.BeforeTimestamp, state.TopicName, partition), nil)
+		}
+		newOffset = timestampOffset.Offset
+	case deleteRecordsModePercentage:
+		newOffset = startOffset + (endOffset.Offset-startOffset)*state.Percentage/100
+	default:
+		newOffset = endOffset.Offset - state.Offset
+	}
+	newOffset = min(max(newOffset, startOffset, 0), endOffset.Offset)
+
+	return deleteRecordsOffset{
+		Partition:      partition,
+		StartOffset:    startOffset,
+		EndOffset:      endOffset.Offset,
+		NewStartOffset: newOffset,
+		LeaderEpoch:    endOffset.LeaderEpoch,
+		DeletedRecords: newOffset - startOffset,
 	}, nil
+}
 
+func toDeleteRecordsArtifact(topic string, offsets []deleteRecordsOffset) (action_kit_api.Artifact, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	rows := [][]string{{"topic", "partition", "start_offset", "end_offset", "new_start_offset", "deleted_records", "error"}}
+	for _, offset := range offsets {
+		rows = append(rows, []string{
+			topic,
+			strconv.Itoa(int(offset.Partition)),
+			strconv.FormatInt(offset.StartOffset, 10),
+			strconv.FormatInt(offset.EndOffset, 10),
+			strconv.FormatInt(offset.NewStartOffset, 10),
+			strconv.FormatInt(offset.DeletedRecords, 10),
+			offset.ResponseMessage,
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return action_kit_api.Artifact{}, fmt.Errorf("failed to write delete records offsets: %w", err)
+	}
+	return action_kit_api.Artifact{
+		Label: "delete-records-offsets.csv",
+		Data:  base64.StdEncoding.EncodeToString(buffer.Bytes()),
+	}, nil
 }
@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestDeleteRecords_Prepare(t *testing.T) {
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {
+			SeedBrokers: "localhost:9092",
+		},
+	})
+
+	tests := []struct {
+		name        string
+		config      map[string]any
+		wantedError string
+		assert      func(t *testing.T, state DeleteRecordsState)
+	}{
+		{
+			name:   "defaults to the newest mode",
+			config: map[string]any{"partitions": []string{"0", "1"}, "offset": 10},
+			assert: func(t *testing.T, state DeleteRecordsState) {
+				assert.Equal(t, deleteRecordsModeNewest, state.Mode)
+				assert.Equal(t, []string{"0", "1"}, state.Partitions)
+				assert.Equal(t, int64(10), state.Offset)
+			},
+		},
+		{
+			name:   "older than a duration",
+			config: map[string]any{"allPartitions": true, "mode": deleteRecordsModeOlderThan, "olderThan": 3600000},
+			assert: func(t *testing.T, state DeleteRecordsState) {
+				assert.True(t, state.AllPartitions)
+				assert.Equal(t, time.Hour, state.OlderThan)
+				assert.Zero(t, state.BeforeTimestamp)
+			},
+		},
+		{
+			name:   "before a timestamp",
+			config: map[string]any{"allPartitions": true, "mode": deleteRecordsModeBeforeTimestamp, "beforeTimestamp": "2025-01-31T12:00:00Z"},
+			assert: func(t *testing.T, state DeleteRecordsState) {
+				assert.Equal(t, int64(1738324800000), state.BeforeTimestamp)
+			},
+		},
+		{
+			name:        "invalid percentage",
+			config:      map[string]any{"allPartitions": true, "mode": deleteRecordsModePercentage, "percentage": 0},
+			wantedError: "percentage",
+		},
+		{
+			name:        "no partitions",
+			config:      map[string]any{"mode": deleteRecordsModeNewest},
+			wantedError: "at least one partition",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//Given
+			action := DeleteRecordsAttack{}
+			state := action.NewEmptyState()
+			request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+				Target: &action_kit_api.Target{
+					Attributes: map[string][]string{
+						"kafka.topic.name":   {"orders"},
+						"kafka.cluster.name": {"test-cluster"},
+					},
+				},
+				Config:      tt.config,
+				ExecutionId: uuid.New(),
+			})
+
+			//When
+			_, err := action.Prepare(t.Context(), &state, request)
+
+			//Then
+			if tt.wantedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantedError)
+				return
+			}
+			require.NoError(t, err)
+			tt.assert(t, state)
+		})
+	}
+}
+
+func TestDeleteRecords_computeDeleteRecordsOffset(t *testing.T) {
+	startOffsets := kadm.ListedOffsets{"orders": {0: {Topic: "orders", Partition: 0, Offset: 100}}}
+	endOffsets := kadm.ListedOffsets{"orders": {0: {Topic: "orders", Partition: 0, Offset: 300, LeaderEpoch: 4}}}
+	timestampOffsets := kadm.ListedOffsets{"orders": {0: {Topic: "orders", Partition: 0, Offset: 250}}}
+
+	tests := []struct {
+		name   string
+		state  DeleteRecordsState
+		wanted int64
+	}{
+		{name: "newest", state: DeleteRecordsState{Mode: deleteRecordsModeNewest, Offset: 20}, wanted: 280},
+		{name: "newest keeps more than present", state: DeleteRecordsState{Mode: deleteRecordsModeNewest, Offset: 1000}, wanted: 100},
+		{name: "older than", state: DeleteRecordsState{Mode: deleteRecordsModeOlderThan}, wanted: 250},
+		{name: "percentage", state: DeleteRecordsState{Mode: deleteRecordsModePercentage, Percentage: 25}, wanted: 150},
+		{name: "all by percentage", state: DeleteRecordsState{Mode: deleteRecordsModePercentage, Percentage: 100}, wanted: 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.state.TopicName = "orders"
+
+			offset, err := computeDeleteRecordsOffset(&tt.state, 0, startOffsets, endOffsets, timestampOffsets)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wanted, offset.NewStartOffset)
+			assert.Equal(t, tt.wanted-100, offset.DeletedRecords)
+			assert.Equal(t, int32(4), offset.LeaderEpoch)
+		})
+	}
+
+	_, err := computeDeleteRecordsOffset(&DeleteRecordsState{TopicName: "orders"}, 1, startOffsets, endOffsets, timestampOffsets)
+	assert.Error(t, err)
+}
+
+func TestDeleteRecords_deleteRecordsPartitions(t *testing.T) {
+	endOffsets := kadm.ListedOffsets{"orders": {
+		0: {Topic: "orders", Partition: 0},
+		1: {Topic: "orders", Partition: 1},
+		2: {Topic: "orders", Partition: 2},
+	}}
+
+	partitions, err := deleteRecordsPartitions(&DeleteRecordsState{AllPartitions: true, Partitions: []string{"1"}}, endOffsets)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int32{0, 1, 2}, partitions)
+
+	partitions, err = deleteRecordsPartitions(&DeleteRecordsState{Partitions: []string{"2", "0"}}, endOffsets)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{2, 0}, partitions)
+
+	_, err = deleteRecordsPartitions(&DeleteRecordsState{Partitions: []string{"first"}}, endOffsets)
+	assert.Error(t, err)
+}
+
+func TestDeleteRecords_toDeleteRecordsArtifact(t *testing.T) {
+	artifact, err := toDeleteRecordsArtifact("orders", []deleteRecordsOffset{
+		{Partition: 0, StartOffset: 100, EndOffset: 300, NewStartOffset: 250, DeletedRecords: 150},
+	})
+	require.NoError(t, err)
+
+	data, err := base64.StdEncoding.DecodeString(artifact.Data)
+	require.NoError(t, err)
+	assert.Equal(t, "delete-records-offsets.csv", artifact.Label)
+	assert.Equal(t, "topic,partition,start_offset,end_offset,new_start_offset,deleted_records,error\norders,0,100,300,250,150,\n", string(data))
+}
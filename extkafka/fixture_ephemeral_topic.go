@@ -0,0 +1,337 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+type EphemeralTopicAction struct{}
+
+type EphemeralTopicState struct {
+	Topic             string
+	Partitions        int32
+	ReplicationFactor int16
+	Configs           map[string]string
+	Racks             []string
+	ReplicaAssignment [][]int32 // Replicas per partition, only set when the replicas are placed on racks
+	Created           bool      // Whether the topic was created by this execution and must be deleted
+	ExecutionID       string
+	BrokerHosts       []string
+	ClusterName       string // Cluster name for multi-cluster support
+}
+
+// ephemeralTopicPrefix starts the name of every ephemeral topic. Topic discovery uses it to mark these topics, also
+// when they were left over by an extension instance that is gone.
+const ephemeralTopicPrefix = "steadybit-ephemeral-"
+
+// Make sure Action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[EphemeralTopicState]         = (*EphemeralTopicAction)(nil)
+	_ action_kit_sdk.ActionWithStop[EphemeralTopicState] = (*EphemeralTopicAction)(nil)
+)
+
+func NewEphemeralTopicAction() action_kit_sdk.Action[EphemeralTopicState] {
+	return &EphemeralTopicAction{}
+}
+
+func (k *EphemeralTopicAction) NewEmptyState() EphemeralTopicState {
+	return EphemeralTopicState{}
+}
+
+func (k *EphemeralTopicAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.ephemeral-topic", kafkaClusterTargetId),
+		Label:       "Create Ephemeral Topic",
+		Description: "Create a topic for the duration of the experiment and delete it when the action ends, so that produce and delivery experiments don't need a pre-created topic and don't touch existing topics of shared clusters. The topic name starts with " + ephemeralTopicPrefix + " and the topic is marked with the attribute kafka.topic.ephemeral in the topic discovery.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          kafkaClusterTargetId,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionExactlyOne),
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "by cluster name",
+					Description: new("Find cluster by name"),
+					Query:       "kafka.cluster.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		TimeControl: action_kit_api.TimeControlExternal,
+		Kind:        action_kit_api.Other,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Label:        "Duration",
+				Description:  new("How long the topic exists. The topic is deleted when the duration expires."),
+				Name:         "duration",
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("300s"),
+				Required:     new(true),
+			},
+			{
+				Label:       "Topic",
+				Description: new("Optional. The name of the topic, prefixed with " + ephemeralTopicPrefix + " if it doesn't start with it. If empty, the name is generated from the execution id. The topic must not exist yet."),
+				Name:        "topic",
+				Type:        action_kit_api.ActionParameterTypeString,
+			},
+			{
+				Label:        "Partitions",
+				Description:  new("The number of partitions of the topic."),
+				Name:         "partitions",
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("3"),
+				MinValue:     new(1),
+				Required:     new(true),
+			},
+			{
+				Label:        "Replication factor",
+				Description:  new("The number of replicas of every partition."),
+				Name:         "replicationFactor",
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("3"),
+				MinValue:     new(1),
+				Required:     new(true),
+			},
+			{
+				Label:       "Topic configuration",
+				Description: new("Optional. Configuration overrides of the topic, e.g. min.insync.replicas=2 or cleanup.policy=compact."),
+				Name:        "configs",
+				Type:        action_kit_api.ActionParameterTypeKeyValue,
+			},
+			{
+				Label:       "Racks",
+				Description: new("Optional. Only place replicas on brokers in these racks (broker.rack). The replicas of every partition are spread across the racks. If empty, the cluster places the replicas."),
+				Name:        "racks",
+				Type:        action_kit_api.ActionParameterTypeStringArray,
+			},
+		},
+		Stop: new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (k *EphemeralTopicAction) Prepare(ctx context.Context, state *EphemeralTopicState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+	state.ExecutionID = request.ExecutionId.String()
+
+	state.Topic = ephemeralTopicName(strings.TrimSpace(extutil.ToString(request.Config["topic"])), state.ExecutionID)
+	state.Partitions = extutil.ToInt32(request.Config["partitions"])
+	if state.Partitions <= 0 {
+		return nil, fmt.Errorf("number of partitions must be greater than zero")
+	}
+	state.ReplicationFactor = int16(extutil.ToInt(request.Config["replicationFactor"]))
+	if state.ReplicationFactor <= 0 {
+		return nil, fmt.Errorf("replication factor must be greater than zero")
+	}
+	state.Configs, err = extutil.ToKeyValue(request.Config, "configs")
+	if err != nil {
+		return nil, fmt.Errorf("invalid topic configuration: %w", err)
+	}
+	state.Racks = extutil.ToStringArray(request.Config["racks"])
+
+	adminClient, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer adminClient.Close()
+
+	topicDetails, err := adminClient.ListTopics(ctx, state.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check whether topic %s exists: %w", state.Topic, err)
+	}
+	if topicDetails.Has(state.Topic) {
+		return nil, fmt.Errorf("topic %s already exists, an ephemeral topic must not exist yet", state.Topic)
+	}
+
+	if len(state.Racks) > 0 {
+		metadata, err := adminClient.BrokerMetadata(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get brokers metadata: %w", err)
+		}
+		state.ReplicaAssignment, err = assignReplicasToRacks(metadata.Brokers, state.Racks, state.Partitions, state.ReplicationFactor)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (k *EphemeralTopicAction) Start(ctx context.Context, state *EphemeralTopicState) (*action_kit_api.StartResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	client, err := createNewClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	response, err := toCreateTopicsRequest(state).RequestWith(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create topic %s: %w", state.Topic, err)
+	}
+	for _, topic := range response.Topics {
+		if err := kerr.ErrorForCode(topic.ErrorCode); err != nil {
+			return nil, fmt.Errorf("failed to create topic %s: %s %s", state.Topic, err.Error(), extutil.ToString(topic.ErrorMessage))
+		}
+	}
+	state.Created = true
+	log.Info().Msgf("Created ephemeral topic %s in cluster %s", state.Topic, state.ClusterName)
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Created ephemeral topic %s with %d partition(s) and replication factor %d", state.Topic, state.Partitions, state.ReplicationFactor),
+		}},
+	}, nil
+}
+
+func (k *EphemeralTopicAction) Stop(ctx context.Context, state *EphemeralTopicState) (*action_kit_api.StopResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	// Never delete a topic this execution didn't create
+	if !state.Created {
+		return nil, nil
+	}
+
+	adminClient, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer adminClient.Close()
+
+	response, err := adminClient.DeleteTopic(ctx, state.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete topic %s: %w", state.Topic, err)
+	}
+	if response.Err != nil && !errors.Is(response.Err, kerr.UnknownTopicOrPartition) {
+		return nil, fmt.Errorf("failed to delete topic %s: %s %s", state.Topic, response.Err.Error(), response.ErrMessage)
+	}
+
+	return &action_kit_api.StopResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Deleted ephemeral topic %s", state.Topic),
+		}},
+	}, nil
+}
+
+// ephemeralTopicName prefixes the topic with ephemeralTopicPrefix, an empty topic is named after the execution.
+func ephemeralTopicName(topic string, executionID string) string {
+	if topic == "" {
+		topic = executionID[:8]
+	}
+	if !strings.HasPrefix(topic, ephemeralTopicPrefix) {
+		topic = ephemeralTopicPrefix + topic
+	}
+	return topic
+}
+
+func toCreateTopicsRequest(state *EphemeralTopicState) *kmsg.CreateTopicsRequest {
+	topic := kmsg.NewCreateTopicsRequestTopic()
+	topic.Topic = state.Topic
+	if len(state.ReplicaAssignment) > 0 {
+		// Partitions and replication factor must be -1 if the replicas are assigned manually
+		topic.NumPartitions = -1
+		topic.ReplicationFactor = -1
+		for partition, replicas := range state.ReplicaAssignment {
+			assignment := kmsg.NewCreateTopicsRequestTopicReplicaAssignment()
+			assignment.Partition = int32(partition)
+			assignment.Replicas = replicas
+			topic.ReplicaAssignment = append(topic.ReplicaAssignment, assignment)
+		}
+	} else {
+		topic.NumPartitions = state.Partitions
+		topic.ReplicationFactor = state.ReplicationFactor
+	}
+	configNames := make([]string, 0, len(state.Configs))
+	for name := range state.Configs {
+		configNames = append(configNames, name)
+	}
+	slices.Sort(configNames)
+	for _, name := range configNames {
+		topicConfig := kmsg.NewCreateTopicsRequestTopicConfig()
+		topicConfig.Name = name
+		topicConfig.Value = new(state.Configs[name])
+		topic.Configs = append(topic.Configs, topicConfig)
+	}
+
+	req := kmsg.NewPtrCreateTopicsRequest()
+	req.TimeoutMillis = 30000
+	req.Topics = append(req.Topics, topic)
+	return req
+}
+
+// assignReplicasToRacks assigns the replicas of every partition to brokers in the given racks. The brokers are ordered
+// alternating between the racks, so that consecutive replicas of a partition are placed in different racks.
+func assignReplicasToRacks(brokers kadm.BrokerDetails, racks []string, partitions int32, replicationFactor int16) ([][]int32, error) {
+	brokersByRack := make(map[string][]int32)
+	for _, broker := range brokers {
+		if broker.Rack != nil && slices.Contains(racks, *broker.Rack) {
+			brokersByRack[*broker.Rack] = append(brokersByRack[*broker.Rack], broker.NodeID)
+		}
+	}
+	rackNames := make([]string, 0, len(brokersByRack))
+	for rack, nodeIDs := range brokersByRack {
+		slices.Sort(nodeIDs)
+		rackNames = append(rackNames, rack)
+	}
+	slices.Sort(rackNames)
+
+	var ordered []int32
+	for i := 0; len(ordered) < countBrokers(brokersByRack); i++ {
+		for _, rack := range rackNames {
+			if i < len(brokersByRack[rack]) {
+				ordered = append(ordered, brokersByRack[rack][i])
+			}
+		}
+	}
+	if len(ordered) < int(replicationFactor) {
+		return nil, fmt.Errorf("only %d broker(s) in the racks %s, at least %d are required for replication factor %d", len(ordered), strings.Join(racks, ", "), replicationFactor, replicationFactor)
+	}
+
+	assignment := make([][]int32, partitions)
+	for partition := range partitions {
+		replicas := make([]int32, replicationFactor)
+		for replica := range replicationFactor {
+			replicas[replica] = ordered[(int(partition)+int(replica))%len(ordered)]
+		}
+		assignment[partition] = replicas
+	}
+	return assignment, nil
+}
+
+func countBrokers(brokersByRack map[string][]int32) int {
+	count := 0
+	for _, nodeIDs := range brokersByRack {
+		count += len(nodeIDs)
+	}
+	return count
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestEphemeralTopic_Describe(t *testing.T) {
+	//Given
+	action := EphemeralTopicAction{}
+
+	//When
+	response := action.Describe()
+
+	//Then
+	assert.Equal(t, "Create Ephemeral Topic", response.Label)
+	assert.Equal(t, "com.steadybit.extension_kafka.cluster.ephemeral-topic", response.Id)
+	require.NotNil(t, response.TargetSelection)
+	assert.Equal(t, kafkaClusterTargetId, response.TargetSelection.TargetType)
+	assert.Equal(t, new("Kafka"), response.Technology)
+}
+
+func TestEphemeralTopic_assignReplicasToRacks(t *testing.T) {
+	brokers := kadm.BrokerDetails{
+		{NodeID: 1, Rack: new("eu-1a")},
+		{NodeID: 2, Rack: new("eu-1b")},
+		{NodeID: 3, Rack: new("eu-1c")},
+		{NodeID: 4, Rack: new("eu-1a")},
+		{NodeID: 5, Rack: new("eu-1b")},
+		{NodeID: 6},
+	}
+
+	t.Run("spreads replicas across racks", func(t *testing.T) {
+		assignment, err := assignReplicasToRacks(brokers, []string{"eu-1a", "eu-1b"}, 3, 2)
+		require.NoError(t, err)
+		assert.Equal(t, [][]int32{{1, 2}, {2, 4}, {4, 5}}, assignment)
+	})
+
+	t.Run("not enough brokers in racks", func(t *testing.T) {
+		_, err := assignReplicasToRacks(brokers, []string{"eu-1c"}, 3, 2)
+		assert.ErrorContains(t, err, "only 1 broker(s)")
+	})
+}
+
+func TestEphemeralTopic_toCreateTopicsRequest(t *testing.T) {
+	req := toCreateTopicsRequest(&EphemeralTopicState{
+		Topic:             "steadybit-ephemeral",
+		Partitions:        2,
+		ReplicationFactor: 3,
+		Configs:           map[string]string{"min.insync.replicas": "2", "cleanup.policy": "compact"},
+	})
+	require.Len(t, req.Topics, 1)
+	assert.Equal(t, int32(2), req.Topics[0].NumPartitions)
+	assert.Equal(t, int16(3), req.Topics[0].ReplicationFactor)
+	require.Len(t, req.Topics[0].Configs, 2)
+	assert.Equal(t, "cleanup.policy", req.Topics[0].Configs[0].Name)
+	assert.Equal(t, new("compact"), req.Topics[0].Configs[0].Value)
+
+	req = toCreateTopicsRequest(&EphemeralTopicState{
+		Topic:             "steadybit-ephemeral",
+		Partitions:        2,
+		ReplicationFactor: 2,
+		ReplicaAssignment: [][]int32{{1, 2}, {2, 1}},
+	})
+	assert.Equal(t, int32(-1), req.Topics[0].NumPartitions)
+	assert.Equal(t, int16(-1), req.Topics[0].ReplicationFactor)
+	require.Len(t, req.Topics[0].ReplicaAssignment, 2)
+	assert.Equal(t, []int32{2, 1}, req.Topics[0].ReplicaAssignment[1].Replicas)
+}
+
+func TestEphemeralTopic_ephemeralTopicName(t *testing.T) {
+	assert.Equal(t, "steadybit-ephemeral-0f8e7d6c", ephemeralTopicName("", "0f8e7d6c-5b4a-4321-9876-543210fedcba"))
+	assert.Equal(t, "steadybit-ephemeral-orders", ephemeralTopicName("orders", "0f8e7d6c-5b4a-4321-9876-543210fedcba"))
+	assert.Equal(t, "steadybit-ephemeral-orders", ephemeralTopicName("steadybit-ephemeral-orders", "0f8e7d6c-5b4a-4321-9876-543210fedcba"))
+}
+
+func TestEphemeralTopic_addEphemeralTopicAttributes(t *testing.T) {
+	attributes := map[string][]string{}
+	addEphemeralTopicAttributes(attributes, "steadybit-ephemeral-0f8e7d6c")
+	assert.Equal(t, []string{"true"}, attributes["kafka.topic.ephemeral"])
+
+	attributes = map[string][]string{}
+	addEphemeralTopicAttributes(attributes, "orders")
+	assert.NotContains(t, attributes, "kafka.topic.ephemeral")
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// The KRaft controller quorum replicates the metadata log in this single partition topic
+const kraftMetadataTopic = "__cluster_metadata"
+
+type quorumInfo struct {
+	LeaderID      int32
+	LeaderEpoch   int32
+	HighWatermark int64
+	Voters        []quorumReplica
+	Observers     []quorumReplica
+}
+
+type quorumReplica struct {
+	ReplicaID    int32
+	LogEndOffset int64 // -1 if unknown
+}
+
+// Lag returns how many records the replica is behind the high watermark, or -1 if unknown.
+func (q quorumInfo) Lag(replica quorumReplica) int64 {
+	if replica.LogEndOffset < 0 || q.HighWatermark < 0 {
+		return -1
+	}
+	return max(q.HighWatermark-replica.LogEndOffset, 0)
+}
+
+// describeQuorum describes the KRaft controller quorum. The request fails on clusters running in ZooKeeper mode.
+func describeQuorum(ctx context.Context, client *kgo.Client) (*quorumInfo, error) {
+	partition := kmsg.NewDescribeQuorumRequestTopicPartition()
+	partition.Partition = 0
+	topic := kmsg.NewDescribeQuorumRequestTopic()
+	topic.Topic = kraftMetadataTopic
+	topic.Partitions = append(topic.Partitions, partition)
+	req := kmsg.NewPtrDescribeQuorumRequest()
+	req.Topics = append(req.Topics, topic)
+
+	resp, err := req.RequestWith(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	for _, t := range resp.Topics {
+		for _, p := range t.Partitions {
+			if err := kerr.ErrorForCode(p.ErrorCode); err != nil {
+				return nil, err
+			}
+			return toQuorumInfo(p), nil
+		}
+	}
+	return nil, fmt.Errorf("the response doesn't contain the quorum of %s", kraftMetadataTopic)
+}
+
+func toQuorumInfo(partition kmsg.DescribeQuorumResponseTopicPartition) *quorumInfo {
+	info := &quorumInfo{
+		LeaderID:      partition.LeaderID,
+		LeaderEpoch:   partition.LeaderEpoch,
+		HighWatermark: partition.HighWatermark,
+	}
+	for _, voter := range partition.CurrentVoters {
+		info.Voters = append(info.Voters, quorumReplica{ReplicaID: voter.ReplicaID, LogEndOffset: voter.LogEndOffset})
+	}
+	for _, observer := range partition.Observers {
+		info.Observers = append(info.Observers, quorumReplica{ReplicaID: observer.ReplicaID, LogEndOffset: observer.LogEndOffset})
+	}
+	return info
+}
+
+func addQuorumAttributes(attributes map[string][]string, info *quorumInfo) {
+	voters := make([]string, 0, len(info.Voters))
+	voterLags := make([]string, 0, len(info.Voters))
+	for _, voter := range info.Voters {
+		voters = append(voters, strconv.Itoa(int(voter.ReplicaID)))
+		voterLags = append(voterLags, fmt.Sprintf("%d->lag=%d", voter.ReplicaID, info.Lag(voter)))
+	}
+	observers := make([]string, 0, len(info.Observers))
+	for _, observer := range info.Observers {
+		observers = append(observers, strconv.Itoa(int(observer.ReplicaID)))
+	}
+
+	attributes["kafka.cluster.quorum-leader-id"] = []string{strconv.Itoa(int(info.LeaderID))}
+	attributes["kafka.cluster.quorum-leader-epoch"] = []string{strconv.Itoa(int(info.LeaderEpoch))}
+	attributes["kafka.cluster.quorum-high-watermark"] = []string{strconv.FormatInt(info.HighWatermark, 10)}
+	attributes["kafka.cluster.quorum-voters"] = voters
+	attributes["kafka.cluster.quorum-voter-lag"] = voterLags
+	if len(observers) > 0 {
+		attributes["kafka.cluster.quorum-observers"] = observers
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+const (
+	partitionHealthUnderReplicated = "under-replicated"
+	partitionHealthUnderMinIsr     = "under-min-isr"
+	partitionHealthOffline         = "offline"
+)
+
+// partitionHealth holds the unhealthy partitions, formatted as "topic-partition", by kind.
+type partitionHealth struct {
+	UnderReplicated []string
+	UnderMinIsr     []string
+	Offline         []string
+}
+
+func (h partitionHealth) byKind() map[string][]string {
+	return map[string][]string{
+		partitionHealthUnderReplicated: h.UnderReplicated,
+		partitionHealthUnderMinIsr:     h.UnderMinIsr,
+		partitionHealthOffline:         h.Offline,
+	}
+}
+
+func isPartitionOffline(partition kadm.PartitionDetail) bool {
+	return partition.Leader < 0 || errors.Is(partition.Err, kerr.LeaderNotAvailable)
+}
+
+// countPartitionHealth classifies the partitions of the given topics. An offline partition is neither counted as
+// under-replicated nor as under min ISR. Topics missing in minInSyncReplicas are not checked for min ISR.
+func countPartitionHealth(topics kadm.TopicDetails, minInSyncReplicas map[string]int) partitionHealth {
+	var health partitionHealth
+	for _, topic := range topics.Sorted() {
+		for _, partition := range topic.Partitions.Sorted() {
+			name := fmt.Sprintf("%s-%d", topic.Topic, partition.Partition)
+			if isPartitionOffline(partition) {
+				health.Offline = append(health.Offline, name)
+				continue
+			}
+			if len(partition.ISR) < len(partition.Replicas) {
+				health.UnderReplicated = append(health.UnderReplicated, name)
+			}
+			if minIsr, ok := minInSyncReplicas[topic.Topic]; ok && len(partition.ISR) < minIsr {
+				health.UnderMinIsr = append(health.UnderMinIsr, name)
+			}
+		}
+	}
+	return health
+}
+
+// describeMinInSyncReplicas returns the effective min.insync.replicas of the given topics. Topics whose config
+// can't be described are left out.
+func describeMinInSyncReplicas(ctx context.Context, adminClient *kadm.Client, topics ...string) (map[string]int, error) {
+	configs, err := describeTopicConfigValues(ctx, adminClient, []string{"min.insync.replicas"}, topics...)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]int, len(configs))
+	for topic, values := range configs {
+		if value, err := strconv.Atoi(values["min.insync.replicas"]); err == nil {
+			result[topic] = value
+		}
+	}
+	return result, nil
+}
@@ -26,6 +26,7 @@ type ExecutionRunData struct {
 	metrics               chan action_kit_api.Metric // stores the metrics for each execution
 	requestCounter        atomic.Uint64              // stores the number of requests for each execution
 	requestSuccessCounter atomic.Uint64              // stores the number of successful requests for each execution
+	tombstoneCounter      atomic.Uint64              // stores the index of the next tombstone key for each execution
 }
 
 var (
@@ -101,6 +102,7 @@ func initExecutionRunData(state *KafkaBrokerAttackState) {
 		metrics:               make(chan action_kit_api.Metric, state.MaxConcurrent),
 		requestCounter:        atomic.Uint64{},
 		requestSuccessCounter: atomic.Uint64{},
+		tombstoneCounter:      atomic.Uint64{},
 	})
 }
 
@@ -155,6 +157,10 @@ func requestProducerWorker(executionRunData *ExecutionRunData, state *KafkaBroke
 				continue
 			}
 			rec := createRecord(state)
+			if state.Tombstone {
+				// Concurrent workers must not pick the same tombstone key
+				rec = createTombstoneRecord(state, executionRunData.tombstoneCounter.Add(1)-1)
+			}
 			_, err = client.ProduceSync(executionRunData.ctx, rec).First()
 			executionRunData.requestCounter.Add(1)
 			if err != nil {
@@ -0,0 +1,367 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type produceTombstonesAction struct{}
+
+const (
+	cleanupPolicy = "cleanup.policy"
+
+	tombstoneKeySourceExplicit = "explicit"
+	tombstoneKeySourceSampled  = "sampled"
+	tombstoneKeySourcePattern  = "pattern"
+
+	tombstoneKeyPatternPlaceholder = "{i}"
+)
+
+// Make sure Action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[KafkaBrokerAttackState]           = (*produceTombstonesAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[KafkaBrokerAttackState] = (*produceTombstonesAction)(nil)
+	_ action_kit_sdk.ActionWithStop[KafkaBrokerAttackState]   = (*produceTombstonesAction)(nil)
+)
+
+func NewProduceTombstonesAction() action_kit_sdk.Action[KafkaBrokerAttackState] {
+	return &produceTombstonesAction{}
+}
+
+func (l *produceTombstonesAction) NewEmptyState() KafkaBrokerAttackState {
+	return KafkaBrokerAttackState{}
+}
+
+// Describe returns the action description for the platform with all required information.
+func (l *produceTombstonesAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.produce-tombstones", kafkaTopicTargetId),
+		Label:       "Produce Tombstones",
+		Description: "Produce tombstones (records with a null value) to a compacted topic at a constant rate, simulating deletes for state stores and CDC consumers. Keys are given explicitly, sampled from recent records or generated from a pattern.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: kafkaTopicTargetId,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "topic name",
+					Description: new("Find topic by cluster and name"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.topic.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		Kind:        action_kit_api.Attack,
+		TimeControl: action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "keySource",
+				Label:        "Key source",
+				Description:  new("How the keys of the tombstones are determined. 'Explicit': use the given record keys. 'Sampled': use keys of the most recent records in the topic. 'Pattern': generate keys from a pattern."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(tombstoneKeySourceExplicit),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Explicit",
+						Value: tombstoneKeySourceExplicit,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Sampled from recent records",
+						Value: tombstoneKeySourceSampled,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Pattern",
+						Value: tombstoneKeySourcePattern,
+					},
+				}),
+				Required: new(true),
+			},
+			{
+				Name:        "recordKeys",
+				Label:       "Record keys",
+				Description: new("Only used with key source 'Explicit'. The keys to produce tombstones for. Keys are used round-robin."),
+				Type:        action_kit_api.ActionParameterTypeStringArray,
+			},
+			{
+				Name:        "keyPattern",
+				Label:       "Key pattern",
+				Description: new("Only used with key source 'Pattern'. The pattern of the generated keys, where {i} is replaced by a running number starting at 0, e.g. 'customer-{i}'."),
+				Type:        action_kit_api.ActionParameterTypeString,
+			},
+			{
+				Name:         "numberOfKeys",
+				Label:        "Number of keys",
+				Description:  new("With key source 'Pattern': how many keys are generated from the pattern. With key source 'Sampled': the maximum number of distinct keys sampled from the most recent records."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("10"),
+				MinValue:     new(1),
+				MaxValue:     new(10000),
+			},
+			recordHeaders,
+			{
+				Name:  "-",
+				Label: "-",
+				Type:  action_kit_api.ActionParameterTypeSeparator,
+				Order: new(6),
+			},
+			{
+				Name:         "recordsPerSecond",
+				Label:        "Tombstones per second",
+				Description:  new("The number of tombstones per second. Should be between 1 and 10."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1"),
+				MinValue:     new(1),
+				MaxValue:     new(10),
+				Required:     new(true),
+			},
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("How long the producer runs. Tombstones are produced continuously for this duration."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("10s"),
+				Required:     new(true),
+			},
+			{
+				Name:  "-",
+				Label: "-",
+				Type:  action_kit_api.ActionParameterTypeSeparator,
+				Order: new(10),
+			},
+			successRate,
+			maxConcurrent,
+			{
+				Name:         "force",
+				Label:        "Force on non-compacted topics",
+				Description:  new("By default, the attack refuses to run on topics whose cleanup.policy doesn't include 'compact'. Enable to produce tombstones anyway."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("false"),
+				Advanced:     new(true),
+				Required:     new(false),
+			},
+		},
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("1s"),
+		}),
+		Stop: new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (l *produceTombstonesAction) Prepare(ctx context.Context, state *KafkaBrokerAttackState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	if len(request.Target.Attributes["kafka.topic.name"]) == 0 {
+		return nil, fmt.Errorf("the target is missing the kafka.topic.name attribute")
+	}
+	topicName := extutil.MustHaveValue(request.Target.Attributes, "kafka.topic.name")[0]
+
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+	brokerHosts := strings.Split(clusterConfig.SeedBrokers, ",")
+
+	if !extutil.ToBool(request.Config["force"]) {
+		if err := ensureCompactedTopic(ctx, brokerHosts, clusterConfig, topicName); err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := resolveTombstoneKeys(ctx, brokerHosts, clusterConfig, topicName, request.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	state.Tombstone = true
+	state.TombstoneKeys = keys
+	state.DelayBetweenRequestsInMS = getDelayBetweenRequestsInMsPeriodically(extutil.ToInt64(request.Config["recordsPerSecond"]))
+	return prepare(request, state, func(executionRunData *ExecutionRunData, state *KafkaBrokerAttackState) bool { return false })
+}
+
+func (l *produceTombstonesAction) Start(_ context.Context, state *KafkaBrokerAttackState) (*action_kit_api.StartResult, error) {
+	start(state)
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Producing tombstones to topic %s for %d key(s)", state.Topic, len(state.TombstoneKeys)),
+		}},
+	}, nil
+}
+
+func (l *produceTombstonesAction) Status(_ context.Context, state *KafkaBrokerAttackState) (*action_kit_api.StatusResult, error) {
+	executionRunData, err := loadExecutionRunData(state.ExecutionID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load execution run data")
+		return nil, err
+	}
+	latestMetrics := retrieveLatestMetrics(executionRunData.metrics)
+	return &action_kit_api.StatusResult{
+		Completed: false,
+		Metrics:   new(latestMetrics),
+	}, nil
+}
+
+func (l *produceTombstonesAction) Stop(_ context.Context, state *KafkaBrokerAttackState) (*action_kit_api.StopResult, error) {
+	return stop(state)
+}
+
+func createTombstoneRecord(state *KafkaBrokerAttackState, index uint64) *kgo.Record {
+	record := createRecord(state)
+	if len(state.TombstoneKeys) > 0 {
+		record.Key = []byte(state.TombstoneKeys[index%uint64(len(state.TombstoneKeys))])
+	}
+	// A nil value (in contrast to an empty one) marks the record as a tombstone
+	record.Value = nil
+	return record
+}
+
+func ensureCompactedTopic(ctx context.Context, brokers []string, clusterConfig *config.ClusterConfig, topic string) error {
+	adminClient, err := createNewAdminClientWithConfig(brokers, clusterConfig)
+	if err != nil {
+		return err
+	}
+	defer adminClient.Close()
+
+	policy, err := describeTopicConfigOf(ctx, adminClient, cleanupPolicy, topic)
+	if err != nil {
+		return fmt.Errorf("failed to describe %s of topic %s: %w", cleanupPolicy, topic, err)
+	}
+	if !isCompactedCleanupPolicy(policy) {
+		return fmt.Errorf("topic %s is not compacted (%s=%s). Enable 'Force on non-compacted topics' to produce tombstones anyway", topic, cleanupPolicy, policy)
+	}
+	return nil
+}
+
+func isCompactedCleanupPolicy(policy string) bool {
+	for p := range strings.SplitSeq(policy, ",") {
+		if strings.TrimSpace(p) == "compact" {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveTombstoneKeys(ctx context.Context, brokers []string, clusterConfig *config.ClusterConfig, topic string, cfg map[string]any) ([]string, error) {
+	keySource := extutil.ToString(cfg["keySource"])
+	numberOfKeys := extutil.ToInt(cfg["numberOfKeys"])
+
+	var keys []string
+	var err error
+	switch keySource {
+	case tombstoneKeySourceExplicit, "":
+		keys = extutil.ToStringArray(cfg["recordKeys"])
+	case tombstoneKeySourcePattern:
+		keys, err = generateKeysFromPattern(extutil.ToString(cfg["keyPattern"]), numberOfKeys)
+	case tombstoneKeySourceSampled:
+		keys, err = sampleRecentKeys(ctx, brokers, clusterConfig, topic, numberOfKeys)
+	default:
+		return nil, fmt.Errorf("unknown key source '%s'", keySource)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys to produce tombstones for (key source '%s')", keySource)
+	}
+	return keys, nil
+}
+
+func generateKeysFromPattern(pattern string, numberOfKeys int) ([]string, error) {
+	if !strings.Contains(pattern, tombstoneKeyPatternPlaceholder) {
+		return nil, fmt.Errorf("key pattern '%s' must contain the placeholder %s", pattern, tombstoneKeyPatternPlaceholder)
+	}
+	keys := make([]string, 0, numberOfKeys)
+	for i := range numberOfKeys {
+		keys = append(keys, strings.ReplaceAll(pattern, tombstoneKeyPatternPlaceholder, strconv.Itoa(i)))
+	}
+	return keys, nil
+}
+
+// sampleRecentKeys reads the most recent records of every partition of the topic and returns up to
+// sampleSize distinct, non-null keys.
+func sampleRecentKeys(ctx context.Context, brokers []string, clusterConfig *config.ClusterConfig, topic string, sampleSize int) ([]string, error) {
+	adminClient, err := createNewAdminClientWithConfig(brokers, clusterConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer adminClient.Close()
+
+	startOffsets, err := adminClient.ListStartOffsets(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	endOffsets, err := adminClient.ListEndOffsets(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	if endOffsets.Error() != nil {
+		return nil, endOffsets.Error()
+	}
+
+	partitions := make(map[int32]kgo.Offset)
+	remaining := make(map[int32]int64)
+	endOffsets.Each(func(endOffset kadm.ListedOffset) {
+		from := endOffset.Offset - int64(sampleSize)
+		if startOffset, found := startOffsets.Lookup(topic, endOffset.Partition); found {
+			from = max(from, startOffset.Offset)
+		}
+		if from < endOffset.Offset {
+			partitions[endOffset.Partition] = kgo.NewOffset().At(max(from, 0))
+			remaining[endOffset.Partition] = endOffset.Offset
+		}
+	})
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("topic %s has no records to sample keys from", topic)
+	}
+
+	client, err := createNewClientWithConfig(brokers, clusterConfig, kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: partitions}))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	pollCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	seen := make(map[string]bool)
+	keys := make([]string, 0, sampleSize)
+	for len(remaining) > 0 && len(keys) < sampleSize {
+		fetches := client.PollFetches(pollCtx)
+		if pollCtx.Err() != nil {
+			log.Debug().Msgf("Stopped sampling keys of topic %s after timeout, %d key(s) sampled", topic, len(keys))
+			break
+		}
+		if errs := fetches.Errors(); len(errs) > 0 {
+			return nil, fmt.Errorf("failed to sample keys of topic %s partition %d: %w", errs[0].Topic, errs[0].Partition, errs[0].Err)
+		}
+		fetches.EachRecord(func(record *kgo.Record) {
+			if record.Offset+1 >= remaining[record.Partition] {
+				delete(remaining, record.Partition)
+			}
+			if record.Key == nil || len(keys) >= sampleSize || seen[string(record.Key)] {
+				return
+			}
+			seen[string(record.Key)] = true
+			keys = append(keys, string(record.Key))
+		})
+	}
+
+	log.Info().Msgf("Sampled %d key(s) from topic %s", len(keys), topic)
+	return keys, nil
+}
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestProduceTombstones_Describe(t *testing.T) {
+	//Given
+	action := produceTombstonesAction{}
+
+	//When
+	response := action.Describe()
+
+	//Then
+	assert.Equal(t, "Produce Tombstones", response.Label)
+	assert.Equal(t, fmt.Sprintf("%s.produce-tombstones", kafkaTopicTargetId), response.Id)
+	assert.Equal(t, kafkaTopicTargetId, response.TargetSelection.TargetType)
+	assert.Equal(t, new("Kafka"), response.Technology)
+	assert.NotNil(t, response.Stop)
+}
+
+func TestProduceTombstones_generateKeysFromPattern(t *testing.T) {
+	keys, err := generateKeysFromPattern("customer-{i}", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"customer-0", "customer-1", "customer-2"}, keys)
+
+	_, err = generateKeysFromPattern("customer", 3)
+	assert.EqualError(t, err, "key pattern 'customer' must contain the placeholder {i}")
+}
+
+func TestProduceTombstones_isCompactedCleanupPolicy(t *testing.T) {
+	assert.True(t, isCompactedCleanupPolicy("compact"))
+	assert.True(t, isCompactedCleanupPolicy("compact,delete"))
+	assert.True(t, isCompactedCleanupPolicy("delete, compact"))
+	assert.False(t, isCompactedCleanupPolicy("delete"))
+	assert.False(t, isCompactedCleanupPolicy(""))
+}
+
+func TestProduceTombstones_createTombstoneRecord(t *testing.T) {
+	state := &KafkaBrokerAttackState{
+		Topic:         "steadybit",
+		Tombstone:     true,
+		TombstoneKeys: []string{"a", "b"},
+		RecordHeaders: map[string]string{"source": "steadybit"},
+	}
+
+	first := createTombstoneRecord(state, 0)
+	second := createTombstoneRecord(state, 1)
+	third := createTombstoneRecord(state, 2)
+
+	assert.Equal(t, "a", string(first.Key))
+	assert.Equal(t, "b", string(second.Key))
+	assert.Equal(t, "a", string(third.Key))
+	assert.Nil(t, first.Value)
+	assert.Equal(t, "steadybit", first.Topic)
+	assert.Len(t, first.Headers, 1)
+}
+
+func TestProduceTombstones_Prepare(t *testing.T) {
+	c, err := kfake.NewCluster(
+		kfake.SeedTopics(1, "steadybit"),
+		kfake.NumBrokers(1),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {
+			SeedBrokers: strings.Join(c.ListenAddrs(), ","),
+		},
+	})
+
+	client, err := kgo.NewClient(kgo.SeedBrokers(c.ListenAddrs()...))
+	require.NoError(t, err)
+	defer client.Close()
+	for _, key := range []string{"a", "b", "a", "c"} {
+		require.NoError(t, client.ProduceSync(t.Context(), &kgo.Record{Topic: "steadybit", Key: []byte(key), Value: []byte("value")}).FirstErr())
+	}
+
+	action := produceTombstonesAction{}
+	prepareRequest := func(cfg map[string]any) action_kit_api.PrepareActionRequestBody {
+		return extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+			Target: &action_kit_api.Target{
+				Attributes: map[string][]string{
+					"kafka.topic.name":   {"steadybit"},
+					"kafka.cluster.name": {"test-cluster"},
+				},
+			},
+			Config:      cfg,
+			ExecutionId: uuid.New(),
+		})
+	}
+
+	t.Run("refuses non-compacted topics", func(t *testing.T) {
+		//Given
+		state := action.NewEmptyState()
+
+		//When
+		_, err := action.Prepare(t.Context(), &state, prepareRequest(map[string]any{
+			"keySource":        tombstoneKeySourceExplicit,
+			"recordKeys":       []string{"a"},
+			"recordsPerSecond": 1,
+			"duration":         10000,
+		}))
+
+		//Then
+		assert.ErrorContains(t, err, "topic steadybit is not compacted")
+	})
+
+	t.Run("samples the most recent keys", func(t *testing.T) {
+		//Given
+		state := action.NewEmptyState()
+
+		//When
+		_, err := action.Prepare(t.Context(), &state, prepareRequest(map[string]any{
+			"keySource":        tombstoneKeySourceSampled,
+			"numberOfKeys":     2,
+			"recordsPerSecond": 1,
+			"maxConcurrent":    1,
+			"duration":         10000,
+			"force":            true,
+		}))
+		t.Cleanup(func() { _, _ = stop(&state) })
+
+		//Then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "c"}, state.TombstoneKeys)
+		assert.True(t, state.Tombstone)
+	})
+}
@@ -0,0 +1,291 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+type ScramCredentialInvalidationAttack struct{}
+
+// ScramCredentialInvalidationState never contains passwords, the restore password is looked up in the cluster config
+// when the attack stops.
+type ScramCredentialInvalidationState struct {
+	User        string
+	CredInfos   []ScramCredInfo
+	RestoreMode string
+	BrokerHosts []string
+	ClusterName string // Cluster name for multi-cluster support
+}
+
+type ScramCredInfo struct {
+	Mechanism  int8
+	Iterations int32
+}
+
+const (
+	scramRestoreModeConfigured = "configured"
+	scramRestoreModeRegenerate = "regenerate"
+)
+
+// Make sure Action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[ScramCredentialInvalidationState]         = (*ScramCredentialInvalidationAttack)(nil)
+	_ action_kit_sdk.ActionWithStop[ScramCredentialInvalidationState] = (*ScramCredentialInvalidationAttack)(nil)
+)
+
+func NewScramCredentialInvalidationAttack() action_kit_sdk.Action[ScramCredentialInvalidationState] {
+	return &ScramCredentialInvalidationAttack{}
+}
+
+func (k *ScramCredentialInvalidationAttack) NewEmptyState() ScramCredentialInvalidationState {
+	return ScramCredentialInvalidationState{}
+}
+
+func (k *ScramCredentialInvalidationAttack) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.invalidate-credential", kafkaScramUserTargetId),
+		Label:       "Invalidate SCRAM Credential",
+		Description: "Temporarily replace the SCRAM credential of a user with a random one, so that clients using this user fail to authenticate. Reproduces credential-rotation incidents. When the attack ends, the credential is restored from the password configured for the user in the extension's cluster configuration, or regenerated. To block a user on specific topics only, use Deny Access instead.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: kafkaScramUserTargetId,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "SCRAM user name",
+					Description: new("Find SCRAM user by cluster and name"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.scram-user.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		TimeControl: action_kit_api.TimeControlExternal,
+		Kind:        action_kit_api.Attack,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Label:        "Duration",
+				Description:  new("How long the credential stays invalid. The credential is restored when the duration expires."),
+				Name:         "duration",
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("60s"),
+				Required:     new(true),
+			},
+			{
+				Label:        "Restore mode",
+				Description:  new("How the credential is restored when the attack ends. 'Configured password': use the password configured for the user via SCRAM_RESTORE_PASSWORDS in the cluster configuration. 'Regenerate': set a new random password that is not disclosed, only useful if the credential is reconciled by an external tool (e.g. an operator)."),
+				Name:         "restoreMode",
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(scramRestoreModeConfigured),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Configured password",
+						Value: scramRestoreModeConfigured,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Regenerate",
+						Value: scramRestoreModeRegenerate,
+					},
+				}),
+				Required: new(true),
+			},
+		},
+		Stop: new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (k *ScramCredentialInvalidationAttack) Prepare(ctx context.Context, state *ScramCredentialInvalidationState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	if len(request.Target.Attributes["kafka.scram-user.name"]) == 0 {
+		return nil, fmt.Errorf("the target is missing the kafka.scram-user.name attribute")
+	}
+	state.User = request.Target.Attributes["kafka.scram-user.name"][0]
+
+	// Get cluster name from target
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+
+	if clusterConfig.SaslUser == state.User {
+		return nil, fmt.Errorf("the SCRAM credential of user %s can't be invalidated, the extension itself uses this user to connect to the cluster", state.User)
+	}
+
+	state.RestoreMode = extutil.ToString(request.Config["restoreMode"])
+	switch state.RestoreMode {
+	case scramRestoreModeConfigured, "":
+		state.RestoreMode = scramRestoreModeConfigured
+		if _, ok := clusterConfig.GetScramRestorePassword(state.User); !ok {
+			return nil, fmt.Errorf("no restore password configured for SCRAM user %s in cluster %s. Configure it via SCRAM_RESTORE_PASSWORDS or use the restore mode 'Regenerate'", state.User, clusterName)
+		}
+	case scramRestoreModeRegenerate:
+	default:
+		return nil, fmt.Errorf("unknown restore mode '%s'", state.RestoreMode)
+	}
+
+	client, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	described, err := client.DescribeUserSCRAMs(ctx, state.User)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe SCRAM credentials of user %s: %w", state.User, err)
+	}
+	user, ok := described[state.User]
+	if ok && user.Err != nil {
+		return nil, fmt.Errorf("failed to describe SCRAM credentials of user %s: %s %s", state.User, user.Err.Error(), user.ErrMessage)
+	}
+	state.CredInfos = make([]ScramCredInfo, 0, len(user.CredInfos))
+	for _, credInfo := range user.CredInfos {
+		state.CredInfos = append(state.CredInfos, ScramCredInfo{Mechanism: int8(credInfo.Mechanism), Iterations: credInfo.Iterations})
+	}
+	if len(state.CredInfos) == 0 {
+		return nil, fmt.Errorf("user %s has no SCRAM credentials", state.User)
+	}
+	return nil, nil
+}
+
+func (k *ScramCredentialInvalidationAttack) Start(ctx context.Context, state *ScramCredentialInvalidationState) (*action_kit_api.StartResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	password, err := generateScramPassword()
+	if err != nil {
+		return nil, err
+	}
+	// One mechanism at a time, so that the applied ones are known if a later one fails
+	for i, credInfo := range state.CredInfos {
+		if err := alterScramCredentials(ctx, state.BrokerHosts, clusterConfig, toScramUpserts(state.User, []ScramCredInfo{credInfo}, password)); err != nil {
+			// Don't leave the user with only a part of the credentials replaced
+			if i > 0 {
+				if _, restoreErr := restoreScramCredentials(ctx, state, clusterConfig, state.CredInfos[:i]); restoreErr != nil {
+					log.Error().Err(restoreErr).Msgf("Failed to roll back the SCRAM credential(s) of user %s", state.User)
+					return nil, errors.Join(err, restoreErr)
+				}
+			}
+			return nil, err
+		}
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Replaced the SCRAM credential(s) of user %s (%s) with a random password", state.User, joinScramMechanisms(state.CredInfos)),
+		}},
+	}, nil
+}
+
+func (k *ScramCredentialInvalidationAttack) Stop(ctx context.Context, state *ScramCredentialInvalidationState) (*action_kit_api.StopResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	message, err := restoreScramCredentials(ctx, state, clusterConfig, state.CredInfos)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Msg(message)
+
+	return &action_kit_api.StopResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: message,
+		}},
+	}, nil
+}
+
+// restoreScramCredentials replaces the given credentials of the user according to the restore mode and describes
+// what was done.
+func restoreScramCredentials(ctx context.Context, state *ScramCredentialInvalidationState, clusterConfig *config.ClusterConfig, credInfos []ScramCredInfo) (string, error) {
+	var password string
+	var message string
+	if state.RestoreMode == scramRestoreModeRegenerate {
+		var err error
+		password, err = generateScramPassword()
+		if err != nil {
+			return "", err
+		}
+		message = fmt.Sprintf("Regenerated the SCRAM credential(s) of user %s (%s)", state.User, joinScramMechanisms(credInfos))
+	} else {
+		var ok bool
+		password, ok = clusterConfig.GetScramRestorePassword(state.User)
+		if !ok {
+			return "", fmt.Errorf("no restore password configured for SCRAM user %s in cluster %s, the credential couldn't be restored", state.User, state.ClusterName)
+		}
+		message = fmt.Sprintf("Restored the SCRAM credential(s) of user %s (%s) from the configured password", state.User, joinScramMechanisms(credInfos))
+	}
+
+	if err := alterScramCredentials(ctx, state.BrokerHosts, clusterConfig, toScramUpserts(state.User, credInfos, password)); err != nil {
+		return "", err
+	}
+	return message, nil
+}
+
+func toScramUpserts(user string, credInfos []ScramCredInfo, password string) []kadm.UpsertSCRAM {
+	upserts := make([]kadm.UpsertSCRAM, 0, len(credInfos))
+	for _, credInfo := range credInfos {
+		upserts = append(upserts, kadm.UpsertSCRAM{
+			User:       user,
+			Mechanism:  kadm.ScramMechanism(credInfo.Mechanism),
+			Iterations: credInfo.Iterations,
+			Password:   password,
+		})
+	}
+	return upserts
+}
+
+func alterScramCredentials(ctx context.Context, brokers []string, clusterConfig *config.ClusterConfig, upserts []kadm.UpsertSCRAM) error {
+	client, err := createNewAdminClientWithConfig(brokers, clusterConfig)
+	if err != nil {
+		return fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer client.Close()
+
+	results, err := client.AlterUserSCRAMs(ctx, nil, upserts)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	results.EachError(func(result kadm.AlteredUserSCRAM) {
+		errs = append(errs, fmt.Errorf("%w: %s", result.Err, result.ErrMessage))
+	})
+	return errors.Join(errs...)
+}
+
+func generateScramPassword() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func joinScramMechanisms(credInfos []ScramCredInfo) string {
+	mechanisms := make([]string, 0, len(credInfos))
+	for _, credInfo := range credInfos {
+		mechanisms = append(mechanisms, kadm.ScramMechanism(credInfo.Mechanism).String())
+	}
+	return strings.Join(mechanisms, ", ")
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestScramCredentialInvalidation_Describe(t *testing.T) {
+	//Given
+	action := ScramCredentialInvalidationAttack{}
+
+	//When
+	response := action.Describe()
+
+	//Then
+	assert.Equal(t, "Invalidate SCRAM Credential", response.Label)
+	assert.Equal(t, kafkaScramUserTargetId, response.TargetSelection.TargetType)
+	assert.Equal(t, fmt.Sprintf("%s.invalidate-credential", kafkaScramUserTargetId), response.Id)
+	assert.Equal(t, new("Kafka"), response.Technology)
+	assert.NotNil(t, response.Stop)
+}
+
+func TestScramCredentialInvalidation_Prepare(t *testing.T) {
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {
+			SeedBrokers: "localhost:9092",
+			SaslUser:    "steadybit",
+		},
+	})
+
+	tests := []struct {
+		name        string
+		user        string
+		restoreMode string
+		wantedError string
+	}{
+		{
+			name:        "Should refuse the extension's own user",
+			user:        "steadybit",
+			restoreMode: scramRestoreModeRegenerate,
+			wantedError: "the SCRAM credential of user steadybit can't be invalidated, the extension itself uses this user to connect to the cluster",
+		},
+		{
+			name:        "Should require a configured restore password",
+			user:        "alice",
+			restoreMode: scramRestoreModeConfigured,
+			wantedError: "no restore password configured for SCRAM user alice in cluster test-cluster. Configure it via SCRAM_RESTORE_PASSWORDS or use the restore mode 'Regenerate'",
+		},
+		{
+			name:        "Should reject unknown restore mode",
+			user:        "alice",
+			restoreMode: "keep",
+			wantedError: "unknown restore mode 'keep'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//Given
+			action := ScramCredentialInvalidationAttack{}
+			state := action.NewEmptyState()
+			request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+				Target: &action_kit_api.Target{
+					Attributes: map[string][]string{
+						"kafka.scram-user.name": {tt.user},
+						"kafka.cluster.name":    {"test-cluster"},
+					},
+				},
+				Config: map[string]any{
+					"duration":    60000,
+					"restoreMode": tt.restoreMode,
+				},
+				ExecutionId: uuid.New(),
+			})
+
+			//When
+			_, err := action.Prepare(t.Context(), &state, request)
+
+			//Then
+			assert.EqualError(t, err, tt.wantedError)
+		})
+	}
+}
+
+func TestScramCredentialInvalidation_toScramUpserts(t *testing.T) {
+	credInfos := []ScramCredInfo{
+		{Mechanism: int8(kadm.ScramSha256), Iterations: 4096},
+		{Mechanism: int8(kadm.ScramSha512), Iterations: 8192},
+	}
+
+	upserts := toScramUpserts("alice", credInfos, "secret")
+
+	require.Len(t, upserts, 2)
+	assert.Equal(t, kadm.UpsertSCRAM{User: "alice", Mechanism: kadm.ScramSha256, Iterations: 4096, Password: "secret"}, upserts[0])
+	assert.Equal(t, kadm.UpsertSCRAM{User: "alice", Mechanism: kadm.ScramSha512, Iterations: 8192, Password: "secret"}, upserts[1])
+	assert.Equal(t, "SCRAM-SHA-256, SCRAM-SHA-512", joinScramMechanisms(credInfos))
+}
+
+func TestScramCredentialInvalidation_generateScramPassword(t *testing.T) {
+	first, err := generateScramPassword()
+	require.NoError(t, err)
+	second, err := generateScramPassword()
+	require.NoError(t, err)
+	assert.Len(t, first, 43)
+	assert.NotEqual(t, first, second)
+}
@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_commons"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+type kafkaScramUserDiscovery struct {
+}
+
+var (
+	_ discovery_kit_sdk.TargetDescriber    = (*kafkaScramUserDiscovery)(nil)
+	_ discovery_kit_sdk.AttributeDescriber = (*kafkaScramUserDiscovery)(nil)
+)
+
+func NewKafkaScramUserDiscovery(ctx context.Context) discovery_kit_sdk.TargetDiscovery {
+	discovery := &kafkaScramUserDiscovery{}
+	return discovery_kit_sdk.NewCachedTargetDiscovery(discovery,
+		discovery_kit_sdk.WithRefreshTargetsNow(),
+		discovery_kit_sdk.WithRefreshTargetsInterval(ctx, time.Duration(config.Config.DiscoveryIntervalKafkaScramUser)*time.Second),
+	)
+}
+
+func (r *kafkaScramUserDiscovery) Describe() discovery_kit_api.DiscoveryDescription {
+	return discovery_kit_api.DiscoveryDescription{
+		Id: kafkaScramUserTargetId,
+		Discover: discovery_kit_api.DescribingEndpointReferenceWithCallInterval{
+			CallInterval: new(fmt.Sprintf("%ds", config.Config.DiscoveryIntervalKafkaScramUser)),
+		},
+	}
+}
+
+func (r *kafkaScramUserDiscovery) DescribeTarget() discovery_kit_api.TargetDescription {
+	return discovery_kit_api.TargetDescription{
+		Id:       kafkaScramUserTargetId,
+		Label:    discovery_kit_api.PluralLabel{One: "Kafka SCRAM User", Other: "Kafka SCRAM Users"},
+		Category: new("kafka"),
+		Version:  extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:     new(kafkaIcon),
+		Table: discovery_kit_api.Table{
+			Columns: []discovery_kit_api.Column{
+				{Attribute: "steadybit.label"},
+				{Attribute: "kafka.cluster.name"},
+				{Attribute: "kafka.scram-user.mechanisms"},
+			},
+			OrderBy: []discovery_kit_api.OrderBy{
+				{
+					Attribute: "steadybit.label",
+					Direction: "ASC",
+				},
+			},
+		},
+	}
+}
+
+func (r *kafkaScramUserDiscovery) DescribeAttributes() []discovery_kit_api.AttributeDescription {
+	return []discovery_kit_api.AttributeDescription{
+		{
+			Attribute: "kafka.scram-user.name",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka SCRAM user name",
+				Other: "Kafka SCRAM user names",
+			},
+		},
+		{
+			Attribute: "kafka.scram-user.mechanisms",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka SCRAM mechanism",
+				Other: "Kafka SCRAM mechanisms",
+			},
+		},
+		{
+			Attribute: "kafka.scram-user.iterations",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka SCRAM iterations",
+				Other: "Kafka SCRAM iterations",
+			},
+		},
+		{
+			Attribute: "kafka.scram-user.restorable",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka SCRAM credential restorable",
+				Other: "Kafka SCRAM credentials restorable",
+			},
+		},
+	}
+}
+
+func (r *kafkaScramUserDiscovery) DiscoverTargets(ctx context.Context) ([]discovery_kit_api.Target, error) {
+	return getAllScramUsersMultiCluster(ctx)
+}
+
+func getAllScramUsersMultiCluster(ctx context.Context) ([]discovery_kit_api.Target, error) {
+	RetryPendingClusters()
+	clusters := config.GetAllClusterConfigs()
+
+	type clusterResult struct {
+		targets []discovery_kit_api.Target
+		err     error
+	}
+
+	resultChan := make(chan clusterResult, len(clusters))
+
+	// Discover from all clusters in parallel
+	for clusterName, clusterConfig := range clusters {
+		go func(name string, cfg *config.ClusterConfig) {
+			targets, err := discoverScramUsersForCluster(ctx, name, cfg)
+			resultChan <- clusterResult{targets: targets, err: err}
+		}(clusterName, clusterConfig)
+	}
+
+	// Collect results
+	allTargets := make([]discovery_kit_api.Target, 0, 20*len(clusters))
+	var errorList []error
+
+	for i := 0; i < len(clusters); i++ {
+		result := <-resultChan
+		if result.err != nil {
+			errorList = append(errorList, result.err)
+		} else {
+			allTargets = append(allTargets, result.targets...)
+		}
+	}
+
+	// Fail only if all clusters failed
+	if len(errorList) == len(clusters) && len(clusters) > 0 {
+		return nil, fmt.Errorf("failed to discover from all clusters: %v", errorList)
+	}
+
+	return discovery_kit_commons.ApplyAttributeExcludes(allTargets, config.Config.DiscoveryAttributesExcludesScramUsers), nil
+}
+
+func discoverScramUsersForCluster(ctx context.Context, clusterName string, clusterConfig *config.ClusterConfig) ([]discovery_kit_api.Target, error) {
+	result := make([]discovery_kit_api.Target, 0, 20)
+
+	client, err := createNewAdminClientWithConfig(strings.Split(clusterConfig.SeedBrokers, ","), clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client for cluster %s: %s", clusterName, err.Error())
+	}
+	defer client.Close()
+
+	// Describing without users lists all users having SCRAM credentials
+	users, err := client.DescribeUserSCRAMs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe SCRAM users for cluster %s: %v", clusterName, err)
+	}
+
+	for _, user := range users.Sorted() {
+		if user.Err != nil {
+			continue
+		}
+		result = append(result, toScramUserTarget(user, clusterName, clusterConfig))
+	}
+
+	return result, nil
+}
+
+func toScramUserTarget(user kadm.DescribedUserSCRAM, clusterName string, clusterConfig *config.ClusterConfig) discovery_kit_api.Target {
+	id := fmt.Sprintf("%s-%s", user.User, clusterName)
+
+	mechanisms := make([]string, 0, len(user.CredInfos))
+	iterations := make([]string, 0, len(user.CredInfos))
+	for _, credInfo := range user.CredInfos {
+		mechanisms = append(mechanisms, credInfo.Mechanism.String())
+		iterations = append(iterations, fmt.Sprintf("%s=%d", credInfo.Mechanism, credInfo.Iterations))
+	}
+	_, restorable := clusterConfig.GetScramRestorePassword(user.User)
+
+	attributes := make(map[string][]string)
+	attributes["kafka.cluster.name"] = []string{clusterName}
+	attributes["kafka.cluster.id"] = []string{clusterConfig.ClusterID}
+	attributes["kafka.scram-user.name"] = []string{user.User}
+	attributes["kafka.scram-user.mechanisms"] = mechanisms
+	attributes["kafka.scram-user.iterations"] = iterations
+	attributes["kafka.scram-user.restorable"] = []string{strconv.FormatBool(restorable)}
+
+	return discovery_kit_api.Target{
+		Id:         id,
+		Label:      user.User,
+		TargetType: kafkaScramUserTargetId,
+		Attributes: attributes,
+	}
+}
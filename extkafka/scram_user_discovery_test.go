@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"testing"
+
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestScramUserDiscovery_DescribeTarget(t *testing.T) {
+	td := (&kafkaScramUserDiscovery{}).DescribeTarget()
+	assert.Equal(t, kafkaScramUserTargetId, td.Id)
+	assert.Equal(t, "Kafka SCRAM User", td.Label.One)
+	assert.Equal(t, "Kafka SCRAM Users", td.Label.Other)
+	assert.Equal(t, "kafka", *td.Category)
+}
+
+func TestToScramUserTarget(t *testing.T) {
+	user := kadm.DescribedUserSCRAM{
+		User: "alice",
+		CredInfos: []kadm.CredInfo{
+			{Mechanism: kadm.ScramSha256, Iterations: 4096},
+			{Mechanism: kadm.ScramSha512, Iterations: 8192},
+		},
+	}
+	clusterConfig := &config.ClusterConfig{
+		ClusterID:             "internal-id-42",
+		ScramRestorePasswords: map[string]string{"alice": "secret"},
+	}
+
+	tgt := toScramUserTarget(user, "cluster-42", clusterConfig)
+
+	assert.Equal(t, "alice-cluster-42", tgt.Id)
+	assert.Equal(t, "alice", tgt.Label)
+	assert.Equal(t, kafkaScramUserTargetId, tgt.TargetType)
+	assert.Equal(t, []string{"cluster-42"}, tgt.Attributes["kafka.cluster.name"])
+	assert.Equal(t, []string{"internal-id-42"}, tgt.Attributes["kafka.cluster.id"])
+	assert.Equal(t, []string{"alice"}, tgt.Attributes["kafka.scram-user.name"])
+	assert.Equal(t, []string{"SCRAM-SHA-256", "SCRAM-SHA-512"}, tgt.Attributes["kafka.scram-user.mechanisms"])
+	assert.Equal(t, []string{"SCRAM-SHA-256=4096", "SCRAM-SHA-512=8192"}, tgt.Attributes["kafka.scram-user.iterations"])
+	assert.Equal(t, []string{"true"}, tgt.Attributes["kafka.scram-user.restorable"])
+}
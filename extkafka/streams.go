@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Kafka Streams names its internal topics <application.id>-<name>-changelog and <application.id>-<name>-repartition,
+// while the application.id is also the group id of the application's consumer group.
+const (
+	streamsChangelogSuffix   = "-changelog"
+	streamsRepartitionSuffix = "-repartition"
+)
+
+func isStreamsInternalTopic(topic string) bool {
+	return strings.HasSuffix(topic, streamsChangelogSuffix) || strings.HasSuffix(topic, streamsRepartitionSuffix)
+}
+
+func isStreamsChangelogTopic(topic string) bool {
+	return strings.HasSuffix(topic, streamsChangelogSuffix)
+}
+
+// streamsInternalTopicsOf returns the sorted internal topics belonging to the Streams application with the given id.
+func streamsInternalTopicsOf(applicationID string, topics []string) []string {
+	var result []string
+	for _, topic := range topics {
+		if isStreamsInternalTopic(topic) && strings.HasPrefix(topic, applicationID+"-") {
+			result = append(result, topic)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// streamsApplicationOf returns the group owning the internal topic. If multiple group names are a prefix, the longest
+// one is the most specific match.
+func streamsApplicationOf(topic string, groups []string) (string, bool) {
+	if !isStreamsInternalTopic(topic) {
+		return "", false
+	}
+	applicationID := ""
+	for _, group := range groups {
+		if strings.HasPrefix(topic, group+"-") && len(group) > len(applicationID) {
+			applicationID = group
+		}
+	}
+	return applicationID, applicationID != ""
+}
+
+// addStreamsAttributes marks the consumer group as Kafka Streams application if it owns internal topics. Every stream
+// thread joins the group with its own consumer, so the members are the stream threads.
+func addStreamsAttributes(attributes map[string][]string, groupName string, topics []string, members int) {
+	internalTopics := streamsInternalTopicsOf(groupName, topics)
+	if len(internalTopics) == 0 {
+		return
+	}
+	attributes["kafka.consumer-group.streams.application-id"] = []string{groupName}
+	attributes["kafka.consumer-group.streams.internal-topics"] = internalTopics
+	attributes["kafka.consumer-group.streams.threads"] = []string{strconv.Itoa(members)}
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddStreamsAttributes(t *testing.T) {
+	//Given
+	topics := []string{
+		"orders",
+		"order-enricher-KSTREAM-AGGREGATE-STATE-STORE-0000000003-changelog",
+		"order-enricher-KSTREAM-KEY-SELECT-0000000001-repartition",
+		"order-enricher-v2-store-changelog",
+		"other-app-store-changelog",
+	}
+
+	//When
+	streams := map[string][]string{}
+	addStreamsAttributes(streams, "order-enricher", topics, 4)
+	plain := map[string][]string{}
+	addStreamsAttributes(plain, "orders-consumer", topics, 2)
+
+	//Then
+	assert.Equal(t, []string{"order-enricher"}, streams["kafka.consumer-group.streams.application-id"])
+	assert.Equal(t, []string{
+		"order-enricher-KSTREAM-AGGREGATE-STATE-STORE-0000000003-changelog",
+		"order-enricher-KSTREAM-KEY-SELECT-0000000001-repartition",
+		"order-enricher-v2-store-changelog",
+	}, streams["kafka.consumer-group.streams.internal-topics"])
+	assert.Equal(t, []string{"4"}, streams["kafka.consumer-group.streams.threads"])
+	assert.Empty(t, plain)
+}
+
+func TestStreamsApplicationOf(t *testing.T) {
+	groups := []string{"order-enricher", "order-enricher-v2", "orders-consumer"}
+
+	app, ok := streamsApplicationOf("order-enricher-v2-store-changelog", groups)
+	assert.True(t, ok)
+	assert.Equal(t, "order-enricher-v2", app)
+
+	app, ok = streamsApplicationOf("order-enricher-KSTREAM-KEY-SELECT-0000000001-repartition", groups)
+	assert.True(t, ok)
+	assert.Equal(t, "order-enricher", app)
+
+	_, ok = streamsApplicationOf("order-enricher-output", groups)
+	assert.False(t, ok)
+	_, ok = streamsApplicationOf("unknown-store-changelog", groups)
+	assert.False(t, ok)
+}
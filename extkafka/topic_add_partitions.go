@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type AddPartitionsAttack struct{}
+
+type AddPartitionsState struct {
+	Topic             string
+	CurrentPartitions int
+	NewPartitions     int
+	SampledKeys       int
+	RemappedKeys      int
+	BrokerHosts       []string
+	ClusterName       string // Cluster name for multi-cluster support
+}
+
+var _ action_kit_sdk.Action[AddPartitionsState] = (*AddPartitionsAttack)(nil)
+
+func NewAddPartitionsAttack() action_kit_sdk.Action[AddPartitionsState] {
+	return &AddPartitionsAttack{}
+}
+
+func (k *AddPartitionsAttack) NewEmptyState() AddPartitionsState {
+	return AddPartitionsState{}
+}
+
+func (k *AddPartitionsAttack) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.add-partitions", kafkaTopicTargetId),
+		Label:       "Add Partitions",
+		Description: "Increase the partition count of the topic. Records with the same key may then be written to a different partition than before, breaking the key ordering for consumers. Before the partitions are added, a sample of recent keys is read and the fraction of keys that are remapped to another partition by the default (murmur2) partitioner is reported. Partitions can't be removed again, this attack is irreversible.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(kafkaIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: kafkaTopicTargetId,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label:       "topic name",
+					Description: new("Find topic by cluster and name"),
+					Query:       "kafka.cluster.name=\"\" AND kafka.topic.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Kafka"),
+		Category:    new("Kafka"),
+		TimeControl: action_kit_api.TimeControlInstantaneous,
+		Kind:        action_kit_api.Attack,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Label:       "New partition count",
+				Description: new("The total number of partitions of the topic after the attack. Must be greater than the current partition count."),
+				Name:        "partitionCount",
+				Type:        action_kit_api.ActionParameterTypeInteger,
+				MinValue:    new(2),
+				Required:    new(true),
+			},
+			{
+				Label:        "Key sample size",
+				Description:  new("How many of the most recent records are read to determine the fraction of remapped keys. 0 skips the sampling."),
+				Name:         "keySampleSize",
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1000"),
+				MinValue:     new(0),
+				Required:     new(true),
+			},
+			{
+				Label:        "I understand that this is irreversible",
+				Description:  new("Partitions can't be removed from a topic. The topic keeps the new partition count after the attack, restoring it requires recreating the topic."),
+				Name:         "acknowledgeIrreversible",
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("false"),
+				Required:     new(true),
+			},
+		},
+	}
+}
+
+func (k *AddPartitionsAttack) Prepare(ctx context.Context, state *AddPartitionsState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	state.Topic = extutil.MustHaveValue(request.Target.Attributes, "kafka.topic.name")[0]
+
+	// Get cluster name from target
+	clusterName := extutil.MustHaveValue(request.Target.Attributes, "kafka.cluster.name")[0]
+	clusterConfig, err := config.GetClusterConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+	state.ClusterName = clusterName
+	state.BrokerHosts = strings.Split(clusterConfig.SeedBrokers, ",")
+
+	if !extutil.ToBool(request.Config["acknowledgeIrreversible"]) {
+		return nil, fmt.Errorf("adding partitions to topic %s is irreversible, acknowledge this to run the attack", state.Topic)
+	}
+	state.NewPartitions = extutil.ToInt(request.Config["partitionCount"])
+	keySampleSize := extutil.ToInt(request.Config["keySampleSize"])
+
+	adminClient, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer adminClient.Close()
+
+	topicDetails, err := adminClient.ListTopics(ctx, state.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve topic %s: %w", state.Topic, err)
+	}
+	topicDetail, ok := topicDetails[state.Topic]
+	if !ok || topicDetail.Err != nil {
+		return nil, fmt.Errorf("failed to retrieve topic %s: %v", state.Topic, topicDetail.Err)
+	}
+	state.CurrentPartitions = len(topicDetail.Partitions)
+	if state.NewPartitions <= state.CurrentPartitions {
+		return nil, fmt.Errorf("the new partition count %d must be greater than the current partition count %d of topic %s", state.NewPartitions, state.CurrentPartitions, state.Topic)
+	}
+
+	if keySampleSize > 0 {
+		// An empty topic has no keys to remap, the attack is not refused because of that
+		keys, err := sampleRecentKeys(ctx, state.BrokerHosts, clusterConfig, state.Topic, keySampleSize)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to sample keys of topic %s", state.Topic)
+		}
+		state.SampledKeys = len(keys)
+		state.RemappedKeys = countRemappedKeys(keys, state.CurrentPartitions, state.NewPartitions)
+	}
+
+	return &action_kit_api.PrepareResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Warn),
+			Message: describeKeyRemapping(state),
+		}},
+	}, nil
+}
+
+func (k *AddPartitionsAttack) Start(ctx context.Context, state *AddPartitionsState) (*action_kit_api.StartResult, error) {
+	clusterConfig, err := config.GetClusterConfig(state.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+
+	adminClient, err := createNewAdminClientWithConfig(state.BrokerHosts, clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka client: %s", err.Error())
+	}
+	defer adminClient.Close()
+
+	responses, err := adminClient.UpdatePartitions(ctx, state.NewPartitions, state.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add partitions to topic %s: %w", state.Topic, err)
+	}
+	response, err := responses.On(state.Topic, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add partitions to topic %s: %w", state.Topic, err)
+	}
+	if response.Err != nil {
+		return nil, fmt.Errorf("failed to add partitions to topic %s: %s %s", state.Topic, response.Err.Error(), response.ErrMessage)
+	}
+	log.Info().Msgf("Increased the partition count of topic %s from %d to %d", state.Topic, state.CurrentPartitions, state.NewPartitions)
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{{
+			Level:   extutil.Ptr(action_kit_api.Info),
+			Message: fmt.Sprintf("Increased the partition count of topic %s from %d to %d. %s", state.Topic, state.CurrentPartitions, state.NewPartitions, describeKeyRemapping(state)),
+		}},
+	}, nil
+}
+
+// countRemappedKeys counts the keys the default partitioner assigns to a different partition with the new partition count.
+func countRemappedKeys(keys []string, currentPartitions int, newPartitions int) int {
+	partitioner := kgo.StickyKeyPartitioner(nil).ForTopic("")
+	remapped := 0
+	for _, key := range keys {
+		record := &kgo.Record{Key: []byte(key)}
+		if partitioner.Partition(record, currentPartitions) != partitioner.Partition(record, newPartitions) {
+			remapped++
+		}
+	}
+	return remapped
+}
+
+func describeKeyRemapping(state *AddPartitionsState) string {
+	if state.SampledKeys == 0 {
+		return fmt.Sprintf("No keys sampled for topic %s, the impact on the key ordering is unknown.", state.Topic)
+	}
+	return fmt.Sprintf("%d of %d sampled keys (%.1f%%) of topic %s move to a different partition when increasing the partition count from %d to %d.",
+		state.RemappedKeys, state.SampledKeys, float64(state.RemappedKeys)*100/float64(state.SampledKeys), state.Topic, state.CurrentPartitions, state.NewPartitions)
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extkafka
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kafka/config"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddPartitions_Describe(t *testing.T) {
+	//Given
+	action := AddPartitionsAttack{}
+
+	//When
+	response := action.Describe()
+
+	//Then
+	assert.Equal(t, "Add Partitions", response.Label)
+	assert.Equal(t, kafkaTopicTargetId, response.TargetSelection.TargetType)
+	assert.Equal(t, fmt.Sprintf("%s.add-partitions", kafkaTopicTargetId), response.Id)
+	assert.Equal(t, new("Kafka"), response.Technology)
+}
+
+func TestAddPartitions_PrepareRequiresAcknowledgement(t *testing.T) {
+	config.SetClustersForTest(map[string]*config.ClusterConfig{
+		"test-cluster": {
+			SeedBrokers: "localhost:9092",
+		},
+	})
+
+	//Given
+	action := AddPartitionsAttack{}
+	state := action.NewEmptyState()
+	request := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"kafka.topic.name":   {"orders"},
+				"kafka.cluster.name": {"test-cluster"},
+			},
+		},
+		Config: map[string]any{
+			"partitionCount":          6,
+			"keySampleSize":           1000,
+			"acknowledgeIrreversible": false,
+		},
+		ExecutionId: uuid.New(),
+	})
+
+	//When
+	_, err := action.Prepare(t.Context(), &state, request)
+
+	//Then
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "irreversible")
+}
+
+func TestAddPartitions_countRemappedKeys(t *testing.T) {
+	keys := make([]string, 0, 1000)
+	for i := range 1000 {
+		keys = append(keys, fmt.Sprintf("key-%d", i))
+	}
+
+	assert.Equal(t, 0, countRemappedKeys(keys, 3, 3))
+	assert.Equal(t, 0, countRemappedKeys(keys, 1, 1))
+
+	// Doubling the partition count keeps about half of the keys on their partition
+	remapped := countRemappedKeys(keys, 3, 6)
+	assert.Greater(t, remapped, 400)
+	assert.Less(t, remapped, 600)
+}
+
+func TestAddPartitions_describeKeyRemapping(t *testing.T) {
+	assert.Equal(t, "No keys sampled for topic orders, the impact on the key ordering is unknown.",
+		describeKeyRemapping(&AddPartitionsState{Topic: "orders", CurrentPartitions: 3, NewPartitions: 6}))
+	assert.Equal(t, "25 of 100 sampled keys (25.0%) of topic orders move to a different partition when increasing the partition count from 3 to 4.",
+		describeKeyRemapping(&AddPartitionsState{Topic: "orders", CurrentPartitions: 3, NewPartitions: 4, SampledKeys: 100, RemappedKeys: 25}))
+}
@@ -6,12 +6,14 @@ package extkafka
 import (
 	"context"
 	"fmt"
+	"github.com/rs/zerolog/log"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_commons"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
 	"github.com/steadybit/extension-kafka/config"
 	"github.com/steadybit/extension-kit/extbuild"
 	"github.com/twmb/franz-go/pkg/kadm"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -112,6 +114,48 @@ func (r *kafkaTopicDiscovery) DescribeAttributes() []discovery_kit_api.Attribute
 				Other: "Kafka topic replication factors",
 			},
 		},
+		{
+			Attribute: "kafka.topic.min-insync-replicas",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka topic min in-sync replicas",
+				Other: "Kafka topic min in-sync replicas",
+			},
+		},
+		{
+			Attribute: "kafka.topic.broker-failures-tolerated",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka topic broker failures tolerated",
+				Other: "Kafka topic broker failures tolerated",
+			},
+		},
+		{
+			Attribute: "kafka.topic.unclean-leader-election",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka topic unclean leader election",
+				Other: "Kafka topic unclean leader elections",
+			},
+		},
+		{
+			Attribute: "kafka.topic.rack-aware",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka topic rack aware",
+				Other: "Kafka topic rack aware",
+			},
+		},
+		{
+			Attribute: "kafka.topic.ephemeral",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka ephemeral topic",
+				Other: "Kafka ephemeral topics",
+			},
+		},
+		{
+			Attribute: "kafka.topic.streams-application-id",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kafka Streams application id",
+				Other: "Kafka Streams application ids",
+			},
+		},
 	}
 }
 
@@ -178,9 +222,32 @@ func discoverTopicsForCluster(ctx context.Context, clusterName string, clusterCo
 		return nil, fmt.Errorf("failed to get brokers metadata for cluster %s: %v", clusterName, err)
 	}
 
+	// Groups are only needed to link Kafka Streams internal topics to their application
+	var groups []string
+	listedGroups, err := client.ListGroups(ctx)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to list groups for cluster %s, Kafka Streams internal topics aren't linked", clusterName)
+	} else {
+		groups = listedGroups.Groups()
+	}
+
+	// The resilience attributes are optional, describing the configs requires the DESCRIBE_CONFIGS permission
+	configs, err := describeTopicConfigValues(ctx, client, []string{"min.insync.replicas", "unclean.leader.election.enable"}, topicDetails.Names()...)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to describe topic configs for cluster %s, resilience attributes aren't added", clusterName)
+	}
+
 	for _, t := range topicDetails {
 		if !t.IsInternal {
-			result = append(result, toTopicTarget(t, clusterName, metadata.Cluster))
+			target := toTopicTarget(t, clusterName, metadata.Cluster)
+			if configs != nil {
+				addResilienceAttributes(target.Attributes, t, configs[t.Topic], metadata.Brokers)
+			}
+			addEphemeralTopicAttributes(target.Attributes, t.Topic)
+			if applicationID, ok := streamsApplicationOf(t.Topic, groups); ok {
+				target.Attributes["kafka.topic.streams-application-id"] = []string{applicationID}
+			}
+			result = append(result, target)
 		}
 	}
 
@@ -233,3 +300,53 @@ func toTopicTarget(topic kadm.TopicDetail, clusterName string, clusterID string)
 		Attributes: attributes,
 	}
 }
+
+// addEphemeralTopicAttributes marks topics created by the ephemeral topic action.
+func addEphemeralTopicAttributes(attributes map[string][]string, topic string) {
+	if strings.HasPrefix(topic, ephemeralTopicPrefix) {
+		attributes["kafka.topic.ephemeral"] = []string{"true"}
+	}
+}
+
+// describeTopicConfigValues returns the effective values of the given config keys per topic, including values
+// inherited from the broker defaults. Topics whose configs couldn't be described are missing.
+func describeTopicConfigValues(ctx context.Context, adminClient *kadm.Client, keys []string, topics ...string) (map[string]map[string]string, error) {
+	result := make(map[string]map[string]string, len(topics))
+	if len(topics) == 0 {
+		return result, nil
+	}
+	configs, err := adminClient.DescribeTopicConfigs(ctx, topics...)
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range configs {
+		if resource.Err != nil {
+			continue
+		}
+		values := make(map[string]string, len(keys))
+		for _, c := range resource.Configs {
+			if slices.Contains(keys, c.Key) && c.Value != nil {
+				values[c.Key] = *c.Value
+			}
+		}
+		result[resource.Name] = values
+	}
+	return result, nil
+}
+
+// addResilienceAttributes adds the attributes the resilience advice is evaluated against. Query conditions can't
+// compare attributes, so the replicas that may fail while acks=all producers keep succeeding are added precomputed.
+func addResilienceAttributes(attributes map[string][]string, topic kadm.TopicDetail, configs map[string]string, brokers kadm.BrokerDetails) {
+	minInSyncReplicas := map[string]int{}
+	if value, err := strconv.Atoi(configs["min.insync.replicas"]); err == nil {
+		minInSyncReplicas[topic.Topic] = value
+		attributes["kafka.topic.min-insync-replicas"] = []string{strconv.Itoa(value)}
+		attributes["kafka.topic.broker-failures-tolerated"] = []string{strconv.Itoa(max(topic.Partitions.NumReplicas()-value, 0))}
+	}
+	if value, ok := configs["unclean.leader.election.enable"]; ok {
+		attributes["kafka.topic.unclean-leader-election"] = []string{strconv.FormatBool(value == "true")}
+	}
+	// A topic is rack-aware if every partition spans at least two racks and stays available if any rack fails
+	violations := validateRackAwareness(kadm.TopicDetails{topic.Topic: topic}, brokers, minInSyncReplicas, 2)
+	attributes["kafka.topic.rack-aware"] = []string{strconv.FormatBool(len(violations) == 0)}
+}
@@ -53,6 +53,12 @@ func TestDescribeAttributesTopic(t *testing.T) {
 		"kafka.topic.partitions-replicas",
 		"kafka.topic.partitions-isr",
 		"kafka.topic.replication-factor",
+		"kafka.topic.min-insync-replicas",
+		"kafka.topic.broker-failures-tolerated",
+		"kafka.topic.unclean-leader-election",
+		"kafka.topic.rack-aware",
+		"kafka.topic.ephemeral",
+		"kafka.topic.streams-application-id",
 	}
 
 	require.Len(t, attrs, len(expected))
@@ -113,6 +119,41 @@ func TestToTopicTargetTopic(t *testing.T) {
 	check("kafka.topic.replication-factor", []string{"2"})
 }
 
+func TestAddResilienceAttributes(t *testing.T) {
+	//Given
+	brokers := kadm.BrokerDetails{
+		{NodeID: 1, Rack: new("a")},
+		{NodeID: 2, Rack: new("b")},
+		{NodeID: 3, Rack: new("c")},
+		{NodeID: 4, Rack: new("a")},
+	}
+	rackAware := kadm.TopicDetail{Topic: "orders", Partitions: kadm.PartitionDetails{
+		0: {Partition: 0, Leader: 1, Replicas: []int32{1, 2, 3}, ISR: []int32{1, 2, 3}},
+	}}
+	sameRack := kadm.TopicDetail{Topic: "payments", Partitions: kadm.PartitionDetails{
+		0: {Partition: 0, Leader: 1, Replicas: []int32{1, 4}, ISR: []int32{1, 4}},
+	}}
+
+	//When
+	rackAwareAttributes := map[string][]string{}
+	addResilienceAttributes(rackAwareAttributes, rackAware, map[string]string{"min.insync.replicas": "2", "unclean.leader.election.enable": "false"}, brokers)
+	sameRackAttributes := map[string][]string{}
+	addResilienceAttributes(sameRackAttributes, sameRack, map[string]string{"min.insync.replicas": "2"}, brokers)
+
+	//Then
+	assert.Equal(t, map[string][]string{
+		"kafka.topic.min-insync-replicas":       {"2"},
+		"kafka.topic.broker-failures-tolerated": {"1"},
+		"kafka.topic.unclean-leader-election":   {"false"},
+		"kafka.topic.rack-aware":                {"true"},
+	}, rackAwareAttributes)
+	assert.Equal(t, map[string][]string{
+		"kafka.topic.min-insync-replicas":       {"2"},
+		"kafka.topic.broker-failures-tolerated": {"0"},
+		"kafka.topic.rack-aware":                {"false"},
+	}, sameRackAttributes)
+}
+
 // TestDiscoverTargetsClusterName verifies that the kafka.cluster.name attribute
 // is correctly set when discovering topics against a fake Kafka cluster.
 func TestDiscoverTopicTargetsClusterName(t *testing.T) {
@@ -9,6 +9,7 @@ import (
 	"github.com/steadybit/action-kit/go/action_kit_api/v2"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // resolveStatusCodeExpression resolves the given status code expression into a list of status codes
@@ -71,3 +72,19 @@ func resolveStatusCodeExpression(statusCodes string) ([]int, *action_kit_api.Act
 	}
 	return result, nil
 }
+
+// parseTimestampMillis parses an RFC 3339 timestamp or Unix milliseconds into Unix milliseconds
+func parseTimestampMillis(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("a timestamp is required")
+	}
+	if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return millis, nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("timestamp '%s' is neither an RFC 3339 timestamp nor Unix milliseconds", value)
+	}
+	return timestamp.UnixMilli(), nil
+}
@@ -6,6 +6,7 @@ package extkafka
 import (
 	"github.com/steadybit/action-kit/go/action_kit_api/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
 )
 
@@ -84,3 +85,19 @@ func Test_resolveStatusCodeExpression(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseTimestampMillis(t *testing.T) {
+	millis, err := parseTimestampMillis("1738324800000")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1738324800000), millis)
+
+	millis, err = parseTimestampMillis("2025-01-31T12:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1738324800000), millis)
+
+	_, err = parseTimestampMillis("yesterday")
+	assert.Error(t, err)
+
+	_, err = parseTimestampMillis(" ")
+	assert.Error(t, err)
+}
@@ -93,7 +93,7 @@ func main() {
 }
 
 // ExtensionListResponse exists to merge the possible root path responses supported by the
-// various extension kits. In this case, the response for ActionKit, DiscoveryKit and EventKit.
+// various extension kits. In this case, the response for ActionKit, DiscoveryKit, EventKit and AdviceKit.
 type ExtensionListResponse struct {
 	action_kit_api.ActionList       `json:",inline"`
 	discovery_kit_api.DiscoveryList `json:",inline"`
@@ -105,19 +105,42 @@ func registerHandlers(ctx context.Context) {
 	discovery_kit_sdk.Register(extkafka.NewKafkaBrokerDiscovery(ctx))
 	discovery_kit_sdk.Register(extkafka.NewKafkaTopicDiscovery(ctx))
 	discovery_kit_sdk.Register(extkafka.NewKafkaConsumerGroupDiscovery(ctx))
+	discovery_kit_sdk.Register(extkafka.NewKafkaScramUserDiscovery(ctx))
+	discovery_kit_sdk.Register(extkafka.NewKafkaClusterDiscovery(ctx))
 	action_kit_sdk.RegisterAction(extkafka.NewProduceMessageActionPeriodically())
 	action_kit_sdk.RegisterAction(extkafka.NewProduceMessageActionFixedAmount())
+	action_kit_sdk.RegisterAction(extkafka.NewProduceTombstonesAction())
+	action_kit_sdk.RegisterAction(extkafka.NewConsumeFetchLoadAction())
 	action_kit_sdk.RegisterAction(extkafka.NewConsumerGroupCheckAction())
+	action_kit_sdk.RegisterAction(extkafka.NewConsumerGroupRebalanceCheckAction())
+	action_kit_sdk.RegisterAction(extkafka.NewOffsetContinuityCheckAction())
 	action_kit_sdk.RegisterAction(extkafka.NewConsumerGroupLagCheckAction())
+	action_kit_sdk.RegisterAction(extkafka.NewConsumerLagTrendCheckAction())
+	action_kit_sdk.RegisterAction(extkafka.NewStreamsRestoreCheckAction())
+	action_kit_sdk.RegisterAction(extkafka.NewProduceCanaryCheckAction())
+	action_kit_sdk.RegisterAction(extkafka.NewTopicThroughputCheckAction())
+	action_kit_sdk.RegisterAction(extkafka.NewRackAwarenessCheckAction())
 	action_kit_sdk.RegisterAction(extkafka.NewKafkaBrokerElectNewLeaderAttack())
 	action_kit_sdk.RegisterAction(extkafka.NewDeleteRecordsAttack())
+	action_kit_sdk.RegisterAction(extkafka.NewAddPartitionsAttack())
+	action_kit_sdk.RegisterAction(extkafka.NewEphemeralTopicAction())
 	action_kit_sdk.RegisterAction(extkafka.NewAlterMaxMessageBytesAttack())
 	action_kit_sdk.RegisterAction(extkafka.NewAlterNumberIOThreadsAttack())
 	action_kit_sdk.RegisterAction(extkafka.NewAlterNumberNetworkThreadsAttack())
 	action_kit_sdk.RegisterAction(extkafka.NewAlterLimitConnectionCreateRateAttack())
+	action_kit_sdk.RegisterAction(extkafka.NewBrokerConnectionFloodAttack())
+	action_kit_sdk.RegisterAction(extkafka.NewAlterReplicationThrottleAttack())
+	action_kit_sdk.RegisterAction(extkafka.NewMoveLogDirsAttack())
 	action_kit_sdk.RegisterAction(extkafka.NewKafkaConsumerDenyAccessAttack())
+	action_kit_sdk.RegisterAction(extkafka.NewScramCredentialInvalidationAttack())
 	action_kit_sdk.RegisterAction(extkafka.NewPartitionsCheckAction())
+	action_kit_sdk.RegisterAction(extkafka.NewPartitionHealthCheckAction())
 	action_kit_sdk.RegisterAction(extkafka.NewBrokersCheckAction())
+	action_kit_sdk.RegisterAction(extkafka.NewQuorumCheckAction())
+	action_kit_sdk.RegisterAction(extkafka.NewBrokerLossImpactCheckAction())
+	action_kit_sdk.RegisterAction(extkafka.NewBrokerLogDirsCheckAction())
+
+	extkafka.RegisterAdviceHandlers()
 
 	exthttp.RegisterRevisionedHandler("/", getExtensionList)
 }
@@ -150,6 +173,10 @@ func getExtensionList() ExtensionListResponse {
 		// See this document to learn more about the discovery list:
 		// https://github.com/steadybit/discovery-kit/blob/main/docs/discovery-api.md#index-response
 		DiscoveryList: discovery_kit_sdk.GetDiscoveryList(),
+
+		// See this document to learn more about the advice list:
+		// https://github.com/steadybit/advice-kit/blob/main/docs/advice-api.md#index-response
+		AdviceList: extkafka.GetAdviceList(),
 	}
 }
 